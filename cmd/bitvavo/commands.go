@@ -0,0 +1,505 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+var errAborted = errors.New("aborted")
+
+// stdin is read by confirm.
+var stdin io.Reader = os.Stdin
+
+// timeValue is a flag accepting RFC3339 or unix milliseconds.
+type timeValue struct {
+	time time.Time
+}
+
+func (t *timeValue) String() string {
+	if t.time.IsZero() {
+		return ""
+	}
+	return t.time.Format(time.RFC3339)
+}
+
+func (t *timeValue) Set(value string) error {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		t.time = time.UnixMilli(ms)
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return fmt.Errorf("invalid time '%s', expected RFC3339 or unix milliseconds", value)
+	}
+	t.time = parsed
+	return nil
+}
+
+// parse parses the flags of a command and checks the number of positional arguments.
+func parse(fs *flag.FlagSet, args []string, min int, max int) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() < min || fs.NArg() > max {
+		return nil, usageError(fs)
+	}
+	return fs.Args(), nil
+}
+
+// parseInterval parses a candle interval argument.
+func parseInterval(value string) (bitvavo.Interval, error) {
+	if interval := bitvavo.ParseInterval(value); interval != nil {
+		return *interval, nil
+	}
+	return bitvavo.Interval{}, fmt.Errorf("invalid interval '%s'", value)
+}
+
+func usageError(fs *flag.FlagSet) error {
+	if cmd, ok := commands[fs.Name()]; ok {
+		return fmt.Errorf("usage: bitvavo %s", cmd.usage)
+	}
+	return fmt.Errorf("usage: bitvavo %s", fs.Name())
+}
+
+// rangeFlags registers the limit, start and end flags shared by the history commands.
+func rangeFlags(fs *flag.FlagSet) (*uint64, *timeValue, *timeValue) {
+	var (
+		limit = fs.Uint64("limit", 0, "return the limit most recent results only")
+		start = new(timeValue)
+		end   = new(timeValue)
+	)
+	fs.Var(start, "start", "return results after start")
+	fs.Var(end, "end", "return results before end")
+	return limit, start, end
+}
+
+func runMarkets(ctx context.Context, a *app, args []string) error {
+	args, err := parse(flag.NewFlagSet("markets", flag.ContinueOnError), args, 0, 1)
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		market, err := a.public().GetMarket(ctx, strings.ToUpper(args[0]))
+		if err != nil {
+			return err
+		}
+		return a.printer.print(market)
+	}
+	markets, err := a.public().GetMarkets(ctx)
+	if err != nil {
+		return err
+	}
+	return a.printer.print(markets)
+}
+
+func runAssets(ctx context.Context, a *app, args []string) error {
+	args, err := parse(flag.NewFlagSet("assets", flag.ContinueOnError), args, 0, 1)
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		asset, err := a.public().GetAsset(ctx, strings.ToUpper(args[0]))
+		if err != nil {
+			return err
+		}
+		return a.printer.print(asset)
+	}
+	assets, err := a.public().GetAssets(ctx)
+	if err != nil {
+		return err
+	}
+	return a.printer.print(assets)
+}
+
+type bookRow struct {
+	Side  string `json:"side"`
+	Price string `json:"price"`
+	Size  string `json:"size"`
+}
+
+func runBook(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("book", flag.ContinueOnError)
+	depth := fs.Uint64("depth", 25, "number of bids and asks")
+	args, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	book, err := a.public().GetOrderBook(ctx, strings.ToUpper(args[0]), *depth)
+	if err != nil {
+		return err
+	}
+	if a.printer.format == formatJSON {
+		return a.printer.print(book)
+	}
+
+	rows := make([]bookRow, 0, len(book.Bids)+len(book.Asks))
+	for i := len(book.Asks) - 1; i >= 0; i-- {
+		rows = append(rows, bookRow{Side: "ask", Price: book.Asks[i].Price, Size: book.Asks[i].Size})
+	}
+	for _, bid := range book.Bids {
+		rows = append(rows, bookRow{Side: "bid", Price: bid.Price, Size: bid.Size})
+	}
+	return a.printer.print(rows)
+}
+
+func runTrades(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("trades", flag.ContinueOnError)
+	limit, start, end := rangeFlags(fs)
+	args, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	trades, err := a.public().GetTrades(ctx, strings.ToUpper(args[0]), &bitvavo.TradeParams{
+		Limit: *limit,
+		Start: start.time,
+		End:   end.time,
+	})
+	if err != nil {
+		return err
+	}
+	return a.printer.print(trades)
+}
+
+func runCandles(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("candles", flag.ContinueOnError)
+	interval := fs.String("interval", bitvavo.Interval1h.Value, "candle interval (e.g: 1m, 1h, 8h)")
+	limit, start, end := rangeFlags(fs)
+	args, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	candleInterval, err := parseInterval(*interval)
+	if err != nil {
+		return err
+	}
+
+	candles, err := a.public().GetCandles(ctx, strings.ToUpper(args[0]), candleInterval, &bitvavo.CandleParams{
+		Limit: *limit,
+		Start: start.time,
+		End:   end.time,
+	})
+	if err != nil {
+		return err
+	}
+	return a.printer.print(candles)
+}
+
+func runTicker(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("ticker", flag.ContinueOnError)
+	book := fs.Bool("book", false, "show the best bid and best ask instead of the price")
+	day := fs.Bool("24h", false, "show the statistics of the last 24 hours instead of the price")
+	args, err := parse(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+
+	client := a.public()
+	if len(args) == 1 {
+		market := strings.ToUpper(args[0])
+		switch {
+		case *day:
+			return printResult(a, func() (bitvavo.Ticker24hData, error) { return client.GetTicker24h(ctx, market) })
+		case *book:
+			return printResult(a, func() (bitvavo.TickerBook, error) { return client.GetTickerBook(ctx, market) })
+		default:
+			return printResult(a, func() (bitvavo.TickerPrice, error) { return client.GetTickerPrice(ctx, market) })
+		}
+	}
+
+	switch {
+	case *day:
+		return printResult(a, func() ([]bitvavo.Ticker24hData, error) { return client.GetTickers24h(ctx) })
+	case *book:
+		return printResult(a, func() ([]bitvavo.TickerBook, error) { return client.GetTickerBooks(ctx) })
+	default:
+		return printResult(a, func() ([]bitvavo.TickerPrice, error) { return client.GetTickerPrices(ctx) })
+	}
+}
+
+func runBalance(ctx context.Context, a *app, args []string) error {
+	args, err := parse(flag.NewFlagSet("balance", flag.ContinueOnError), args, 0, 1)
+	if err != nil {
+		return err
+	}
+	client, err := a.private()
+	if err != nil {
+		return err
+	}
+
+	symbols := make([]string, 0, 1)
+	for _, symbol := range args {
+		symbols = append(symbols, strings.ToUpper(symbol))
+	}
+	return printResult(a, func() ([]bitvavo.Balance, error) { return client.GetBalance(ctx, symbols...) })
+}
+
+func runOrders(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("orders", flag.ContinueOnError)
+	open := fs.Bool("open", false, "only show open orders, the market is optional")
+	limit, start, end := rangeFlags(fs)
+	args, err := parse(fs, args, 0, 1)
+	if err != nil {
+		return err
+	}
+	if !*open && len(args) == 0 {
+		return usageError(fs)
+	}
+
+	client, err := a.private()
+	if err != nil {
+		return err
+	}
+
+	markets := make([]string, 0, 1)
+	for _, market := range args {
+		markets = append(markets, strings.ToUpper(market))
+	}
+	if *open {
+		return printResult(a, func() ([]bitvavo.Order, error) { return client.GetOrdersOpen(ctx, markets...) })
+	}
+	return printResult(a, func() ([]bitvavo.Order, error) {
+		return client.GetOrders(ctx, markets[0], &bitvavo.OrderParams{
+			Limit: *limit,
+			Start: start.time,
+			End:   end.time,
+		})
+	})
+}
+
+func runOrder(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: bitvavo %s", commands["order"].usage)
+	}
+
+	switch args[0] {
+	case "get":
+		return runOrderGet(ctx, a, args[1:])
+	case "create":
+		return runOrderCreate(ctx, a, args[1:])
+	case "cancel":
+		return runOrderCancel(ctx, a, args[1:])
+	case "update":
+		return runOrderUpdate(ctx, a, args[1:])
+	default:
+		return fmt.Errorf("unknown order command: %s", args[0])
+	}
+}
+
+func runOrderGet(ctx context.Context, a *app, args []string) error {
+	args, err := parse(flag.NewFlagSet("order get <market> <orderId>", flag.ContinueOnError), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	client, err := a.private()
+	if err != nil {
+		return err
+	}
+	return printResult(a, func() (bitvavo.Order, error) { return client.GetOrder(ctx, strings.ToUpper(args[0]), args[1]) })
+}
+
+func runOrderCreate(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("order create [flags] <market> <buy|sell> <orderType>", flag.ContinueOnError)
+	var (
+		amount           = fs.String("amount", "", "amount of the base currency")
+		amountQuote      = fs.String("amount-quote", "", "amount of the quote currency (market orders only)")
+		price            = fs.String("price", "", "limit price")
+		triggerAmount    = fs.String("trigger-amount", "", "trigger price (stop and take profit orders only)")
+		triggerReference = fs.String("trigger-reference", "", "trigger reference: lastTrade, bestBid, bestAsk or midPrice")
+		timeInForce      = fs.String("time-in-force", "", "GTC, IOC or FOK (limit orders only)")
+		postOnly         = fs.Bool("post-only", false, "cancel the order if it would fill against existing orders")
+	)
+	args, err := parse(fs, args, 3, 3)
+	if err != nil {
+		return err
+	}
+	side := bitvavo.ParseSide(strings.ToLower(args[1]))
+	if side == nil {
+		return fmt.Errorf("invalid side '%s', expected buy or sell", args[1])
+	}
+	orderType := bitvavo.ParseOrderType(args[2])
+	if orderType == nil {
+		return fmt.Errorf("invalid order type '%s'", args[2])
+	}
+	client, err := a.private()
+	if err != nil {
+		return err
+	}
+
+	order := bitvavo.OrderNew{
+		Amount:           *amount,
+		AmountQuote:      *amountQuote,
+		Price:            *price,
+		TriggerAmount:    *triggerAmount,
+		TriggerReference: bitvavo.OrderTriggerRef{Value: *triggerReference},
+		TimeInForce:      bitvavo.TimeInForce{Value: strings.ToUpper(*timeInForce)},
+		PostOnly:         *postOnly,
+	}
+	if order.TriggerAmount != "" {
+		order.TriggerType = bitvavo.OrderTriggerTypePrice
+	}
+
+	market := strings.ToUpper(args[0])
+	return printResult(a, func() (bitvavo.Order, error) { return client.NewOrder(ctx, market, *side, *orderType, order) })
+}
+
+func runOrderCancel(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("order cancel [-all] [-yes] <market> [orderId]", flag.ContinueOnError)
+	var (
+		all = fs.Bool("all", false, "cancel all open orders, for all markets if no market is given")
+		yes = fs.Bool("yes", false, "do not ask for confirmation when canceling the orders of all markets")
+	)
+	args, err := parse(fs, args, 0, 2)
+	if err != nil {
+		return err
+	}
+	if (*all && len(args) > 1) || (!*all && len(args) != 2) {
+		return usageError(fs)
+	}
+	client, err := a.private()
+	if err != nil {
+		return err
+	}
+
+	if *all {
+		if len(args) == 0 && !*yes && !confirm("cancel all open orders of all markets?") {
+			return errAborted
+		}
+		markets := make([]string, 0, 1)
+		for _, market := range args {
+			markets = append(markets, strings.ToUpper(market))
+		}
+		return printResult(a, func() ([]string, error) { return client.CancelOrders(ctx, markets...) })
+	}
+	return printResult(a, func() (string, error) { return client.CancelOrder(ctx, strings.ToUpper(args[0]), args[1]) })
+}
+
+func runOrderUpdate(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("order update [flags] <market> <orderId>", flag.ContinueOnError)
+	var (
+		amount          = fs.String("amount", "", "new amount of the base currency")
+		amountRemaining = fs.String("amount-remaining", "", "new remaining amount of the base currency")
+		price           = fs.String("price", "", "new limit price")
+		triggerAmount   = fs.String("trigger-amount", "", "new trigger price")
+	)
+	args, err := parse(fs, args, 2, 2)
+	if err != nil {
+		return err
+	}
+	client, err := a.private()
+	if err != nil {
+		return err
+	}
+
+	market, orderId := strings.ToUpper(args[0]), args[1]
+	return printResult(a, func() (bitvavo.Order, error) {
+		return client.UpdateOrder(ctx, market, orderId, bitvavo.OrderUpdate{
+			Amount:          *amount,
+			AmountRemaining: *amountRemaining,
+			Price:           *price,
+			TriggerAmount:   *triggerAmount,
+		})
+	})
+}
+
+func runDeposits(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("deposits", flag.ContinueOnError)
+	symbol := fs.String("symbol", "", "only show deposits of symbol")
+	limit, start, end := rangeFlags(fs)
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	client, err := a.private()
+	if err != nil {
+		return err
+	}
+
+	return printResult(a, func() ([]bitvavo.DepositHistory, error) {
+		return client.GetDepositHistory(ctx, &bitvavo.DepositHistoryParams{
+			Symbol: strings.ToUpper(*symbol),
+			Limit:  *limit,
+			Start:  start.time,
+			End:    end.time,
+		})
+	})
+}
+
+func runWithdrawals(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("withdrawals", flag.ContinueOnError)
+	symbol := fs.String("symbol", "", "only show withdrawals of symbol")
+	limit, start, end := rangeFlags(fs)
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	client, err := a.private()
+	if err != nil {
+		return err
+	}
+
+	return printResult(a, func() ([]bitvavo.WithdrawalHistory, error) {
+		return client.GetWithdrawalHistory(ctx, &bitvavo.WithdrawalHistoryParams{
+			Symbol: strings.ToUpper(*symbol),
+			Limit:  *limit,
+			Start:  start.time,
+			End:    end.time,
+		})
+	})
+}
+
+func runWithdraw(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("withdraw", flag.ContinueOnError)
+	var (
+		paymentId = fs.String("payment-id", "", "payment id, memo or tag of the destination")
+		internal  = fs.Bool("internal", false, "send to another Bitvavo user without fees")
+		addFee    = fs.Bool("add-fee", false, "add the withdrawal fee on top of the amount")
+		yes       = fs.Bool("yes", false, "do not ask for confirmation")
+	)
+	args, err := parse(fs, args, 3, 3)
+	if err != nil {
+		return err
+	}
+	client, err := a.private()
+	if err != nil {
+		return err
+	}
+
+	symbol, amount, address := strings.ToUpper(args[0]), args[1], args[2]
+	if !*yes && !confirm(fmt.Sprintf("withdraw %s %s to %s?", amount, symbol, address)) {
+		return errAborted
+	}
+
+	return printResult(a, func() (bitvavo.WithDrawalResponse, error) {
+		return client.Withdraw(ctx, symbol, amount, address, bitvavo.Withdrawal{
+			PaymentId:        *paymentId,
+			Internal:         *internal,
+			AddWithdrawalFee: *addFee,
+		})
+	})
+}
+
+// confirm asks a yes/no question on stderr and reads the answer from stdin.
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func printResult[T any](a *app, fn func() (T, error)) error {
+	result, err := fn()
+	if err != nil {
+		return err
+	}
+	return a.printer.print(result)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+)
+
+func TestTimeValue(t *testing.T) {
+	value := new(timeValue)
+
+	test.AssertEqual(t, nil, value.Set("1700000000000"))
+	test.AssertEqual(t, int64(1700000000000), value.time.UnixMilli())
+
+	test.AssertEqual(t, nil, value.Set("2024-01-02T15:04:05Z"))
+	test.AssertEqual(t, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC).UnixMilli(), value.time.UnixMilli())
+
+	test.AssertEqual(t, true, value.Set("yesterday") != nil)
+}
+
+func TestParseInterval(t *testing.T) {
+	interval, err := parseInterval("15m")
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, "15m", interval.Value)
+
+	_, err = parseInterval("15x")
+	test.AssertEqual(t, "invalid interval '15x'", err.Error())
+}
+
+// run runs a command without credentials, arguments which parse end at errMissingCredentials.
+func run(t *testing.T, fn func(ctx context.Context, a *app, args []string) error, args ...string) error {
+	t.Helper()
+	t.Setenv("API_KEY", "")
+	t.Setenv("API_SECRET", "")
+	return fn(context.Background(), &app{}, args)
+}
+
+func TestOrderCreateArguments(t *testing.T) {
+	test.AssertEqual(t, errMissingCredentials, run(t, runOrderCreate, "-amount", "1", "eth-eur", "BUY", "stopLossLimit"))
+	test.AssertEqual(t, "usage: bitvavo order create [flags] <market> <buy|sell> <orderType>", run(t, runOrderCreate, "eth-eur", "buy").Error())
+	test.AssertEqual(t, "invalid side 'hold', expected buy or sell", run(t, runOrderCreate, "eth-eur", "hold", "limit").Error())
+	test.AssertEqual(t, "invalid order type 'stoploss'", run(t, runOrderCreate, "eth-eur", "sell", "stoploss").Error())
+}
+
+func TestOrderCancelArguments(t *testing.T) {
+	test.AssertEqual(t, errMissingCredentials, run(t, runOrderCancel, "eth-eur", "id"))
+	test.AssertEqual(t, errMissingCredentials, run(t, runOrderCancel, "-all", "eth-eur"))
+	test.AssertEqual(t, true, run(t, runOrderCancel, "eth-eur") != nil)
+	test.AssertEqual(t, true, run(t, runOrderCancel, "-all", "eth-eur", "id") != nil)
+
+	// canceling the orders of all markets needs a confirmation
+	t.Setenv("API_KEY", "key")
+	t.Setenv("API_SECRET", "secret")
+	defer func() { stdin = os.Stdin }()
+
+	stdin = strings.NewReader("n\n")
+	test.AssertEqual(t, errAborted, runOrderCancel(context.Background(), &app{}, []string{"-all"}))
+}
+
+func TestCandlesArguments(t *testing.T) {
+	test.AssertEqual(t, "invalid interval '1y'", run(t, runCandles, "-interval", "1y", "eth-eur").Error())
+	test.AssertEqual(t, "invalid interval '1y'", run(t, runStream, "-interval", "1y", "candles", "eth-eur").Error())
+}
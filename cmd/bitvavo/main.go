@@ -0,0 +1,142 @@
+// Command bitvavo is a command-line tool for the public and private Bitvavo API.
+//
+// Credentials are read from the API_KEY and API_SECRET environment variables, a .env file in the working directory
+// is loaded when present.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+
+	"github.com/joho/godotenv"
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+var errMissingCredentials = errors.New("API_KEY and API_SECRET must be set in the environment or .env file")
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, app *app, args []string) error
+}
+
+// commands is assigned in init, the commands refer to it for their usage.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"markets":     {"markets [market]", runMarkets},
+		"assets":      {"assets [symbol]", runAssets},
+		"book":        {"book [-depth n] <market>", runBook},
+		"trades":      {"trades [-limit n] [-start time] [-end time] <market>", runTrades},
+		"candles":     {"candles [-interval 1h] [-limit n] [-start time] [-end time] <market>", runCandles},
+		"ticker":      {"ticker [-book] [-24h] [market]", runTicker},
+		"balance":     {"balance [symbol]", runBalance},
+		"orders":      {"orders [-open] [-limit n] [-start time] [-end time] <market>", runOrders},
+		"order":       {"order <get|create|cancel|update> ...", runOrder},
+		"deposits":    {"deposits [-symbol s] [-limit n] [-start time] [-end time]", runDeposits},
+		"withdrawals": {"withdrawals [-symbol s] [-limit n] [-start time] [-end time]", runWithdrawals},
+		"withdraw":    {"withdraw [-payment-id id] [-internal] [-add-fee] [-yes] <symbol> <amount> <address>", runWithdraw},
+		"stream":      {"stream [-interval 1m] <ticker|ticker24h|trades|book|candles|orders|fills> <markets...>", runStream},
+	}
+}
+
+type app struct {
+	printer *printer
+	logger  *slog.Logger
+	options []bitvavo.HttpOption
+}
+
+func (a *app) public() bitvavo.PublicAPI {
+	return bitvavo.NewPublicHTTPClient(a.options...)
+}
+
+func (a *app) private() (bitvavo.PrivateAPI, error) {
+	apiKey, apiSecret, err := credentials()
+	if err != nil {
+		return nil, err
+	}
+	return bitvavo.NewPrivateHTTPClient(apiKey, apiSecret, a.options...), nil
+}
+
+func (a *app) websocketOptions() []bitvavo.WebSocketOption {
+	if a.logger == nil {
+		return nil
+	}
+	return []bitvavo.WebSocketOption{bitvavo.WithWebSocketLogger(a.logger)}
+}
+
+func credentials() (string, string, error) {
+	apiKey, apiSecret := os.Getenv("API_KEY"), os.Getenv("API_SECRET")
+	if apiKey == "" || apiSecret == "" {
+		return "", "", errMissingCredentials
+	}
+	return apiKey, apiSecret, nil
+}
+
+func main() {
+	_ = godotenv.Load()
+
+	output := flag.String("o", string(formatTable), "output format: table or json")
+	debug := flag.Bool("debug", false, "log http requests and websocket events to stderr")
+	trace := flag.Bool("trace", false, "like debug, but also log request and response bodies")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	if *output != string(formatTable) && *output != string(formatJSON) {
+		fmt.Fprintf(os.Stderr, "unknown output format: %s\n", *output)
+		os.Exit(2)
+	}
+
+	a := &app{printer: &printer{out: os.Stdout, format: format(*output)}}
+	if *debug || *trace {
+		level := util.IfOrElse(*trace, func() slog.Level { return bitvavo.LevelTrace }, slog.LevelDebug)
+		a.logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+		a.options = append(a.options, bitvavo.WithLogger(a.logger))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd.run(ctx, a, flag.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: bitvavo [-o table|json] [-debug] [-trace] <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "time arguments are RFC3339 (e.g: 2024-01-02T15:04:05Z) or unix milliseconds.")
+	fmt.Fprintln(os.Stderr, "credentials are read from API_KEY and API_SECRET (environment or .env file).")
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goccy/go-json"
+)
+
+type format string
+
+const (
+	formatTable format = "table"
+	formatJSON  format = "json"
+)
+
+type printer struct {
+	out    io.Writer
+	format format
+}
+
+// print writes v as indented JSON or as a table, v can be a struct or a slice of structs.
+func (p *printer) print(v any) error {
+	if p.format == formatJSON {
+		b, err := json.MarshalIndent(plain(reflect.ValueOf(v)), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.out, string(b))
+		return err
+	}
+
+	rows := reflect.ValueOf(v)
+	if rows.Kind() != reflect.Slice {
+		rows = reflect.Append(reflect.MakeSlice(reflect.SliceOf(rows.Type()), 0, 1), rows)
+	}
+	if rows.Len() == 0 {
+		_, err := fmt.Fprintln(p.out, "no results")
+		return err
+	}
+
+	w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+
+	first := rows.Index(0)
+	if first.Kind() != reflect.Struct {
+		for i := 0; i < rows.Len(); i++ {
+			fmt.Fprintln(w, cell(rows.Index(i)))
+		}
+		return w.Flush()
+	}
+
+	headers := make([]string, 0)
+	fields := make([]int, 0)
+	for i := 0; i < first.NumField(); i++ {
+		field := first.Type().Field(i)
+		if field.IsExported() {
+			headers = append(headers, strings.ToUpper(field.Name))
+			fields = append(fields, i)
+		}
+	}
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+
+	for i := 0; i < rows.Len(); i++ {
+		cells := make([]string, len(fields))
+		for j, field := range fields {
+			cells[j] = cell(rows.Index(i).Field(field))
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+
+	return w.Flush()
+}
+
+// cell formats a single value, enums are printed by their value and slices by their length.
+func cell(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Struct:
+		if isEnum(v) {
+			return v.Field(0).String()
+		}
+		return fmt.Sprintf("%v", v.Interface())
+	case reflect.Slice:
+		if v.Len() > 0 && v.Index(0).Kind() == reflect.Struct && !isEnum(v.Index(0)) {
+			return fmt.Sprintf("[%d]", v.Len())
+		}
+		values := make([]string, v.Len())
+		for i := range values {
+			values[i] = cell(v.Index(i))
+		}
+		return strings.Join(values, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// isEnum reports whether v is an enum member (a struct with only a Value field).
+func isEnum(v reflect.Value) bool {
+	return v.Kind() == reflect.Struct && v.NumField() == 1 && v.Type().Field(0).Name == "Value"
+}
+
+// plain converts v into maps and slices using the json names of the fields, enums are converted into their value.
+func plain(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return plain(v.Elem())
+	case reflect.Struct:
+		if isEnum(v) {
+			return v.Field(0).Interface()
+		}
+		m := make(map[string]any)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" {
+				name = field.Name
+			}
+			m[name] = plain(v.Field(i))
+		}
+		return m
+	case reflect.Slice:
+		values := make([]any, v.Len())
+		for i := range values {
+			values[i] = plain(v.Index(i))
+		}
+		return values
+	case reflect.Map:
+		m := make(map[string]any)
+		for _, key := range v.MapKeys() {
+			m[fmt.Sprint(plain(key))] = plain(v.MapIndex(key))
+		}
+		return m
+	default:
+		return v.Interface()
+	}
+}
+
+// printLine writes v on a single line, as compact JSON or as space separated name=value pairs.
+func (p *printer) printLine(v any) error {
+	if p.format == formatJSON {
+		b, err := json.Marshal(plain(reflect.ValueOf(v)))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(p.out, string(b))
+		return err
+	}
+
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Struct {
+		_, err := fmt.Fprintln(p.out, cell(value))
+		return err
+	}
+
+	pairs := make([]string, 0, value.NumField())
+	for i := 0; i < value.NumField(); i++ {
+		if field := value.Type().Field(i); field.IsExported() {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", strings.ToLower(field.Name), cell(value.Field(i))))
+		}
+	}
+	_, err := fmt.Fprintln(p.out, strings.Join(pairs, " "))
+	return err
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+func runStream(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("stream", flag.ContinueOnError)
+	interval := fs.String("interval", bitvavo.Interval1m.Value, "candle interval (candles channel only)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		return usageError(fs)
+	}
+	candleInterval, err := parseInterval(*interval)
+	if err != nil {
+		return err
+	}
+
+	markets := make([]string, 0, fs.NArg()-1)
+	for _, arg := range fs.Args()[1:] {
+		for _, market := range strings.Split(arg, ",") {
+			if market != "" {
+				markets = append(markets, strings.ToUpper(market))
+			}
+		}
+	}
+
+	switch channel := fs.Arg(0); channel {
+	case "ticker":
+		return stream(ctx, a, bitvavo.NewTickerListener(a.websocketOptions()...), markets)
+	case "ticker24h":
+		return stream(ctx, a, bitvavo.NewTicker24hListener(a.websocketOptions()...), markets)
+	case "trades":
+		return stream(ctx, a, bitvavo.NewTradesListener(a.websocketOptions()...), markets)
+	case "book":
+		return stream(ctx, a, bitvavo.NewBookListener(a.websocketOptions()...), markets)
+	case "candles":
+		listener := bitvavo.NewCandlesListener(a.websocketOptions()...)
+		defer listener.Close()
+
+		chn, err := listener.Subscribe(markets, []bitvavo.Interval{candleInterval})
+		if err != nil {
+			return err
+		}
+		return consume(ctx, a, chn, func(e bitvavo.CandleEvent) (any, error) { return e.Value, e.Error })
+	case "orders", "fills":
+		apiKey, apiSecret, err := credentials()
+		if err != nil {
+			return err
+		}
+		if channel == "orders" {
+			return stream(ctx, a, bitvavo.NewOrderListener(apiKey, apiSecret, a.websocketOptions()...), markets)
+		}
+		return stream(ctx, a, bitvavo.NewFillListener(apiKey, apiSecret, a.websocketOptions()...), markets)
+	default:
+		return fmt.Errorf("unknown channel: %s", channel)
+	}
+}
+
+// stream subscribes listener to markets and prints every event until ctx is done.
+func stream[T ~struct {
+	Value V
+	Error error
+}, V any](ctx context.Context, a *app, listener bitvavo.Listener[T], markets []string) error {
+	defer listener.Close()
+
+	chn, err := listener.Subscribe(markets)
+	if err != nil {
+		return err
+	}
+	return consume(ctx, a, chn, func(e T) (any, error) {
+		event := bitvavo.ListenerEvent[V](e)
+		return event.Value, event.Error
+	})
+}
+
+func consume[T any](ctx context.Context, a *app, chn <-chan T, unwrap func(T) (any, error)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-chn:
+			if !ok {
+				return nil
+			}
+			value, err := unwrap(e)
+			if err != nil {
+				return err
+			}
+			if err := a.printer.printLine(value); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -19,16 +19,14 @@ func main() {
 	)
 
 	client := bitvavo.NewPrivateHTTPClient(apiKey, apiSecret)
-	markets, err := client.GetMarkets(context.Background())
+	registry, err := bitvavo.NewMarketRegistry(context.Background(), client)
 	if err != nil {
 		panic(err)
 	}
 
 	tradingMarkets := make([]string, 0)
-	for _, market := range markets {
-		if market.Status == bitvavo.MarketStatusTrading {
-			tradingMarkets = append(tradingMarkets, market.Market)
-		}
+	for _, market := range registry.Trading() {
+		tradingMarkets = append(tradingMarkets, market.Market)
 	}
 
 	log.Printf("Subscribing to %d markets\n", len(tradingMarkets))
@@ -5,6 +5,7 @@ go 1.22.4
 require (
 	github.com/coder/websocket v1.8.12
 	github.com/goccy/go-json v0.10.3
+	github.com/goccy/go-yaml v1.19.2
 	github.com/joho/godotenv v1.5.1
 	github.com/orsinium-labs/enum v1.4.0
 )
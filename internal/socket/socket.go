@@ -3,15 +3,20 @@ package socket
 import (
 	"context"
 	"errors"
-	"fmt"
-	"github.com/coder/websocket"
-	"github.com/coder/websocket/wsjson"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/larscom/bitvavo-go/v2/internal/util"
 )
 
 const readLimit = 655350
 
+// LevelTrace is the level received frames are logged at, it equals bitvavo.LevelTrace
+const LevelTrace = slog.LevelDebug - 4
+
 type Options struct {
 	Url        string
 	HttpClient *http.Client
@@ -20,8 +25,18 @@ type Options struct {
 	MessageFunc func(bytes []byte)
 	// reconnectFunc gets called when successfully reconnected to the socket
 	ReconnectFunc func()
-	// debugFunc gets called on every connection event
-	DebugFunc func(string)
+	// retryFunc (optional) gets called when reconnecting failed and is tried again
+	RetryFunc func()
+	// backpressureFunc (optional) gets called with the time reading was blocked because the buffer was full
+	BackpressureFunc func(wait time.Duration)
+	// dropFunc (optional) gets called with the number of buffered messages that are discarded when the socket is closed
+	DropFunc func(count int)
+	// logFunc gets called on every connection event and received frame
+	LogFunc func(level slog.Level, msg string, args ...any)
+	// recordFunc (optional) gets called with the receive time of every frame, before it is handled
+	RecordFunc func(at time.Time, bytes []byte)
+	// replayFunc (optional) replaces the connection, it should call emit for every frame until it's done
+	ReplayFunc func(ctx context.Context, emit func(bytes []byte)) error
 }
 
 type Socket struct {
@@ -40,6 +55,10 @@ func NewSocket(
 		return nil, errors.New("options is nil")
 	}
 
+	if options.ReplayFunc != nil {
+		return replay(ctx, options), nil
+	}
+
 	conn, err := dial(ctx, options.Url, options.HttpClient)
 	if err != nil {
 		return nil, err
@@ -59,23 +78,30 @@ func NewSocket(
 }
 
 func (w *Socket) SendJSON(ctx context.Context, msg any) error {
+	// nothing to send to while replaying
+	if w.conn == nil {
+		return nil
+	}
 	return wsjson.Write(ctx, w.conn, msg)
 }
 
 func (w *Socket) reconnect(ctx context.Context) {
-	w.options.DebugFunc("websocket reconnecting...")
+	w.options.LogFunc(slog.LevelInfo, "websocket reconnecting")
 
 	conn, err := dial(ctx, w.options.Url, w.options.HttpClient)
 	if err != nil {
-		w.options.DebugFunc(fmt.Sprint("websocket error while reconnecting: ", err))
+		w.options.LogFunc(slog.LevelWarn, "websocket reconnect failed", slog.Any("error", err))
 		time.Sleep(time.Second)
+		if w.options.RetryFunc != nil {
+			w.options.RetryFunc()
+		}
 		w.reconnect(ctx)
 		return
 	}
 
 	w.conn = conn
 	w.options.ReconnectFunc()
-	w.options.DebugFunc("websocket reconnected")
+	w.options.LogFunc(slog.LevelInfo, "websocket reconnected")
 
 	go func() {
 		_ = w.listen(ctx)
@@ -83,20 +109,31 @@ func (w *Socket) reconnect(ctx context.Context) {
 }
 
 func (w *Socket) readToBuffer(ctx context.Context) {
-	w.options.DebugFunc("websocket connected")
+	w.options.LogFunc(slog.LevelInfo, "websocket connected")
 	for {
 		_, b, err := w.conn.Read(ctx)
 		if err != nil {
 			_ = w.conn.CloseNow()
-			w.options.DebugFunc(fmt.Sprint("websocket disconnected with error: ", err))
+			w.options.LogFunc(util.IfOrElse(ctx.Err() == nil, func() slog.Level { return slog.LevelWarn }, slog.LevelInfo), "websocket disconnected", slog.Any("error", err))
 			if ctx.Err() == nil {
 				//goland:noinspection ALL
 				defer w.reconnect(ctx)
 			}
 			return
 		}
-		w.buffer <- b
-		w.options.DebugFunc(fmt.Sprint("websocket received: ", string(b)))
+		if w.options.RecordFunc != nil {
+			w.options.RecordFunc(time.Now(), b)
+		}
+		select {
+		case w.buffer <- b:
+		default:
+			start := time.Now()
+			w.buffer <- b
+			if w.options.BackpressureFunc != nil {
+				w.options.BackpressureFunc(time.Since(start))
+			}
+		}
+		w.options.LogFunc(LevelTrace, "websocket received", slog.Any("frame", util.LogBytes(b)))
 	}
 }
 
@@ -106,6 +143,9 @@ func (w *Socket) listen(ctx context.Context) error {
 	for {
 		select {
 		case <-ctx.Done():
+			if n := len(w.buffer); n > 0 && w.options.DropFunc != nil {
+				w.options.DropFunc(n)
+			}
 			return ctx.Err()
 		case bytes := <-w.buffer:
 			w.options.MessageFunc(bytes)
@@ -113,6 +153,21 @@ func (w *Socket) listen(ctx context.Context) error {
 	}
 }
 
+func replay(ctx context.Context, options *Options) *Socket {
+	socket := &Socket{options: options}
+
+	go func() {
+		options.LogFunc(slog.LevelInfo, "websocket replay started")
+		if err := options.ReplayFunc(ctx, options.MessageFunc); err != nil {
+			options.LogFunc(slog.LevelWarn, "websocket replay stopped", slog.Any("error", err))
+		} else {
+			options.LogFunc(slog.LevelInfo, "websocket replay finished")
+		}
+	}()
+
+	return socket
+}
+
 func dial(ctx context.Context, url string, httpClient *http.Client) (*websocket.Conn, error) {
 	c, _, err := websocket.Dial(ctx, url, &websocket.DialOptions{
 		HTTPClient: httpClient,
@@ -0,0 +1,10 @@
+package util
+
+import "log/slog"
+
+// LogBytes logs bytes as a string, the string is only created when a handler handles the record.
+type LogBytes []byte
+
+func (b LogBytes) LogValue() slog.Value {
+	return slog.StringValue(string(b))
+}
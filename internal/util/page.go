@@ -0,0 +1,37 @@
+package util
+
+import "time"
+
+// PageBackwards collects all items between start and end for endpoints which return the most recent items first.
+// fetch is called with the end of the next page until a page has less than limit items, key returns the timestamp
+// in unix milliseconds and a unique id of an item to skip items which are on two pages.
+func PageBackwards[T any](start, end time.Time, limit int, fetch func(end time.Time) ([]T, error), key func(T) (int64, string)) ([]T, error) {
+	var (
+		items = make([]T, 0)
+		seen  = make(map[string]bool)
+	)
+
+	for end.After(start) {
+		page, err := fetch(end)
+		if err != nil {
+			return nil, err
+		}
+
+		oldest := end.UnixMilli()
+		for _, item := range page {
+			timestamp, id := key(item)
+			if !seen[id] {
+				seen[id] = true
+				items = append(items, item)
+			}
+			oldest = min(oldest, timestamp)
+		}
+
+		if len(page) < limit || oldest >= end.UnixMilli() {
+			break
+		}
+		end = time.UnixMilli(oldest)
+	}
+
+	return items, nil
+}
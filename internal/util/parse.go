@@ -9,3 +9,22 @@ func MustInt64(s string) int64 {
 	}
 	return v
 }
+
+// ParseFloat parses s into a float64, it returns 0 for empty or malformed values.
+func ParseFloat(s string) float64 {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// FormatFloat formats v without exponent, rounded to 15 significant digits
+// to get rid of floating point noise (e.g: 0.30000000000000004 becomes 0.3).
+func FormatFloat(v float64) string {
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 15, 64), 64)
+	if err != nil {
+		rounded = v
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
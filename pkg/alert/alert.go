@@ -0,0 +1,341 @@
+// Package alert fires alerts on price and volume conditions of the ticker, ticker 24h and trades streams,
+// for example a price crossing a level, a large move within a time window or a large single trade.
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+var (
+	ErrDuplicateRule = func(name string) error { return fmt.Errorf("rule '%s' already exists", name) }
+	ErrUnknownRule   = errors.New("unknown rule")
+)
+
+// Alert is a rule which fired.
+type Alert struct {
+	Rule Rule
+
+	// The value which met the condition: the price, the change or spread as a fraction, the growth of the volume
+	// as a fraction or the value of the trade in quote currency.
+	Value float64
+
+	Timestamp time.Time
+}
+
+type AlertEvent bitvavo.ListenerEvent[Alert]
+
+type Option func(*Engine)
+
+// WithHandler calls handler for every alert, after the update which fired it has been applied.
+func WithHandler(handler func(Alert)) Option {
+	return func(e *Engine) {
+		e.handlers = append(e.handlers, handler)
+	}
+}
+
+// observation is the kind of value which is observed on a market.
+type observation int
+
+const (
+	observePrice observation = iota
+	observeSpread
+	observeVolume
+	observeTrade
+)
+
+type sample struct {
+	at    time.Time
+	value float64
+}
+
+type rule struct {
+	Rule
+	armed    bool
+	observed bool
+	fired    time.Time
+	history  []sample
+}
+
+type quote struct {
+	bid float64
+	ask float64
+}
+
+// Engine evaluates rules on the updates of the streams, it is safe for concurrent use.
+type Engine struct {
+	handlers []func(Alert)
+	now      func() time.Time
+
+	mu     sync.Mutex
+	rules  []*rule
+	quotes map[string]quote
+}
+
+// New creates an engine with rules (e.g: from LoadJSON or LoadYAML).
+func New(rules []Rule, options ...Option) (*Engine, error) {
+	e := &Engine{
+		now:    time.Now,
+		quotes: make(map[string]quote),
+	}
+
+	for _, opt := range options {
+		opt(e)
+	}
+	for _, r := range rules {
+		if err := e.Add(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// Add adds a rule, the name must be unique.
+func (e *Engine) Add(r Rule) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, existing := range e.rules {
+		if existing.Name == r.Name {
+			return ErrDuplicateRule(r.Name)
+		}
+	}
+	e.rules = append(e.rules, &rule{Rule: r, armed: true})
+	return nil
+}
+
+// Remove removes the rule by name.
+func (e *Engine) Remove(name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, r := range e.rules {
+		if r.Name == name {
+			e.rules = append(e.rules[:i], e.rules[i+1:]...)
+			return nil
+		}
+	}
+	return ErrUnknownRule
+}
+
+// Rules returns all rules sorted by name.
+func (e *Engine) Rules() []Rule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rules := make([]Rule, len(e.rules))
+	for i, r := range e.rules {
+		rules[i] = r.Rule
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// UpdateTicker evaluates the price and spread rules of the market of ticker (see: bitvavo.TickerListener).
+func (e *Engine) UpdateTicker(ticker bitvavo.Ticker) []Alert {
+	return e.update(func() []Alert {
+		alerts := e.spread(ticker.Market, ticker.BestBid, ticker.BestAsk)
+		if ticker.LastPrice != "" {
+			alerts = append(alerts, e.observe(ticker.Market, observePrice, util.ParseFloat(ticker.LastPrice))...)
+		}
+		return alerts
+	})
+}
+
+// UpdateTicker24h evaluates the price, spread and volume rules of the market of ticker (see: bitvavo.Ticker24hListener).
+func (e *Engine) UpdateTicker24h(ticker bitvavo.Ticker24hData) []Alert {
+	return e.update(func() []Alert {
+		alerts := e.spread(ticker.Market, ticker.Bid, ticker.Ask)
+		if ticker.Last != "" {
+			alerts = append(alerts, e.observe(ticker.Market, observePrice, util.ParseFloat(ticker.Last))...)
+		}
+		if ticker.Volume != "" {
+			alerts = append(alerts, e.observe(ticker.Market, observeVolume, util.ParseFloat(ticker.Volume))...)
+		}
+		return alerts
+	})
+}
+
+// UpdateTrade evaluates the price and large trade rules of the market of trade (see: bitvavo.TradesListener).
+func (e *Engine) UpdateTrade(trade bitvavo.Trade) []Alert {
+	return e.update(func() []Alert {
+		price := util.ParseFloat(trade.Price)
+		alerts := e.observe(trade.Market, observeTrade, util.ParseFloat(trade.Amount)*price)
+		return append(alerts, e.observe(trade.Market, observePrice, price)...)
+	})
+}
+
+// Listen evaluates the rules on the events of the channels (any may be nil) and emits the alerts.
+// The returned channel is closed when all channels are closed.
+func (e *Engine) Listen(tickers <-chan bitvavo.TickerEvent, tickers24h <-chan bitvavo.Ticker24hEvent, trades <-chan bitvavo.TradeEvent) <-chan AlertEvent {
+	chn := make(chan AlertEvent)
+
+	go func() {
+		defer close(chn)
+
+		for tickers != nil || tickers24h != nil || trades != nil {
+			var (
+				alerts []Alert
+				err    error
+			)
+			select {
+			case event, open := <-tickers:
+				if !open {
+					tickers = nil
+					continue
+				}
+				if err = event.Error; err == nil {
+					alerts = e.UpdateTicker(event.Value)
+				}
+			case event, open := <-tickers24h:
+				if !open {
+					tickers24h = nil
+					continue
+				}
+				if err = event.Error; err == nil {
+					alerts = e.UpdateTicker24h(event.Value)
+				}
+			case event, open := <-trades:
+				if !open {
+					trades = nil
+					continue
+				}
+				if err = event.Error; err == nil {
+					alerts = e.UpdateTrade(event.Value)
+				}
+			}
+
+			if err != nil {
+				chn <- AlertEvent{Error: err}
+			}
+			for _, alert := range alerts {
+				chn <- AlertEvent{Value: alert}
+			}
+		}
+	}()
+
+	return chn
+}
+
+// update runs fn with the lock held and calls the handlers with the alerts afterwards.
+func (e *Engine) update(fn func() []Alert) []Alert {
+	e.mu.Lock()
+	alerts := fn()
+	e.mu.Unlock()
+
+	for _, alert := range alerts {
+		for _, handler := range e.handlers {
+			handler(alert)
+		}
+	}
+	return alerts
+}
+
+// spread updates the best bid and ask of market and evaluates the spread rules.
+func (e *Engine) spread(market string, bid string, ask string) []Alert {
+	if bid == "" && ask == "" {
+		return nil
+	}
+
+	q := e.quotes[market]
+	if bid != "" {
+		q.bid = util.ParseFloat(bid)
+	}
+	if ask != "" {
+		q.ask = util.ParseFloat(ask)
+	}
+	e.quotes[market] = q
+
+	if q.bid <= 0 || q.ask <= 0 {
+		return nil
+	}
+	return e.observe(market, observeSpread, (q.ask-q.bid)/((q.ask+q.bid)/2))
+}
+
+// observe evaluates the rules of market which observe kind.
+func (e *Engine) observe(market string, kind observation, value float64) []Alert {
+	var (
+		now    = e.now()
+		alerts = make([]Alert, 0)
+	)
+
+	for _, r := range e.rules {
+		if r.Market != market || observes(r.Condition) != kind {
+			continue
+		}
+
+		v := value
+		if r.Condition == ConditionChange || r.Condition == ConditionVolumeSpike {
+			v = r.growth(now, value)
+		}
+		if r.check(now, v) {
+			alerts = append(alerts, Alert{Rule: r.Rule, Value: v, Timestamp: now})
+		}
+	}
+
+	return alerts
+}
+
+func observes(condition Condition) observation {
+	switch condition {
+	case ConditionSpread:
+		return observeSpread
+	case ConditionVolumeSpike:
+		return observeVolume
+	case ConditionLargeTrade:
+		return observeTrade
+	}
+	return observePrice
+}
+
+// growth adds value to the history and returns its growth since the oldest value within the window.
+func (r *rule) growth(now time.Time, value float64) float64 {
+	start := now.Add(-time.Duration(r.Window))
+	i := 0
+	for i < len(r.history) && r.history[i].at.Before(start) {
+		i++
+	}
+	r.history = append(r.history[i:], sample{at: now, value: value})
+
+	oldest := r.history[0].value
+	return util.IfOrElse(oldest != 0, func() float64 { return value/oldest - 1 }, 0)
+}
+
+// check returns true if the rule fires for value, taking the hysteresis and cooldown into account.
+func (r *rule) check(now time.Time, value float64) bool {
+	var (
+		below     = r.Condition == ConditionPriceBelow || r.Value < 0
+		margin    = math.Abs(r.Value) * r.Hysteresis
+		triggered = util.IfOrElse(below, func() bool { return value <= r.Value }, value >= r.Value)
+		reset     = util.IfOrElse(below, func() bool { return value > r.Value+margin }, value < r.Value-margin)
+	)
+
+	// a level has to be crossed, so it doesn't fire on the first price if that's already beyond the level
+	if !r.observed && (r.Condition == ConditionPriceAbove || r.Condition == ConditionPriceBelow) {
+		r.armed = !triggered
+	}
+	r.observed = true
+
+	if !r.armed && reset {
+		r.armed = true
+	}
+	if !triggered || !r.armed || now.Before(r.fired.Add(time.Duration(r.Cooldown))) {
+		return false
+	}
+
+	r.fired = now
+	r.armed = r.Condition == ConditionLargeTrade
+	return true
+}
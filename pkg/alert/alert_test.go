@@ -0,0 +1,157 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newEngine(t *testing.T, rules ...Rule) (*Engine, *clock) {
+	engine, err := New(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	engine.now = func() time.Time { return c.now }
+	return engine, c
+}
+
+func price(engine *Engine, value string) int {
+	return len(engine.UpdateTicker(bitvavo.Ticker{Market: "ETH-EUR", LastPrice: value}))
+}
+
+func TestPriceAbove(t *testing.T) {
+	engine, _ := newEngine(t, Rule{Name: "eth", Market: "ETH-EUR", Condition: ConditionPriceAbove, Value: 3000, Hysteresis: 0.01})
+
+	// already above the level, it has to cross it first
+	test.AssertEqual(t, 0, price(engine, "3100"))
+	test.AssertEqual(t, 0, price(engine, "2960"))
+	test.AssertEqual(t, 1, price(engine, "3000"))
+	test.AssertEqual(t, 0, price(engine, "3050"))
+
+	// within the hysteresis
+	test.AssertEqual(t, 0, price(engine, "2980"))
+	test.AssertEqual(t, 0, price(engine, "3010"))
+
+	test.AssertEqual(t, 0, price(engine, "2960"))
+	test.AssertEqual(t, 1, price(engine, "3010"))
+}
+
+func TestCooldown(t *testing.T) {
+	var fired []Alert
+	engine, err := New([]Rule{{Name: "eth", Market: "ETH-EUR", Condition: ConditionPriceBelow, Value: 2000, Cooldown: Duration(time.Minute)}},
+		WithHandler(func(alert Alert) { fired = append(fired, alert) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	engine.now = func() time.Time { return c.now }
+
+	test.AssertEqual(t, 0, price(engine, "2100"))
+	test.AssertEqual(t, 1, price(engine, "1990"))
+	test.AssertEqual(t, 0, price(engine, "2010"))
+
+	c.advance(30 * time.Second)
+	test.AssertEqual(t, 0, price(engine, "1990"))
+	test.AssertEqual(t, 0, price(engine, "2010"))
+
+	c.advance(30 * time.Second)
+	test.AssertEqual(t, 1, price(engine, "1990"))
+	test.AssertEqual(t, 2, len(fired))
+	test.AssertEqual(t, 1990.0, fired[1].Value)
+}
+
+func TestChange(t *testing.T) {
+	engine, c := newEngine(t, Rule{Name: "drop", Market: "ETH-EUR", Condition: ConditionChange, Value: -0.05, Window: Duration(5 * time.Minute)})
+
+	test.AssertEqual(t, 0, price(engine, "3000"))
+	c.advance(4 * time.Minute)
+	test.AssertEqual(t, 0, price(engine, "2900"))
+
+	// 3000 is out of the window
+	c.advance(2 * time.Minute)
+	test.AssertEqual(t, 0, price(engine, "2800"))
+
+	c.advance(time.Minute)
+	alerts := engine.UpdateTicker(bitvavo.Ticker{Market: "ETH-EUR", LastPrice: "2750"})
+	test.AssertEqual(t, 1, len(alerts))
+	test.AssertEqual(t, "-0.051724", fmt.Sprintf("%.6f", alerts[0].Value))
+}
+
+func TestSpreadAndVolume(t *testing.T) {
+	engine, c := newEngine(t,
+		Rule{Name: "spread", Market: "ETH-EUR", Condition: ConditionSpread, Value: 0.01},
+		Rule{Name: "volume", Market: "ETH-EUR", Condition: ConditionVolumeSpike, Value: 0.1, Window: Duration(time.Hour)},
+	)
+
+	test.AssertEqual(t, 0, len(engine.UpdateTicker(bitvavo.Ticker{Market: "ETH-EUR", BestBid: "2990", BestAsk: "3010"})))
+	// only the bid changes
+	alerts := engine.UpdateTicker(bitvavo.Ticker{Market: "ETH-EUR", BestBid: "2900"})
+	test.AssertEqual(t, 1, len(alerts))
+	test.AssertEqual(t, "spread", alerts[0].Rule.Name)
+
+	test.AssertEqual(t, 0, len(engine.UpdateTicker24h(bitvavo.Ticker24hData{Market: "ETH-EUR", Volume: "1000"})))
+	c.advance(30 * time.Minute)
+	alerts = engine.UpdateTicker24h(bitvavo.Ticker24hData{Market: "ETH-EUR", Volume: "1150"})
+	test.AssertEqual(t, 1, len(alerts))
+	test.AssertEqual(t, "volume", alerts[0].Rule.Name)
+}
+
+func TestLargeTrade(t *testing.T) {
+	engine, _ := newEngine(t, Rule{Name: "whale", Market: "BTC-EUR", Condition: ConditionLargeTrade, Value: 100000})
+
+	trades := make(chan bitvavo.TradeEvent)
+	events := engine.Listen(nil, nil, trades)
+	go func() {
+		defer close(trades)
+		trades <- bitvavo.TradeEvent{Value: bitvavo.Trade{Market: "BTC-EUR", Amount: "1", Price: "50000"}}
+		trades <- bitvavo.TradeEvent{Value: bitvavo.Trade{Market: "BTC-EUR", Amount: "3", Price: "50000"}}
+		trades <- bitvavo.TradeEvent{Value: bitvavo.Trade{Market: "BTC-EUR", Amount: "2", Price: "50000"}}
+	}()
+
+	values := make([]float64, 0)
+	for event := range events {
+		values = append(values, event.Value.Value)
+	}
+	test.AssertEqual(t, 2, len(values))
+	test.AssertEqual(t, 150000.0, values[0])
+	test.AssertEqual(t, 100000.0, values[1])
+}
+
+func TestLoad(t *testing.T) {
+	fromJSON, err := LoadJSON(strings.NewReader(`[{"name": "drop", "market": "ETH-EUR", "condition": "change", "value": -0.05, "window": "5m", "cooldown": "1h"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromYAML, err := LoadYAML(strings.NewReader(`
+- name: drop
+  market: ETH-EUR
+  condition: change
+  value: -0.05
+  window: 5m
+  cooldown: 1h
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.AssertEqual(t, 1, len(fromYAML))
+	test.AssertEqual(t, fromJSON[0], fromYAML[0])
+	test.AssertEqual(t, ConditionChange, fromYAML[0].Condition)
+	test.AssertEqual(t, Duration(5*time.Minute), fromYAML[0].Window)
+
+	_, err = New([]Rule{{Name: "drop", Market: "ETH-EUR", Condition: ConditionChange, Value: -0.05}})
+	test.AssertEqual(t, "invalid rule 'drop': window is required", err.Error())
+}
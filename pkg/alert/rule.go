@@ -0,0 +1,134 @@
+package alert
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/goccy/go-yaml"
+	"github.com/orsinium-labs/enum"
+)
+
+var ErrInvalidRule = func(name string, reason string) error { return fmt.Errorf("invalid rule '%s': %s", name, reason) }
+
+type Condition enum.Member[string]
+
+var (
+	condition = enum.NewBuilder[string, Condition]()
+
+	// ConditionPriceAbove fires when the price crosses Value upwards.
+	ConditionPriceAbove = condition.Add(Condition{"priceAbove"})
+
+	// ConditionPriceBelow fires when the price crosses Value downwards.
+	ConditionPriceBelow = condition.Add(Condition{"priceBelow"})
+
+	// ConditionChange fires when the price moved Value (e.g: 0.05 for 5% up or -0.05 for 5% down) within Window.
+	ConditionChange = condition.Add(Condition{"change"})
+
+	// ConditionSpread fires when the spread between the best bid and best ask is wider than Value (e.g: 0.01 for 1% of the mid price).
+	ConditionSpread = condition.Add(Condition{"spread"})
+
+	// ConditionVolumeSpike fires when the 24h volume grew Value (e.g: 0.1 for 10%) within Window.
+	ConditionVolumeSpike = condition.Add(Condition{"volumeSpike"})
+
+	// ConditionLargeTrade fires for a single trade worth at least Value in quote currency.
+	ConditionLargeTrade = condition.Add(Condition{"largeTrade"})
+
+	conditions = condition.Enum()
+)
+
+func (c Condition) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Value)
+}
+
+func (c *Condition) UnmarshalJSON(bytes []byte) error {
+	var value string
+	if err := json.Unmarshal(bytes, &value); err != nil {
+		return err
+	}
+	if parsed := conditions.Parse(value); parsed != nil {
+		*c = *parsed
+		return nil
+	}
+	return fmt.Errorf("unknown condition: %s", value)
+}
+
+// Duration is a time.Duration which is written as a string (e.g: "5m") in JSON and YAML.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(bytes []byte) error {
+	var value string
+	if err := json.Unmarshal(bytes, &value); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// Rule is a condition on a market.
+type Rule struct {
+	// Unique name of the rule.
+	Name string `json:"name"`
+
+	Market    string    `json:"market"`
+	Condition Condition `json:"condition"`
+
+	// The level or threshold of the condition.
+	Value float64 `json:"value"`
+
+	// The time window of ConditionChange and ConditionVolumeSpike.
+	Window Duration `json:"window,omitempty"`
+
+	// After the rule fired, the condition has to go back by this fraction of Value before it can fire again
+	// (e.g: a ConditionPriceAbove at 3000 with a hysteresis of 0.01 fires again after the price was below 2970).
+	// Not used by ConditionLargeTrade, which fires for every large trade.
+	Hysteresis float64 `json:"hysteresis,omitempty"`
+
+	// The minimum time between 2 alerts of the rule.
+	Cooldown Duration `json:"cooldown,omitempty"`
+}
+
+func (r Rule) validate() error {
+	switch {
+	case r.Name == "":
+		return ErrInvalidRule(r.Name, "name is required")
+	case r.Market == "":
+		return ErrInvalidRule(r.Name, "market is required")
+	case r.Condition.Value == "":
+		return ErrInvalidRule(r.Name, "condition is required")
+	case r.Value == 0 || (r.Value < 0 && r.Condition != ConditionChange):
+		return ErrInvalidRule(r.Name, "value must be greater than 0")
+	case r.Window <= 0 && (r.Condition == ConditionChange || r.Condition == ConditionVolumeSpike):
+		return ErrInvalidRule(r.Name, "window is required")
+	case r.Hysteresis < 0 || r.Hysteresis >= 1:
+		return ErrInvalidRule(r.Name, "hysteresis must be between 0 and 1")
+	}
+	return nil
+}
+
+// LoadJSON reads a JSON array of rules.
+func LoadJSON(r io.Reader) ([]Rule, error) {
+	rules := make([]Rule, 0)
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// LoadYAML reads a YAML sequence of rules, the fields have the same names as in JSON.
+func LoadYAML(r io.Reader) ([]Rule, error) {
+	rules := make([]Rule, 0)
+	if err := yaml.NewDecoder(r, yaml.UseJSONUnmarshaler()).Decode(&rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
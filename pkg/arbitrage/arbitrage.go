@@ -0,0 +1,328 @@
+// Package arbitrage scans the markets for triangular arbitrage: cycles of 3 trades (e.g: EUR -> BTC -> ETH -> EUR)
+// which end with more of the start currency than they began with, net of taker fees.
+package arbitrage
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+// Markets is the part of bitvavo.MarketRegistry needed to find the cycles.
+type Markets interface {
+	// Trading returns all markets which are trading.
+	Trading() []bitvavo.Market
+
+	// Rules returns the precision and order size rules of a market.
+	Rules(market string) (bitvavo.MarketRules, error)
+}
+
+// Leg is a single trade of a cycle.
+type Leg struct {
+	Market string
+	Side   bitvavo.Side
+
+	// The best ask for a buy, the best bid for a sell.
+	Price float64
+}
+
+// Opportunity is a cycle which returns more than it costs.
+type Opportunity struct {
+	// The currencies of the cycle, the first and the last are the start currency (e.g: EUR BTC ETH EUR).
+	Path []string
+
+	Legs []Leg
+
+	// The amount of the start currency received for each unit spent, net of fees (e.g: 1.002).
+	Rate float64
+
+	// Rate - 1
+	Profit float64
+
+	Timestamp time.Time
+}
+
+// String returns the path of the cycle (e.g: EUR -> BTC -> ETH -> EUR).
+func (o Opportunity) String() string {
+	return strings.Join(o.Path, " -> ")
+}
+
+type OpportunityEvent bitvavo.ListenerEvent[Opportunity]
+
+type Option func(*Scanner)
+
+// WithStart only scans cycles which start and end in currencies.
+//
+// Default: "EUR"
+func WithStart(currencies ...string) Option {
+	return func(s *Scanner) {
+		s.start = currencies
+	}
+}
+
+// WithTakerFee sets the fee of each trade (see: bitvavo.Account).
+//
+// Default: 0.0025
+func WithTakerFee(fee float64) Option {
+	return func(s *Scanner) {
+		s.fee = fee
+	}
+}
+
+// WithMinProfit only emits opportunities with at least profit (e.g: 0.001 for 0.1%).
+//
+// Default: 0
+func WithMinProfit(profit float64) Option {
+	return func(s *Scanner) {
+		s.minProfit = profit
+	}
+}
+
+// WithAmount simulates each cycle with amount of the start currency, rounding every trade to the precision of the market
+// and skipping cycles with a trade below the minimum order size.
+//
+// Default: 0 (no rounding)
+func WithAmount(amount float64) Option {
+	return func(s *Scanner) {
+		s.amount = amount
+	}
+}
+
+type leg struct {
+	market string
+	side   bitvavo.Side
+	rules  bitvavo.MarketRules
+}
+
+type cycle struct {
+	path []string
+	legs []leg
+}
+
+type quote struct {
+	bid float64
+	ask float64
+}
+
+// Scanner finds the cycles between the trading markets and evaluates the cycles of a market on each ticker event
+// of that market (see: bitvavo.TickerListener). It is safe for concurrent use.
+type Scanner struct {
+	markets   Markets
+	start     []string
+	fee       float64
+	minProfit float64
+	amount    float64
+
+	mu       sync.RWMutex
+	cycles   []*cycle
+	byMarket map[string][]*cycle
+	quotes   map[string]quote
+	current  map[*cycle]Opportunity
+}
+
+func New(markets Markets, options ...Option) (*Scanner, error) {
+	s := &Scanner{
+		markets: markets,
+		start:   []string{"EUR"},
+		fee:     0.0025,
+		quotes:  make(map[string]quote),
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	return s, s.Refresh()
+}
+
+// Refresh finds the cycles again, call it when a market changes status or a new market is listed
+// (see: bitvavo.MarketRegistry.Subscribe).
+func (s *Scanner) Refresh() error {
+	var (
+		trading = s.markets.Trading()
+		byPair  = make(map[[2]string]bitvavo.Market, len(trading))
+		byAsset = make(map[string][]bitvavo.Market)
+		rules   = make(map[string]bitvavo.MarketRules, len(trading))
+	)
+	for _, market := range trading {
+		r, err := s.markets.Rules(market.Market)
+		if err != nil {
+			return err
+		}
+		rules[market.Market] = r
+		byPair[[2]string{market.Base, market.Quote}] = market
+		byAsset[market.Base] = append(byAsset[market.Base], market)
+		byAsset[market.Quote] = append(byAsset[market.Quote], market)
+	}
+
+	// trade converts from into the other currency of market
+	trade := func(from string, market bitvavo.Market) (string, leg) {
+		if market.Base == from {
+			return market.Quote, leg{market: market.Market, side: bitvavo.SideSell, rules: rules[market.Market]}
+		}
+		return market.Base, leg{market: market.Market, side: bitvavo.SideBuy, rules: rules[market.Market]}
+	}
+
+	cycles := make([]*cycle, 0)
+	for _, start := range s.start {
+		for _, first := range byAsset[start] {
+			a, firstLeg := trade(start, first)
+			for _, second := range byAsset[a] {
+				b, secondLeg := trade(a, second)
+				if second.Market == first.Market || b == start {
+					continue
+				}
+				third, ok := byPair[[2]string{b, start}]
+				if !ok {
+					if third, ok = byPair[[2]string{start, b}]; !ok {
+						continue
+					}
+				}
+				_, thirdLeg := trade(b, third)
+				cycles = append(cycles, &cycle{path: []string{start, a, b, start}, legs: []leg{firstLeg, secondLeg, thirdLeg}})
+			}
+		}
+	}
+
+	byMarket := make(map[string][]*cycle)
+	for _, c := range cycles {
+		for _, l := range c.legs {
+			byMarket[l.market] = append(byMarket[l.market], c)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cycles = cycles
+	s.byMarket = byMarket
+	s.current = make(map[*cycle]Opportunity)
+	for _, c := range cycles {
+		if opportunity, ok := s.evaluate(c); ok {
+			s.current[c] = opportunity
+		}
+	}
+	return nil
+}
+
+// Cycles returns the number of cycles which are scanned.
+func (s *Scanner) Cycles() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.cycles)
+}
+
+// UpdateTicker updates the best bid and best ask of a market and returns the opportunities of the cycles with that market.
+// Fields which are not in the ticker (only changes are sent) keep their previous value.
+func (s *Scanner) UpdateTicker(ticker bitvavo.Ticker) []Opportunity {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := s.quotes[ticker.Market]
+	if ticker.BestBid != "" {
+		q.bid = util.ParseFloat(ticker.BestBid)
+	}
+	if ticker.BestAsk != "" {
+		q.ask = util.ParseFloat(ticker.BestAsk)
+	}
+	s.quotes[ticker.Market] = q
+
+	opportunities := make([]Opportunity, 0)
+	for _, c := range s.byMarket[ticker.Market] {
+		opportunity, ok := s.evaluate(c)
+		if !ok {
+			delete(s.current, c)
+			continue
+		}
+		s.current[c] = opportunity
+		opportunities = append(opportunities, opportunity)
+	}
+	sortByProfit(opportunities)
+
+	return opportunities
+}
+
+// Opportunities returns the current opportunities of all cycles, the most profitable first.
+func (s *Scanner) Opportunities() []Opportunity {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	opportunities := make([]Opportunity, 0, len(s.current))
+	for _, opportunity := range s.current {
+		opportunities = append(opportunities, opportunity)
+	}
+	sortByProfit(opportunities)
+
+	return opportunities
+}
+
+// Listen updates the scanner with ticker events and emits the opportunities, the returned channel is closed when events is closed.
+func (s *Scanner) Listen(events <-chan bitvavo.TickerEvent) <-chan OpportunityEvent {
+	chn := make(chan OpportunityEvent)
+
+	go func() {
+		defer close(chn)
+
+		for event := range events {
+			if event.Error != nil {
+				chn <- OpportunityEvent{Error: event.Error}
+				continue
+			}
+			for _, opportunity := range s.UpdateTicker(event.Value) {
+				chn <- OpportunityEvent{Value: opportunity}
+			}
+		}
+	}()
+
+	return chn
+}
+
+// evaluate trades 1 unit (or the amount) of the start currency through the cycle, it returns false if the cycle
+// can't be traded or is not profitable enough.
+func (s *Scanner) evaluate(c *cycle) (Opportunity, bool) {
+	var (
+		start  = util.IfOrElse(s.amount > 0, func() float64 { return s.amount }, 1)
+		amount = start
+		legs   = make([]Leg, len(c.legs))
+	)
+
+	for i, l := range c.legs {
+		q := s.quotes[l.market]
+		price := util.IfOrElse(l.side == bitvavo.SideBuy, func() float64 { return q.ask }, q.bid)
+		if price <= 0 {
+			return Opportunity{}, false
+		}
+		legs[i] = Leg{Market: l.market, Side: l.side, Price: price}
+
+		// the amount of the base currency which is bought or sold
+		base := util.IfOrElse(l.side == bitvavo.SideBuy, func() float64 { return amount / (price * (1 + s.fee)) }, amount)
+		if s.amount > 0 {
+			base = util.ParseFloat(l.rules.FloorAmount(base))
+			if l.rules.Validate(base, price) != nil {
+				return Opportunity{}, false
+			}
+		}
+		amount = util.IfOrElse(l.side == bitvavo.SideBuy, func() float64 { return base }, base*price*(1-s.fee))
+	}
+
+	rate := amount / start
+	if rate-1 < s.minProfit || rate <= 1 {
+		return Opportunity{}, false
+	}
+	return Opportunity{
+		Path:      append([]string(nil), c.path...),
+		Legs:      legs,
+		Rate:      rate,
+		Profit:    rate - 1,
+		Timestamp: time.Now(),
+	}, true
+}
+
+func sortByProfit(opportunities []Opportunity) {
+	sort.SliceStable(opportunities, func(i, j int) bool { return opportunities[i].Rate > opportunities[j].Rate })
+}
@@ -0,0 +1,81 @@
+package arbitrage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+type markets []bitvavo.Market
+
+func (m markets) Trading() []bitvavo.Market {
+	return m
+}
+
+func (m markets) Rules(market string) (bitvavo.MarketRules, error) {
+	for _, mm := range m {
+		if mm.Market == market {
+			return bitvavo.MarketRules{Market: mm, BaseDecimals: 8, QuoteDecimals: 2}, nil
+		}
+	}
+	return bitvavo.MarketRules{}, nil
+}
+
+var trading = markets{
+	{Market: "BTC-EUR", Base: "BTC", Quote: "EUR", MinOrderInBaseAsset: "0.0001"},
+	{Market: "ETH-EUR", Base: "ETH", Quote: "EUR", MinOrderInBaseAsset: "0.001"},
+	{Market: "ETH-BTC", Base: "ETH", Quote: "BTC", MinOrderInBaseAsset: "0.001"},
+	{Market: "SOL-EUR", Base: "SOL", Quote: "EUR"},
+}
+
+func TestScanner(t *testing.T) {
+	scanner, err := New(trading, WithMinProfit(0.01))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, 2, scanner.Cycles())
+
+	test.AssertEqual(t, 0, len(scanner.UpdateTicker(bitvavo.Ticker{Market: "BTC-EUR", BestBid: "50000", BestAsk: "50000"})))
+	test.AssertEqual(t, 0, len(scanner.UpdateTicker(bitvavo.Ticker{Market: "ETH-EUR", BestBid: "3000", BestAsk: "3000"})))
+
+	// EUR -> ETH -> BTC -> EUR: 1 / 3000 * 0.065 * 50000 = 1.0833 before fees
+	opportunities := scanner.UpdateTicker(bitvavo.Ticker{Market: "ETH-BTC", BestBid: "0.065", BestAsk: "0.065"})
+	test.AssertEqual(t, 1, len(opportunities))
+	test.AssertEqual(t, "EUR -> ETH -> BTC -> EUR", opportunities[0].String())
+	test.AssertEqual(t, "1.075235", fmt.Sprintf("%.6f", opportunities[0].Rate))
+	test.AssertEqual(t, bitvavo.SideBuy, opportunities[0].Legs[0].Side)
+	test.AssertEqual(t, bitvavo.SideSell, opportunities[0].Legs[1].Side)
+	test.AssertEqual(t, bitvavo.SideSell, opportunities[0].Legs[2].Side)
+	test.AssertEqual(t, 0.065, opportunities[0].Legs[1].Price)
+
+	// only the bid changes
+	test.AssertEqual(t, 0, len(scanner.UpdateTicker(bitvavo.Ticker{Market: "ETH-BTC", BestBid: "0.0601"})))
+	test.AssertEqual(t, 0, len(scanner.Opportunities()))
+}
+
+func TestScannerAmount(t *testing.T) {
+	scanner, _ := New(trading, WithAmount(1))
+	scanner.UpdateTicker(bitvavo.Ticker{Market: "BTC-EUR", BestBid: "50000", BestAsk: "50000"})
+	scanner.UpdateTicker(bitvavo.Ticker{Market: "ETH-EUR", BestBid: "3000", BestAsk: "3000"})
+	scanner.UpdateTicker(bitvavo.Ticker{Market: "ETH-BTC", BestBid: "0.065", BestAsk: "0.065"})
+
+	// 1 EUR buys less than the minimum amount of ETH
+	test.AssertEqual(t, 0, len(scanner.Opportunities()))
+
+	scanner, _ = New(trading, WithAmount(1000), WithTakerFee(0))
+	events := make(chan bitvavo.TickerEvent, 3)
+	events <- bitvavo.TickerEvent{Value: bitvavo.Ticker{Market: "BTC-EUR", BestBid: "50000", BestAsk: "50000"}}
+	events <- bitvavo.TickerEvent{Value: bitvavo.Ticker{Market: "ETH-EUR", BestBid: "3000", BestAsk: "3000"}}
+	events <- bitvavo.TickerEvent{Value: bitvavo.Ticker{Market: "ETH-BTC", BestBid: "0.065", BestAsk: "0.065"}}
+	close(events)
+
+	var opportunities []Opportunity
+	for event := range scanner.Listen(events) {
+		opportunities = append(opportunities, event.Value)
+	}
+	test.AssertEqual(t, 1, len(opportunities))
+	// 0.33333333 ETH -> 0.02166666 BTC -> 1083.333 EUR
+	test.AssertEqual(t, "1.083333", fmt.Sprintf("%.6f", opportunities[0].Rate))
+}
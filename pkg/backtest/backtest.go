@@ -0,0 +1,131 @@
+// Package backtest replays historical candles and trades through a Strategy and simulates the orders it places.
+//
+// A strategy places orders through a Broker, which is a subset of bitvavo.PrivateAPI. That means the same strategy
+// can run against the real exchange by passing the private http client as Broker.
+package backtest
+
+import (
+	"context"
+	"errors"
+
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+var ErrNoEvents = errors.New("no events to replay")
+
+// Broker is the part of bitvavo.PrivateAPI a strategy can use to trade.
+type Broker interface {
+	// GetBalance returns the balance on the account.
+	// Optionally provide the symbol to filter for in uppercase (e.g: ETH)
+	GetBalance(ctx context.Context, symbol ...string) ([]bitvavo.Balance, error)
+
+	// GetOrdersOpen returns all open orders for market (e.g: ETH-EUR) or all open orders
+	// if no market is given.
+	GetOrdersOpen(ctx context.Context, market ...string) ([]bitvavo.Order, error)
+
+	// CancelOrder cancels a single order by ID for the specific market (e.g: ETH-EUR)
+	//
+	// It returns the canceled orderId if it was canceled
+	CancelOrder(ctx context.Context, market string, orderId string) (string, error)
+
+	// NewOrder places a new order on the exchange.
+	//
+	// It returns the new order if it was successfully created
+	NewOrder(ctx context.Context, market string, side bitvavo.Side, orderType bitvavo.OrderType, order bitvavo.OrderNew) (bitvavo.Order, error)
+}
+
+// Event is a single market event, either a candle or a trade.
+type Event struct {
+	// The market of this event (e.g: ETH-EUR)
+	Market string
+
+	// Timestamp in unix milliseconds.
+	Timestamp int64
+
+	// Set if this is a candle event.
+	Candle *bitvavo.CandleOnly
+
+	// Set if this is a trade event.
+	Trade *bitvavo.Trade
+}
+
+// Strategy receives every market event in chronological order.
+// Returning an error stops the backtest.
+type Strategy interface {
+	OnEvent(ctx context.Context, broker Broker, event Event) error
+}
+
+// FillHandler can optionally be implemented by a Strategy to get notified of fills.
+type FillHandler interface {
+	OnFill(ctx context.Context, broker Broker, fill bitvavo.Fill) error
+}
+
+// StrategyFunc is an adapter to use an ordinary function as Strategy.
+type StrategyFunc func(ctx context.Context, broker Broker, event Event) error
+
+func (f StrategyFunc) OnEvent(ctx context.Context, broker Broker, event Event) error {
+	return f(ctx, broker, event)
+}
+
+type Config struct {
+	// Starting balance per symbol (e.g: {"EUR": 1000})
+	Balances map[string]float64
+
+	// Maker and taker fees, use the fees of your account (see: PrivateAPI.GetAccount)
+	Fees bitvavo.Fee
+
+	// The currency in which the equity is measured.
+	//
+	// Default: "EUR"
+	Quote string
+
+	// Historical data has no order book, the spread (fraction of the price, e.g: 0.001) is used to derive
+	// the best bid and best ask from the traded price.
+	// Market orders buy at the ask and sell at the bid, stop orders use it for the bestBid, bestAsk trigger references.
+	//
+	// Default: 0
+	Spread float64
+}
+
+type EquityPoint struct {
+	// Timestamp in unix milliseconds.
+	Timestamp int64
+
+	// Value of all balances in the quote currency.
+	Equity float64
+}
+
+type Result struct {
+	// Equity after each event.
+	Equity []EquityPoint
+
+	// All fills in chronological order.
+	Fills []bitvavo.Fill
+
+	// All orders placed during the backtest with their final status.
+	Orders []bitvavo.Order
+
+	// Balances at the end of the backtest.
+	Balances []bitvavo.Balance
+
+	Stats Stats
+}
+
+// Run replays events (see: Merge) through strategy.
+func Run(ctx context.Context, strategy Strategy, events []Event, config Config) (Result, error) {
+	if len(events) == 0 {
+		return Result{}, ErrNoEvents
+	}
+
+	e := newEngine(config)
+	for _, event := range events {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		if err := e.process(ctx, strategy, event); err != nil {
+			return e.result(), err
+		}
+	}
+
+	return e.result(), nil
+}
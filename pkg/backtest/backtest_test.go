@@ -0,0 +1,105 @@
+package backtest
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+const candlesJSON = `[
+	[4000, "120", "125", "118", "124", "10"],
+	[3000, "104", "121", "103", "120", "10"],
+	[2000, "100", "106", "95", "105", "10"],
+	[1000, "100", "101", "99", "100", "10"]
+]`
+
+func TestRunLimitAndTakeProfit(t *testing.T) {
+	events, err := ReadCandles(strings.NewReader(candlesJSON), "ETH-EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	strategy := StrategyFunc(func(ctx context.Context, broker Broker, event Event) error {
+		if event.Timestamp != 1000 {
+			return nil
+		}
+		if _, err := broker.NewOrder(ctx, event.Market, bitvavo.SideBuy, bitvavo.OrderTypeLimit, bitvavo.OrderNew{Amount: "1", Price: "96"}); err != nil {
+			return err
+		}
+		_, err := broker.NewOrder(ctx, event.Market, bitvavo.SideSell, bitvavo.OrderTypeTakeProfit, bitvavo.OrderNew{
+			Amount:           "1",
+			TriggerAmount:    "115",
+			TriggerType:      bitvavo.OrderTriggerTypeDefault,
+			TriggerReference: bitvavo.OrderTriggerRefLastTrade,
+		})
+		return err
+	})
+
+	// no base balance yet, so the take profit can't be placed
+	_, err = Run(context.Background(), strategy, events, Config{Balances: map[string]float64{"EUR": 200}})
+	test.AssertEqual(t, ErrInsufficientBalance("ETH").Error(), err.Error())
+
+	result, err := Run(context.Background(), strategy, events, Config{
+		Balances: map[string]float64{"EUR": 200, "ETH": 1},
+		Fees:     bitvavo.Fee{Maker: "0.001", Taker: "0.0025"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.AssertEqual(t, 2, len(result.Fills))
+
+	buy := result.Fills[0]
+	test.AssertEqual(t, "96", buy.Price)
+	test.AssertEqual(t, false, buy.Taker)
+	test.AssertEqual(t, "0.096", buy.Fee)
+
+	sell := result.Fills[1]
+	test.AssertEqual(t, int64(3000), sell.Timestamp)
+	test.AssertEqual(t, "115", sell.Price)
+	test.AssertEqual(t, true, sell.Taker)
+
+	for _, o := range result.Orders {
+		test.AssertEqual(t, bitvavo.OrderStatusFilled, o.Status)
+	}
+
+	test.AssertEqual(t, 1, result.Stats.RoundTrips)
+	// EUR: 200 - 96.096 + 115 - 0.2875, ETH: 1 * 124
+	assertFloat(t, 342.6165, result.Stats.EndEquity)
+}
+
+func TestRunMarketOrderFillsOnNextOpen(t *testing.T) {
+	events, err := ReadCandles(strings.NewReader(candlesJSON), "ETH-EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	strategy := StrategyFunc(func(ctx context.Context, broker Broker, event Event) error {
+		if event.Timestamp == 2000 {
+			_, err := broker.NewOrder(ctx, event.Market, bitvavo.SideBuy, bitvavo.OrderTypeMarket, bitvavo.OrderNew{AmountQuote: "104"})
+			return err
+		}
+		return nil
+	})
+
+	result, err := Run(context.Background(), strategy, events, Config{Balances: map[string]float64{"EUR": 104}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.AssertEqual(t, 1, len(result.Fills))
+	test.AssertEqual(t, "104", result.Fills[0].Price)
+	test.AssertEqual(t, "1", result.Fills[0].Amount)
+	assertFloat(t, 124, result.Stats.EndEquity)
+}
+
+func assertFloat(t *testing.T, expected float64, actual float64) {
+	t.Helper()
+	if math.Abs(expected-actual) > 1e-9 {
+		t.Errorf("\nexpected: %v\nactual: %v\n", expected, actual)
+	}
+}
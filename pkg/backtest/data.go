@@ -0,0 +1,99 @@
+package backtest
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+const (
+	candlesLimit = 1440
+	tradesLimit  = 1000
+)
+
+// CandleEvents converts candles of market into events, sorted oldest first.
+func CandleEvents(market string, candles []bitvavo.CandleOnly) []Event {
+	events := make([]Event, len(candles))
+	for i := range candles {
+		candle := candles[i]
+		events[i] = Event{Market: market, Timestamp: candle.Timestamp, Candle: &candle}
+	}
+	return Merge(events)
+}
+
+// TradeEvents converts trades into events, sorted oldest first.
+func TradeEvents(trades []bitvavo.Trade) []Event {
+	events := make([]Event, len(trades))
+	for i := range trades {
+		trade := trades[i]
+		events[i] = Event{Market: trade.Market, Timestamp: trade.Timestamp, Trade: &trade}
+	}
+	return Merge(events)
+}
+
+// Merge combines multiple series of events (e.g: candles of multiple markets) into a single series sorted oldest first.
+func Merge(series ...[]Event) []Event {
+	events := make([]Event, 0)
+	for _, s := range series {
+		events = append(events, s...)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp < events[j].Timestamp })
+	return events
+}
+
+// LoadCandles retrieves all candles between start and end, paging through GetCandles.
+func LoadCandles(ctx context.Context, api bitvavo.PublicAPI, market string, interval bitvavo.Interval, start, end time.Time) ([]Event, error) {
+	candles, err := util.PageBackwards(start, end, candlesLimit, func(end time.Time) ([]bitvavo.CandleOnly, error) {
+		return api.GetCandles(ctx, market, interval, &bitvavo.CandleParams{Limit: candlesLimit, Start: start, End: end})
+	}, func(c bitvavo.CandleOnly) (int64, string) { return c.Timestamp, strconv.FormatInt(c.Timestamp, 10) })
+	if err != nil {
+		return nil, err
+	}
+	return CandleEvents(market, candles), nil
+}
+
+// LoadTrades retrieves all trades between start and end, paging through GetTrades.
+func LoadTrades(ctx context.Context, api bitvavo.PublicAPI, market string, start, end time.Time) ([]Event, error) {
+	trades, err := util.PageBackwards(start, end, tradesLimit, func(end time.Time) ([]bitvavo.Trade, error) {
+		return api.GetTrades(ctx, market, &bitvavo.TradeParams{Limit: tradesLimit, Start: start, End: end})
+	}, func(t bitvavo.Trade) (int64, string) { return t.Timestamp, t.Id })
+	if err != nil {
+		return nil, err
+	}
+	for i := range trades {
+		if trades[i].Market == "" {
+			trades[i].Market = market
+		}
+	}
+	return TradeEvents(trades), nil
+}
+
+// ReadCandles reads candles of market from a JSON array in the same format as returned by the API.
+func ReadCandles(r io.Reader, market string) ([]Event, error) {
+	var candles []bitvavo.CandleOnly
+	if err := json.NewDecoder(r).Decode(&candles); err != nil {
+		return nil, err
+	}
+	return CandleEvents(market, candles), nil
+}
+
+// ReadTrades reads trades from a JSON array in the same format as returned by the API.
+// If the trades have no market, market is used.
+func ReadTrades(r io.Reader, market string) ([]Event, error) {
+	var trades []bitvavo.Trade
+	if err := json.NewDecoder(r).Decode(&trades); err != nil {
+		return nil, err
+	}
+	for i := range trades {
+		if trades[i].Market == "" {
+			trades[i].Market = market
+		}
+	}
+	return TradeEvents(trades), nil
+}
@@ -0,0 +1,524 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+var (
+	ErrInvalidOrder        = func(reason string) error { return fmt.Errorf("invalid order: %s", reason) }
+	ErrInsufficientBalance = func(symbol string) error { return fmt.Errorf("insufficient balance for: %s", symbol) }
+	ErrOrderNotFound       = func(orderId string) error { return fmt.Errorf("order: %s not found", orderId) }
+)
+
+type balance struct {
+	available float64
+	inOrder   float64
+}
+
+// bar is the price range of an event, for trades all prices are equal.
+type bar struct {
+	open  float64
+	high  float64
+	low   float64
+	close float64
+}
+
+func barOf(event Event) bar {
+	if event.Candle != nil {
+		return bar{
+			open:  util.ParseFloat(event.Candle.Open),
+			high:  util.ParseFloat(event.Candle.High),
+			low:   util.ParseFloat(event.Candle.Low),
+			close: util.ParseFloat(event.Candle.Close),
+		}
+	}
+	price := util.ParseFloat(event.Trade.Price)
+	return bar{open: price, high: price, low: price, close: price}
+}
+
+type simOrder struct {
+	order       bitvavo.Order
+	base        string
+	quote       string
+	amount      float64
+	amountQuote float64
+	price       float64
+	trigger     float64
+	hold        float64
+	holdSymbol  string
+	evaluated   bool
+	triggered   bool
+}
+
+func (o *simOrder) isBuy() bool {
+	return o.order.Side == bitvavo.SideBuy
+}
+
+func (o *simOrder) isOpen() bool {
+	return o.order.Status == bitvavo.OrderStatusNew || o.order.Status == bitvavo.OrderStatusAwaitingTrigger
+}
+
+func (o *simOrder) isStop() bool {
+	switch o.order.OrderType {
+	case bitvavo.OrderTypeStopLoss, bitvavo.OrderTypeStopLossLimit, bitvavo.OrderTypeTakeProfit, bitvavo.OrderTypeTakeProfitLimit:
+		return true
+	}
+	return false
+}
+
+func (o *simOrder) isLimit() bool {
+	switch o.order.OrderType {
+	case bitvavo.OrderTypeLimit, bitvavo.OrderTypeStopLossLimit, bitvavo.OrderTypeTakeProfitLimit:
+		return true
+	}
+	return false
+}
+
+// triggersBelow reports whether the order triggers when the reference price drops to the trigger price.
+func (o *simOrder) triggersBelow() bool {
+	isStopLoss := o.order.OrderType == bitvavo.OrderTypeStopLoss || o.order.OrderType == bitvavo.OrderTypeStopLossLimit
+	return isStopLoss != o.isBuy()
+}
+
+type position struct {
+	amount float64
+	cost   float64
+}
+
+type engine struct {
+	config   Config
+	maker    float64
+	taker    float64
+	now      int64
+	nextId   int
+	balances map[string]*balance
+	prices   map[string]float64
+	orders   []*simOrder
+	fills    []bitvavo.Fill
+	equity   []EquityPoint
+
+	positions map[string]*position
+	realised  []float64
+	fees      float64
+}
+
+func newEngine(config Config) *engine {
+	if config.Quote == "" {
+		config.Quote = "EUR"
+	}
+
+	e := &engine{
+		config:    config,
+		maker:     util.ParseFloat(config.Fees.Maker),
+		taker:     util.ParseFloat(config.Fees.Taker),
+		balances:  make(map[string]*balance),
+		prices:    make(map[string]float64),
+		positions: make(map[string]*position),
+	}
+	for symbol, amount := range config.Balances {
+		e.balances[symbol] = &balance{available: amount}
+	}
+
+	return e
+}
+
+func (e *engine) process(ctx context.Context, strategy Strategy, event Event) error {
+	e.now = event.Timestamp
+
+	fills := e.match(event.Market, barOf(event))
+	e.prices[event.Market] = barOf(event).close
+	e.equity = append(e.equity, EquityPoint{Timestamp: e.now, Equity: e.valuate()})
+
+	if handler, ok := strategy.(FillHandler); ok {
+		for _, fill := range fills {
+			if err := handler.OnFill(ctx, e, fill); err != nil {
+				return err
+			}
+		}
+	}
+
+	return strategy.OnEvent(ctx, e, event)
+}
+
+// match processes all open orders of market against the price range of the current event.
+func (e *engine) match(market string, b bar) []bitvavo.Fill {
+	fills := make([]bitvavo.Fill, 0)
+
+	for _, o := range e.orders {
+		if o.order.Market != market || !o.isOpen() {
+			continue
+		}
+
+		first := !o.evaluated
+		o.evaluated = true
+
+		if o.order.OrderType == bitvavo.OrderTypeMarket {
+			fills = e.appendFill(fills, o, e.sidePrice(o, b.open), true)
+			continue
+		}
+
+		if o.isStop() && !o.triggered {
+			var (
+				ref       = o.order.TriggerReference
+				refOpen   = e.reference(ref, b.open)
+				triggered bool
+				level     float64
+			)
+			if o.triggersBelow() {
+				triggered = e.reference(ref, b.low) <= o.trigger
+				level = min(refOpen, o.trigger)
+			} else {
+				triggered = e.reference(ref, b.high) >= o.trigger
+				level = max(refOpen, o.trigger)
+			}
+			if !triggered {
+				continue
+			}
+
+			o.triggered = true
+			o.order.Status = bitvavo.OrderStatusNew
+			if !o.isLimit() {
+				fills = e.appendFill(fills, o, level, true)
+				continue
+			}
+
+			// a triggered stop limit order takes liquidity if its price is within the range of the event
+			if e.crosses(o, b) {
+				fills = e.appendFill(fills, o, o.price, true)
+			}
+			continue
+		}
+
+		if first && o.order.OrderType == bitvavo.OrderTypeLimit {
+			open := e.sidePrice(o, b.open)
+			marketable := (o.isBuy() && open <= o.price) || (!o.isBuy() && open >= o.price)
+			if marketable {
+				if o.order.PostOnly {
+					e.close(o, bitvavo.OrderStatusCanceledPo)
+				} else {
+					fills = e.appendFill(fills, o, open, true)
+				}
+				continue
+			}
+			if o.order.TimeInForce == bitvavo.TimeInForceIoc {
+				e.close(o, bitvavo.OrderStatusCanceledIoc)
+				continue
+			}
+			if o.order.TimeInForce == bitvavo.TimeInForceFok {
+				e.close(o, bitvavo.OrderStatusCanceledFok)
+				continue
+			}
+		}
+
+		// resting limit orders fill at their own price
+		if e.crosses(o, b) {
+			fills = e.appendFill(fills, o, o.price, false)
+		}
+	}
+
+	return fills
+}
+
+// crosses reports whether the limit price of o is within the range of b.
+func (e *engine) crosses(o *simOrder, b bar) bool {
+	if o.isBuy() {
+		return e.sidePrice(o, b.low) <= o.price
+	}
+	return e.sidePrice(o, b.high) >= o.price
+}
+
+// sidePrice returns the ask for buy orders and the bid for sell orders.
+func (e *engine) sidePrice(o *simOrder, price float64) float64 {
+	if o.isBuy() {
+		return price * (1 + e.config.Spread/2)
+	}
+	return price * (1 - e.config.Spread/2)
+}
+
+func (e *engine) reference(ref bitvavo.OrderTriggerRef, price float64) float64 {
+	switch ref {
+	case bitvavo.OrderTriggerRefBestBid:
+		return price * (1 - e.config.Spread/2)
+	case bitvavo.OrderTriggerRefBestAsk:
+		return price * (1 + e.config.Spread/2)
+	default:
+		return price
+	}
+}
+
+func (e *engine) appendFill(fills []bitvavo.Fill, o *simOrder, price float64, taker bool) []bitvavo.Fill {
+	if fill, ok := e.execute(o, price, taker); ok {
+		return append(fills, fill)
+	}
+	return fills
+}
+
+// execute fills the complete order at price, it cancels the order if the balance is insufficient.
+func (e *engine) execute(o *simOrder, price float64, taker bool) (bitvavo.Fill, bool) {
+	e.release(o)
+
+	rate := util.IfOrElse(taker, func() float64 { return e.taker }, e.maker)
+	amount := o.amount
+	if amount == 0 && o.isBuy() {
+		// fee is part of amountQuote
+		amount = o.amountQuote / (price * (1 + rate))
+	} else if amount == 0 {
+		amount = o.amountQuote / price
+	}
+
+	var (
+		quoteAmount = amount * price
+		fee         = quoteAmount * rate
+		base        = e.balance(o.base)
+		quote       = e.balance(o.quote)
+	)
+
+	if o.isBuy() {
+		if quote.available < quoteAmount+fee {
+			e.close(o, bitvavo.OrderStatusCanceled)
+			return bitvavo.Fill{}, false
+		}
+		quote.available -= quoteAmount + fee
+		base.available += amount
+	} else {
+		if base.available < amount {
+			e.close(o, bitvavo.OrderStatusCanceled)
+			return bitvavo.Fill{}, false
+		}
+		base.available -= amount
+		quote.available += quoteAmount - fee
+	}
+
+	e.track(o, amount, quoteAmount, fee)
+
+	fill := bitvavo.Fill{
+		FillId:      fmt.Sprintf("%s-fill", o.order.OrderId),
+		Market:      o.order.Market,
+		OrderId:     o.order.OrderId,
+		Timestamp:   e.now,
+		Amount:      util.FormatFloat(amount),
+		Side:        o.order.Side,
+		Price:       util.FormatFloat(price),
+		Taker:       taker,
+		Fee:         util.FormatFloat(fee),
+		FeeCurrency: o.quote,
+		Settled:     true,
+	}
+
+	o.order.Fills = append(o.order.Fills, fill)
+	o.order.FilledAmount = fill.Amount
+	o.order.FilledAmountQuote = util.FormatFloat(quoteAmount)
+	o.order.FeePaid = fill.Fee
+	o.order.FeeCurrency = o.quote
+	o.order.AmountRemaining = "0"
+	o.order.Status = bitvavo.OrderStatusFilled
+	o.order.Updated = e.now
+
+	e.fills = append(e.fills, fill)
+	e.fees += fee
+
+	return fill, true
+}
+
+// track keeps the average cost per market to calculate the realised profit of sell fills.
+func (e *engine) track(o *simOrder, amount, quoteAmount, fee float64) {
+	pos, ok := e.positions[o.order.Market]
+	if !ok {
+		pos = new(position)
+		e.positions[o.order.Market] = pos
+	}
+
+	if o.isBuy() {
+		pos.amount += amount
+		pos.cost += quoteAmount + fee
+		return
+	}
+
+	closed := min(amount, pos.amount)
+	if closed <= 0 {
+		return
+	}
+
+	var (
+		avg      = pos.cost / pos.amount
+		proceeds = (quoteAmount - fee) * closed / amount
+	)
+
+	e.realised = append(e.realised, proceeds-avg*closed)
+	pos.cost -= avg * closed
+	pos.amount -= closed
+}
+
+func (e *engine) close(o *simOrder, status bitvavo.OrderStatus) {
+	e.release(o)
+	o.order.Status = status
+	o.order.Updated = e.now
+}
+
+func (e *engine) release(o *simOrder) {
+	if o.hold == 0 {
+		return
+	}
+	b := e.balance(o.holdSymbol)
+	b.inOrder -= o.hold
+	b.available += o.hold
+	o.hold = 0
+	o.order.OnHold = "0"
+}
+
+func (e *engine) balance(symbol string) *balance {
+	b, ok := e.balances[symbol]
+	if !ok {
+		b = new(balance)
+		e.balances[symbol] = b
+	}
+	return b
+}
+
+// valuate returns the value of all balances in the configured quote currency.
+// Balances without a market to the quote currency (or without a price yet) are ignored.
+func (e *engine) valuate() float64 {
+	equity := 0.0
+	for symbol, b := range e.balances {
+		total := b.available + b.inOrder
+		if symbol == e.config.Quote {
+			equity += total
+		} else if price, ok := e.prices[fmt.Sprintf("%s-%s", symbol, e.config.Quote)]; ok {
+			equity += total * price
+		}
+	}
+	return equity
+}
+
+func (e *engine) GetBalance(_ context.Context, symbol ...string) ([]bitvavo.Balance, error) {
+	balances := make([]bitvavo.Balance, 0, len(e.balances))
+	for s, b := range e.balances {
+		if len(symbol) > 0 && symbol[0] != s {
+			continue
+		}
+		balances = append(balances, bitvavo.Balance{
+			Symbol:    s,
+			Available: util.FormatFloat(b.available),
+			InOrder:   util.FormatFloat(b.inOrder),
+		})
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Symbol < balances[j].Symbol })
+	return balances, nil
+}
+
+func (e *engine) GetOrdersOpen(_ context.Context, market ...string) ([]bitvavo.Order, error) {
+	orders := make([]bitvavo.Order, 0)
+	for _, o := range e.orders {
+		if o.isOpen() && (len(market) == 0 || market[0] == o.order.Market) {
+			orders = append(orders, o.order)
+		}
+	}
+	return orders, nil
+}
+
+func (e *engine) CancelOrder(_ context.Context, market string, orderId string) (string, error) {
+	for _, o := range e.orders {
+		if o.order.OrderId == orderId && o.order.Market == market && o.isOpen() {
+			e.close(o, bitvavo.OrderStatusCanceled)
+			return orderId, nil
+		}
+	}
+	return "", ErrOrderNotFound(orderId)
+}
+
+func (e *engine) NewOrder(_ context.Context, market string, side bitvavo.Side, orderType bitvavo.OrderType, order bitvavo.OrderNew) (bitvavo.Order, error) {
+	base, quote, ok := strings.Cut(market, "-")
+	if !ok {
+		return bitvavo.Order{}, ErrInvalidOrder(fmt.Sprintf("unknown market %s", market))
+	}
+
+	o := &simOrder{
+		base:        base,
+		quote:       quote,
+		amount:      util.ParseFloat(order.Amount),
+		amountQuote: util.ParseFloat(order.AmountQuote),
+		price:       util.ParseFloat(order.Price),
+		trigger:     util.ParseFloat(order.TriggerAmount),
+	}
+
+	if o.amount <= 0 && (orderType != bitvavo.OrderTypeMarket || o.amountQuote <= 0) {
+		return bitvavo.Order{}, ErrInvalidOrder("amount is required")
+	}
+
+	e.nextId++
+	o.order = bitvavo.Order{
+		OrderId:             fmt.Sprintf("backtest-%d", e.nextId),
+		Market:              market,
+		Created:             e.now,
+		Updated:             e.now,
+		Status:              bitvavo.OrderStatusNew,
+		Side:                side,
+		OrderType:           orderType,
+		Amount:              order.Amount,
+		AmountRemaining:     order.Amount,
+		Price:               order.Price,
+		TriggerAmount:       order.TriggerAmount,
+		TriggerPrice:        order.TriggerAmount,
+		TriggerType:         order.TriggerType,
+		TriggerReference:    order.TriggerReference,
+		TimeInForce:         util.IfOrElse(order.TimeInForce.Value == "", func() bitvavo.TimeInForce { return bitvavo.TimeInForceDefault }, order.TimeInForce),
+		PostOnly:            order.PostOnly,
+		SelfTradePrevention: order.SelfTradePrevention,
+		Visible:             true,
+	}
+
+	if o.isLimit() && o.price <= 0 {
+		return bitvavo.Order{}, ErrInvalidOrder("price is required for limit orders")
+	}
+	if o.isStop() {
+		if o.trigger <= 0 {
+			return bitvavo.Order{}, ErrInvalidOrder("triggerAmount is required for stop orders")
+		}
+		o.order.Status = bitvavo.OrderStatusAwaitingTrigger
+	}
+
+	// put the balance on hold, buy orders without price are checked once they fill
+	if o.isBuy() && o.isLimit() {
+		o.holdSymbol = quote
+		o.hold = o.amount * o.price * (1 + e.taker)
+	} else if !o.isBuy() && o.amount > 0 {
+		o.holdSymbol = base
+		o.hold = o.amount
+	}
+	if o.hold > 0 {
+		b := e.balance(o.holdSymbol)
+		if b.available < o.hold {
+			return bitvavo.Order{}, ErrInsufficientBalance(o.holdSymbol)
+		}
+		b.available -= o.hold
+		b.inOrder += o.hold
+		o.order.OnHold = util.FormatFloat(o.hold)
+		o.order.OnHoldCurrency = o.holdSymbol
+	}
+
+	e.orders = append(e.orders, o)
+
+	return o.order, nil
+}
+
+func (e *engine) result() Result {
+	orders := make([]bitvavo.Order, len(e.orders))
+	for i, o := range e.orders {
+		orders[i] = o.order
+	}
+	balances, _ := e.GetBalance(context.Background())
+
+	return Result{
+		Equity:   e.equity,
+		Fills:    e.fills,
+		Orders:   orders,
+		Balances: balances,
+		Stats:    newStats(e.equity, e.realised, len(e.fills), e.fees),
+	}
+}
@@ -0,0 +1,67 @@
+package backtest
+
+type Stats struct {
+	// Equity after the first event.
+	StartEquity float64
+
+	// Equity after the last event.
+	EndEquity float64
+
+	// (EndEquity - StartEquity) / StartEquity
+	Return float64
+
+	// The largest drop from a peak in equity as a fraction of that peak.
+	MaxDrawdown float64
+
+	// The number of fills.
+	Fills int
+
+	// The total amount of fees paid (in quote currency of the markets).
+	Fees float64
+
+	// The number of sell fills which (partially) closed a position.
+	RoundTrips int
+
+	// The fraction of round trips with a profit.
+	WinRate float64
+
+	// The realised profit of all round trips, using the average cost of the position and including fees.
+	RealisedPnL float64
+}
+
+func newStats(equity []EquityPoint, realised []float64, fills int, fees float64) Stats {
+	stats := Stats{
+		Fills:      fills,
+		Fees:       fees,
+		RoundTrips: len(realised),
+	}
+
+	if len(equity) > 0 {
+		stats.StartEquity = equity[0].Equity
+		stats.EndEquity = equity[len(equity)-1].Equity
+		if stats.StartEquity != 0 {
+			stats.Return = (stats.EndEquity - stats.StartEquity) / stats.StartEquity
+		}
+	}
+
+	peak := 0.0
+	for _, point := range equity {
+		peak = max(peak, point.Equity)
+		if peak > 0 {
+			stats.MaxDrawdown = max(stats.MaxDrawdown, (peak-point.Equity)/peak)
+		}
+	}
+
+	wins := 0
+	for _, pnl := range realised {
+		stats.RealisedPnL += pnl
+		if pnl > 0 {
+			wins++
+		}
+	}
+	if len(realised) > 0 {
+		stats.WinRate = float64(wins) / float64(len(realised))
+	}
+
+	return stats
+}
@@ -0,0 +1,233 @@
+package bitvavo
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cachedEndpoints are the endpoints of static reference data which can be cached.
+var cachedEndpoints = map[string]bool{
+	"GetMarkets": true,
+	"GetMarket":  true,
+	"GetAssets":  true,
+	"GetAsset":   true,
+}
+
+type CacheOption func(*Cache)
+
+// WithCacheTTL sets how long a response is fresh.
+//
+// Default: 1 hour
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// WithCacheStaleWhileRevalidate returns an expired response for at most stale after it expired,
+// while it is refreshed in the background.
+//
+// Default: 0 (expired responses are refreshed before returning)
+func WithCacheStaleWhileRevalidate(stale time.Duration) CacheOption {
+	return func(c *Cache) {
+		c.staleWhileRevalidate = stale
+	}
+}
+
+// WithCacheStaleIfError returns an expired response for at most stale after it expired, if refreshing it failed.
+//
+// Default: 24 hours
+func WithCacheStaleIfError(stale time.Duration) CacheOption {
+	return func(c *Cache) {
+		c.staleIfError = stale
+	}
+}
+
+// Cache caches the static reference data of GetMarkets, GetMarket, GetAssets and GetAsset (see: WithCache).
+// GetMarket and GetAsset are served from the cached GetMarkets and GetAssets responses if those are fresh.
+//
+// A Cache can be shared between clients, it is safe for concurrent use.
+type Cache struct {
+	ttl                  time.Duration
+	staleWhileRevalidate time.Duration
+	staleIfError         time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	value      any
+	fetched    time.Time
+	refreshing bool
+}
+
+func NewCache(options ...CacheOption) *Cache {
+	c := &Cache{
+		ttl:          time.Hour,
+		staleIfError: time.Hour * 24,
+		entries:      make(map[string]*cacheEntry),
+		now:          time.Now,
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	return c
+}
+
+// WithCache caches static reference data (markets and assets) in cache.
+func WithCache(cache *Cache) HttpOption {
+	return func(c *httpClient) {
+		c.httpConfig.middleware = append(c.httpConfig.middleware, cache.middleware)
+	}
+}
+
+// Invalidate removes the cached responses of endpoints (e.g: GetMarkets), all cached responses if no endpoint is given.
+func (c *Cache) Invalidate(endpoints ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(endpoints) == 0 {
+		clear(c.entries)
+		return
+	}
+	for key := range c.entries {
+		endpoint, _, _ := strings.Cut(key, "?")
+		if slices.Contains(endpoints, endpoint) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *Cache) middleware(next Invoker) Invoker {
+	return func(ctx context.Context, call *Call) (any, error) {
+		if !cachedEndpoints[call.Endpoint] {
+			return next(ctx, call)
+		}
+
+		key := call.Endpoint + "?" + call.Params.Encode()
+		now := c.now()
+
+		c.mu.Lock()
+		if value, ok := c.fromList(call, now); ok {
+			c.mu.Unlock()
+			return value, nil
+		}
+
+		entry, ok := c.entries[key]
+		if ok {
+			age := now.Sub(entry.fetched)
+			if age <= c.ttl {
+				c.mu.Unlock()
+				return clone(entry.value), nil
+			}
+			if age <= c.ttl+c.staleWhileRevalidate {
+				if !entry.refreshing {
+					entry.refreshing = true
+					go c.refresh(context.WithoutCancel(ctx), next, *call, key)
+				}
+				c.mu.Unlock()
+				return clone(entry.value), nil
+			}
+		}
+		c.mu.Unlock()
+
+		value, err := next(ctx, call)
+		if err != nil {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			if entry, ok := c.entries[key]; ok && now.Sub(entry.fetched) <= c.ttl+c.staleIfError {
+				return clone(entry.value), nil
+			}
+			return value, err
+		}
+
+		c.store(key, value)
+		return clone(value), nil
+	}
+}
+
+func (c *Cache) refresh(ctx context.Context, next Invoker, call Call, key string) {
+	call.Params = maps.Clone(call.Params)
+	call.Header = call.Header.Clone()
+	value, err := next(ctx, &call)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		if entry, ok := c.entries[key]; ok {
+			entry.refreshing = false
+		}
+		return
+	}
+	c.entries[key] = &cacheEntry{value: value, fetched: c.now()}
+}
+
+func (c *Cache) store(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = &cacheEntry{value: value, fetched: c.now()}
+}
+
+// fromList finds a single market or asset in a fresh GetMarkets or GetAssets response.
+func (c *Cache) fromList(call *Call, now time.Time) (any, bool) {
+	switch call.Endpoint {
+	case "GetMarket":
+		if entry, ok := c.entries["GetMarkets?"]; ok && now.Sub(entry.fetched) <= c.ttl {
+			market := call.Params.Get("market")
+			markets, _ := entry.value.([]Market)
+			for _, m := range markets {
+				if m.Market == market {
+					return clone(m), true
+				}
+			}
+		}
+	case "GetAsset":
+		if entry, ok := c.entries["GetAssets?"]; ok && now.Sub(entry.fetched) <= c.ttl {
+			symbol := call.Params.Get("symbol")
+			assets, _ := entry.value.([]Asset)
+			for _, a := range assets {
+				if a.Symbol == symbol {
+					return clone(a), true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// clone deep copies cached markets and assets, so callers can't modify the cache.
+func clone(value any) any {
+	switch v := value.(type) {
+	case Market:
+		v.OrderTypes = slices.Clone(v.OrderTypes)
+		return v
+	case []Market:
+		markets := make([]Market, len(v))
+		for i, market := range v {
+			markets[i] = clone(market).(Market)
+		}
+		return markets
+	case Asset:
+		v.Networks = slices.Clone(v.Networks)
+		return v
+	case []Asset:
+		assets := make([]Asset, len(v))
+		for i, asset := range v {
+			assets[i] = clone(asset).(Asset)
+		}
+		return assets
+	default:
+		return value
+	}
+}
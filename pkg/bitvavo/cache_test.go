@@ -0,0 +1,99 @@
+package bitvavo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+)
+
+func cachedAsset(symbol string, name string) string {
+	return `{"symbol":"` + symbol + `","name":"` + name + `","depositStatus":"OK","withdrawalStatus":"OK","networks":["` + symbol + `"]}`
+}
+
+func TestCache(t *testing.T) {
+	var (
+		requests atomic.Int32
+		failing  atomic.Bool
+		now      = time.Now()
+	)
+	transport := roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		if failing.Load() {
+			return nil, errors.New("connection refused")
+		}
+		return respond(http.StatusOK, "["+cachedAsset("ETH", "Ethereum")+","+cachedAsset("BTC", "Bitcoin")+"]"), nil
+	})
+
+	cache := NewCache(WithCacheTTL(time.Minute), WithCacheStaleIfError(time.Hour))
+	cache.now = func() time.Time { return now }
+	client := NewPublicHTTPClient(WithHttpClient(&http.Client{Transport: transport}), WithCache(cache))
+	ctx := context.Background()
+
+	assets, err := client.GetAssets(ctx)
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, 2, len(assets))
+	assets[0].Symbol = "modified"
+	assets[0].Networks[0] = "modified"
+
+	// fresh: served from the cache, also a single asset
+	assets, _ = client.GetAssets(ctx)
+	asset, _ := client.GetAsset(ctx, "BTC")
+	test.AssertEqual(t, "ETH", assets[0].Symbol)
+	test.AssertEqual(t, "ETH", assets[0].Networks[0])
+	test.AssertEqual(t, "Bitcoin", asset.Name)
+	asset.Networks[0] = "modified"
+	asset, _ = client.GetAsset(ctx, "BTC")
+	test.AssertEqual(t, "BTC", asset.Networks[0])
+	test.AssertEqual(t, int32(1), requests.Load())
+
+	// expired and refreshing fails: stale response
+	now = now.Add(time.Minute * 2)
+	failing.Store(true)
+	assets, err = client.GetAssets(ctx)
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, 2, len(assets))
+	test.AssertEqual(t, int32(2), requests.Load())
+
+	// invalidated: error is returned
+	cache.Invalidate("GetAssets")
+	_, err = client.GetAssets(ctx)
+	test.AssertEqual(t, true, err != nil)
+	test.AssertEqual(t, int32(3), requests.Load())
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	var requests atomic.Int32
+	transport := roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		if requests.Add(1) == 2 {
+			return respond(http.StatusOK, "["+cachedAsset("BTC", "Bitcoin")+"]"), nil
+		}
+		return respond(http.StatusOK, "["+cachedAsset("ETH", "Ethereum")+"]"), nil
+	})
+
+	var now atomic.Int64
+	now.Store(time.Now().UnixMilli())
+	cache := NewCache(WithCacheTTL(time.Minute), WithCacheStaleWhileRevalidate(time.Minute))
+	cache.now = func() time.Time { return time.UnixMilli(now.Load()) }
+	client := NewPublicHTTPClient(WithHttpClient(&http.Client{Transport: transport}), WithCache(cache))
+	ctx := context.Background()
+
+	_, _ = client.GetAssets(ctx)
+	now.Add((time.Second * 90).Milliseconds())
+
+	assets, _ := client.GetAssets(ctx)
+	test.AssertEqual(t, "ETH", assets[0].Symbol)
+
+	// the stale response is returned until the refresh has been stored
+	deadline := time.Now().Add(time.Second)
+	for assets[0].Symbol != "BTC" && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		assets, _ = client.GetAssets(ctx)
+	}
+	test.AssertEqual(t, "BTC", assets[0].Symbol)
+	test.AssertEqual(t, int32(2), requests.Load())
+}
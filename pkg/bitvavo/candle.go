@@ -111,3 +111,15 @@ func (c *Candle) UnmarshalJSON(bytes []byte) error {
 
 	return nil
 }
+
+// CandleOnly returns the candle without the market and interval.
+func (c Candle) CandleOnly() CandleOnly {
+	return CandleOnly{
+		Timestamp: c.Timestamp,
+		Open:      c.Open,
+		High:      c.High,
+		Low:       c.Low,
+		Close:     c.Close,
+		Volume:    c.Volume,
+	}
+}
@@ -0,0 +1,346 @@
+package bitvavo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+)
+
+var (
+	ErrInvalidAggregation = errors.New("aggregation size must be greater than 0")
+	ErrInvalidTrade       = func(trade Trade, err error) error {
+		return fmt.Errorf("invalid trade '%s' of %s: %s", trade.Id, trade.Market, err)
+	}
+)
+
+// AggregatedCandle is a candle built locally from trades, it has the same fields as Candle.
+type AggregatedCandle struct {
+	// The size of the candle: the duration of time candles (e.g: 10s, 3m, 90m), "volume:<volume>" for volume
+	// candles (e.g: volume:10) and "tick:<ticks>" for tick candles (e.g: tick:100).
+	Interval string `json:"interval"`
+
+	// The market of the trades in this candle.
+	Market string `json:"market"`
+
+	// Open timestamp in unix milliseconds.
+	// For time candles this is the start of the bucket, otherwise the timestamp of the first trade.
+	Timestamp int64 `json:"timestamp"`
+
+	Open   string `json:"open"`
+	High   string `json:"high"`
+	Low    string `json:"low"`
+	Close  string `json:"close"`
+	Volume string `json:"volume"`
+}
+
+// CandleOnly returns the candle without the market.
+func (c AggregatedCandle) CandleOnly() CandleOnly {
+	return CandleOnly{
+		Timestamp: c.Timestamp,
+		Open:      c.Open,
+		High:      c.High,
+		Low:       c.Low,
+		Close:     c.Close,
+		Volume:    c.Volume,
+	}
+}
+
+type AggregatedCandleEvent ListenerEvent[AggregatedCandle]
+
+type aggregateBar struct {
+	interval  string
+	market    string
+	timestamp int64
+	open      float64
+	high      float64
+	low       float64
+	close     float64
+	volume    float64
+	ticks     uint64
+}
+
+func (b *aggregateBar) add(price float64, amount float64) {
+	if b.ticks == 0 {
+		b.open = price
+		b.high = price
+		b.low = price
+	}
+
+	b.high = max(b.high, price)
+	b.low = min(b.low, price)
+	b.close = price
+	b.volume += amount
+	b.ticks++
+}
+
+func (b *aggregateBar) candle() AggregatedCandle {
+	return AggregatedCandle{
+		Interval:  b.interval,
+		Market:    b.market,
+		Timestamp: b.timestamp,
+		Open:      util.FormatFloat(b.open),
+		High:      util.FormatFloat(b.high),
+		Low:       util.FormatFloat(b.low),
+		Close:     util.FormatFloat(b.close),
+		Volume:    util.FormatFloat(b.volume),
+	}
+}
+
+// CandleAggregator builds OHLCV candles per market from trades.
+//
+// A candle is only emitted once it is closed, for time candles that is when a trade arrives for a
+// later bucket or when the bucket expired (see: Expire), for volume and tick candles that is when
+// the threshold has been reached. Buckets without any trades do not produce a candle.
+// A trade for a time candle which has already been closed is ignored.
+type CandleAggregator struct {
+	mu   sync.Mutex
+	bars map[string]*aggregateBar
+	// the open timestamp of the last closed time candle per market
+	closed map[string]int64
+
+	interval string
+
+	// bucket returns the open timestamp of the candle the trade belongs to.
+	bucket func(trade Trade) int64
+	// full reports whether the bar should be closed after adding a trade.
+	full func(bar *aggregateBar) bool
+	// duration of time candles in milliseconds, 0 for other candles.
+	duration int64
+}
+
+// NewTimeCandleAggregator creates candles with an arbitrary duration (e.g: 10s, 3m, 90m).
+// Candles are aligned to the unix epoch, just like the candles of the exchange.
+func NewTimeCandleAggregator(duration time.Duration) (*CandleAggregator, error) {
+	ms := duration.Milliseconds()
+	if ms <= 0 {
+		return nil, ErrInvalidAggregation
+	}
+
+	return &CandleAggregator{
+		bars:     make(map[string]*aggregateBar),
+		closed:   make(map[string]int64),
+		interval: formatDuration(duration),
+		bucket:   func(trade Trade) int64 { return trade.Timestamp - trade.Timestamp%ms },
+		full:     func(*aggregateBar) bool { return false },
+		duration: ms,
+	}, nil
+}
+
+// NewVolumeCandleAggregator creates a candle every time volume (in base currency) has been traded.
+// Trades are not split, so the volume of a candle can exceed volume by the size of the last trade.
+func NewVolumeCandleAggregator(volume float64) (*CandleAggregator, error) {
+	if volume <= 0 {
+		return nil, ErrInvalidAggregation
+	}
+
+	return &CandleAggregator{
+		bars:     make(map[string]*aggregateBar),
+		closed:   make(map[string]int64),
+		interval: "volume:" + util.FormatFloat(volume),
+		full:     func(bar *aggregateBar) bool { return bar.volume >= volume },
+		bucket:   func(trade Trade) int64 { return trade.Timestamp },
+	}, nil
+}
+
+// NewTickCandleAggregator creates a candle for every ticks amount of trades.
+func NewTickCandleAggregator(ticks uint64) (*CandleAggregator, error) {
+	if ticks == 0 {
+		return nil, ErrInvalidAggregation
+	}
+
+	return &CandleAggregator{
+		bars:     make(map[string]*aggregateBar),
+		closed:   make(map[string]int64),
+		interval: fmt.Sprintf("tick:%d", ticks),
+		full:     func(bar *aggregateBar) bool { return bar.ticks >= ticks },
+		bucket:   func(trade Trade) int64 { return trade.Timestamp },
+	}, nil
+}
+
+// Add adds a single trade and returns the candle that got closed by it (if any).
+// Trades should be added in chronological order per market. A trade with an invalid price or amount is not added.
+func (a *CandleAggregator) Add(trade Trade) ([]AggregatedCandle, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.add(trade)
+}
+
+// AddAll adds trades in chronological order, which makes it suitable for the (newest first) result of GetTrades.
+// It returns all candles that got closed. Trades with an invalid price or amount are skipped, the error joins their errors.
+func (a *CandleAggregator) AddAll(trades []Trade) ([]AggregatedCandle, error) {
+	sorted := make([]Trade, len(trades))
+	copy(sorted, trades)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var (
+		candles = make([]AggregatedCandle, 0)
+		errs    = make([]error, 0)
+	)
+	for _, trade := range sorted {
+		closed, err := a.add(trade)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		candles = append(candles, closed...)
+	}
+	return candles, errors.Join(errs...)
+}
+
+// Expire closes all time candles which ended before now.
+// It has no effect on volume and tick candles.
+func (a *CandleAggregator) Expire(now time.Time) []AggregatedCandle {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	candles := make([]AggregatedCandle, 0)
+	if a.duration == 0 {
+		return candles
+	}
+
+	for _, bar := range a.bars {
+		if bar.timestamp+a.duration <= now.UnixMilli() {
+			candles = append(candles, a.close(bar))
+		}
+	}
+
+	return sortCandles(candles)
+}
+
+// Flush closes and returns all candles that are still open, regardless if they are complete.
+func (a *CandleAggregator) Flush() []AggregatedCandle {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	candles := make([]AggregatedCandle, 0, len(a.bars))
+	for _, bar := range a.bars {
+		candles = append(candles, a.close(bar))
+	}
+
+	return sortCandles(candles)
+}
+
+// Listen consumes trade events (e.g: from TradesListener) and emits closed candles.
+// Errors are passed through. The returned channel is closed once events is closed, any open candles are flushed first.
+func (a *CandleAggregator) Listen(events <-chan TradeEvent) <-chan AggregatedCandleEvent {
+	chn := make(chan AggregatedCandleEvent)
+
+	go func() {
+		defer close(chn)
+
+		var tick <-chan time.Time
+		if a.duration > 0 {
+			ticker := time.NewTicker(min(time.Duration(a.duration)*time.Millisecond, time.Second))
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					for _, candle := range a.Flush() {
+						chn <- AggregatedCandleEvent{Value: candle}
+					}
+					return
+				}
+				if event.Error != nil {
+					chn <- AggregatedCandleEvent{Error: event.Error}
+					continue
+				}
+				candles, err := a.Add(event.Value)
+				if err != nil {
+					chn <- AggregatedCandleEvent{Error: err}
+				}
+				for _, candle := range candles {
+					chn <- AggregatedCandleEvent{Value: candle}
+				}
+			case now := <-tick:
+				for _, candle := range a.Expire(now) {
+					chn <- AggregatedCandleEvent{Value: candle}
+				}
+			}
+		}
+	}()
+
+	return chn
+}
+
+func (a *CandleAggregator) add(trade Trade) ([]AggregatedCandle, error) {
+	price, err := strconv.ParseFloat(trade.Price, 64)
+	if err != nil || price <= 0 {
+		return nil, ErrInvalidTrade(trade, fmt.Errorf("price '%s'", trade.Price))
+	}
+	amount, err := strconv.ParseFloat(trade.Amount, 64)
+	if err != nil || amount <= 0 {
+		return nil, ErrInvalidTrade(trade, fmt.Errorf("amount '%s'", trade.Amount))
+	}
+
+	candles := make([]AggregatedCandle, 0, 1)
+	bucket := a.bucket(trade)
+
+	bar, ok := a.bars[trade.Market]
+	if a.duration > 0 {
+		// the candle of a late trade has been closed already
+		if closed, ok := a.closed[trade.Market]; (ok && bucket <= closed) || (bar != nil && bucket < bar.timestamp) {
+			return candles, nil
+		}
+		if ok && bar.timestamp != bucket {
+			candles = append(candles, a.close(bar))
+			ok = false
+		}
+	}
+	if !ok {
+		bar = &aggregateBar{interval: a.interval, market: trade.Market, timestamp: bucket}
+		a.bars[trade.Market] = bar
+	}
+
+	bar.add(price, amount)
+
+	if a.full(bar) {
+		candles = append(candles, a.close(bar))
+	}
+
+	return candles, nil
+}
+
+// close removes the bar and returns its candle.
+func (a *CandleAggregator) close(bar *aggregateBar) AggregatedCandle {
+	delete(a.bars, bar.market)
+	a.closed[bar.market] = bar.timestamp
+	return bar.candle()
+}
+
+// formatDuration formats duration like the intervals of the exchange (e.g: 90m, 4h, 1d), with the largest unit
+// which divides it.
+func formatDuration(duration time.Duration) string {
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{{"d", 24 * time.Hour}, {"h", time.Hour}, {"m", time.Minute}, {"s", time.Second}, {"ms", time.Millisecond}}
+
+	for _, unit := range units {
+		if duration%unit.size == 0 {
+			return fmt.Sprintf("%d%s", duration/unit.size, unit.suffix)
+		}
+	}
+	return duration.String()
+}
+
+func sortCandles(candles []AggregatedCandle) []AggregatedCandle {
+	sort.Slice(candles, func(i, j int) bool {
+		if candles[i].Timestamp == candles[j].Timestamp {
+			return candles[i].Market < candles[j].Market
+		}
+		return candles[i].Timestamp < candles[j].Timestamp
+	})
+	return candles
+}
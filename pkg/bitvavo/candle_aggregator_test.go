@@ -0,0 +1,99 @@
+package bitvavo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+)
+
+func TestTimeCandleAggregator(t *testing.T) {
+	aggregator, err := NewTimeCandleAggregator(10 * time.Second)
+	test.AssertEqual(t, nil, err)
+
+	// newest first, just like GetTrades
+	trades := []Trade{
+		{Market: "ETH-EUR", Price: "101", Amount: "0.2", Timestamp: 10_500},
+		{Market: "ETH-EUR", Price: "99", Amount: "0.1", Timestamp: 9_000},
+		{Market: "ETH-EUR", Price: "103", Amount: "0.2", Timestamp: 5_000},
+		{Market: "ETH-EUR", Price: "100", Amount: "0.1", Timestamp: 1_000},
+	}
+
+	candles, err := aggregator.AddAll(trades)
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, 1, len(candles))
+	test.AssertEqual(t, AggregatedCandle{
+		Interval:  "10s",
+		Market:    "ETH-EUR",
+		Timestamp: 0,
+		Open:      "100",
+		High:      "103",
+		Low:       "99",
+		Close:     "99",
+		Volume:    "0.4",
+	}, candles[0])
+
+	// a late trade doesn't close the current candle, nor reopen the closed one
+	candles, err = aggregator.Add(Trade{Market: "ETH-EUR", Price: "150", Amount: "1", Timestamp: 9_500})
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, 0, len(candles))
+
+	test.AssertEqual(t, 0, len(aggregator.Expire(time.UnixMilli(19_999))))
+
+	expired := aggregator.Expire(time.UnixMilli(20_000))
+	test.AssertEqual(t, 1, len(expired))
+	test.AssertEqual(t, int64(10_000), expired[0].Timestamp)
+	test.AssertEqual(t, "0.2", expired[0].Volume)
+	test.AssertEqual(t, "101", expired[0].High)
+}
+
+func TestVolumeCandleAggregator(t *testing.T) {
+	aggregator, _ := NewVolumeCandleAggregator(1)
+
+	candles, _ := aggregator.Add(Trade{Market: "BTC-EUR", Price: "10", Amount: "0.6", Timestamp: 1})
+	test.AssertEqual(t, 0, len(candles))
+
+	candles, _ = aggregator.Add(Trade{Market: "BTC-EUR", Price: "12", Amount: "0.5", Timestamp: 2})
+	test.AssertEqual(t, 1, len(candles))
+	test.AssertEqual(t, "volume:1", candles[0].Interval)
+	test.AssertEqual(t, "1.1", candles[0].Volume)
+	test.AssertEqual(t, "12", candles[0].High)
+	test.AssertEqual(t, int64(1), candles[0].Timestamp)
+}
+
+func TestTickCandleAggregator(t *testing.T) {
+	aggregator, _ := NewTickCandleAggregator(2)
+
+	_, err := aggregator.Add(Trade{Market: "BTC-EUR", Price: "10", Amount: "1", Timestamp: 1})
+	test.AssertEqual(t, nil, err)
+	_, err = aggregator.Add(Trade{Market: "ETH-EUR", Price: "5", Amount: "1", Timestamp: 2})
+	test.AssertEqual(t, nil, err)
+
+	// an invalid trade is not added
+	_, err = aggregator.Add(Trade{Market: "BTC-EUR", Price: "", Amount: "1", Timestamp: 3})
+	test.AssertEqual(t, true, err != nil)
+
+	candles, _ := aggregator.Add(Trade{Market: "BTC-EUR", Price: "8", Amount: "1", Timestamp: 3})
+	test.AssertEqual(t, 1, len(candles))
+	test.AssertEqual(t, "tick:2", candles[0].Interval)
+	test.AssertEqual(t, "8", candles[0].Low)
+
+	flushed := aggregator.Flush()
+	test.AssertEqual(t, 1, len(flushed))
+	test.AssertEqual(t, "ETH-EUR", flushed[0].Market)
+}
+
+func TestCandleAggregatorInvalid(t *testing.T) {
+	_, err := NewTimeCandleAggregator(time.Microsecond)
+	test.AssertEqual(t, ErrInvalidAggregation, err)
+	_, err = NewVolumeCandleAggregator(0)
+	test.AssertEqual(t, ErrInvalidAggregation, err)
+	_, err = NewTickCandleAggregator(0)
+	test.AssertEqual(t, ErrInvalidAggregation, err)
+
+	aggregator, _ := NewTimeCandleAggregator(90 * time.Minute)
+	_, err = aggregator.AddAll([]Trade{{Market: "ETH-EUR", Price: "100", Amount: "x"}, {Market: "ETH-EUR", Price: "100", Amount: "1"}})
+	test.AssertEqual(t, true, err != nil && !errors.Is(err, ErrInvalidAggregation))
+	test.AssertEqual(t, "90m", aggregator.Flush()[0].Interval)
+}
@@ -70,6 +70,12 @@ func (d *DepositHistoryParams) Params() url.Values {
 	return params
 }
 
+// The values of DepositHistory.Status
+const (
+	DepositHistoryStatusCompleted = "completed"
+	DepositHistoryStatusCanceled  = "canceled"
+)
+
 type DepositHistory struct {
 	// The time your deposit of symbol was received by Bitvavo.
 	Timestamp int64 `json:"timestamp"`
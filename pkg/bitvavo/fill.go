@@ -65,7 +65,8 @@ func (f *Fill) UnmarshalJSON(bytes []byte) error {
 
 	f.OrderId = orderId
 	f.Market = market
-	f.FillId = fillId
+	// fills of an order and trades of the account have the fill id as id
+	f.FillId = util.IfOrElse(fillId != "", func() string { return fillId }, util.GetOrEmpty[string]("id", j))
 	f.Timestamp = int64(timestamp)
 	f.Amount = amount
 	f.Side = *sides.Parse(side)
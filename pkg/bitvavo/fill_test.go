@@ -0,0 +1,21 @@
+package bitvavo
+
+import (
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+)
+
+func TestFillUnmarshal(t *testing.T) {
+	// fills of an order have a fillId, trades of the account have the fill id as id
+	bytes := []byte(`[{"fillId":"f1","orderId":"o1","amount":"0.5","side":"buy"},{"id":"f2","orderId":"o1","amount":"0.5","side":"buy"}]`)
+
+	var fills []Fill
+	if err := json.Unmarshal(bytes, &fills); err != nil {
+		t.Fatal(err)
+	}
+
+	test.AssertEqual(t, "f1", fills[0].FillId)
+	test.AssertEqual(t, "f2", fills[1].FillId)
+}
@@ -0,0 +1,51 @@
+package bitvavo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+)
+
+const (
+	tradesHistoricLimit = 1000
+	historyLimit        = 500
+)
+
+// History is the part of PrivateAPI needed to walk the history of the account.
+type History interface {
+	// GetTradesHistoric returns historic trades for your account for market (e.g: ETH-EUR)
+	GetTradesHistoric(ctx context.Context, market string, params ...Params) ([]TradeHistoric, error)
+
+	// GetDepositHistory returns the deposit history of the account.
+	GetDepositHistory(ctx context.Context, params ...Params) ([]DepositHistory, error)
+
+	// GetWithdrawalHistory returns the withdrawal history of the account.
+	GetWithdrawalHistory(ctx context.Context, params ...Params) ([]WithdrawalHistory, error)
+}
+
+// GetAllTradesHistoric pages through all historic trades of market between start and end (most recent first).
+func GetAllTradesHistoric(ctx context.Context, client History, market string, start, end time.Time) ([]TradeHistoric, error) {
+	return util.PageBackwards(start, end, tradesHistoricLimit, func(end time.Time) ([]TradeHistoric, error) {
+		return client.GetTradesHistoric(ctx, market, &TradeParams{Limit: tradesHistoricLimit, Start: start, End: end})
+	}, func(t TradeHistoric) (int64, string) { return t.Timestamp, t.FillId })
+}
+
+// GetAllDepositHistory pages through all deposits between start and end (most recent first).
+func GetAllDepositHistory(ctx context.Context, client History, start, end time.Time) ([]DepositHistory, error) {
+	return util.PageBackwards(start, end, historyLimit, func(end time.Time) ([]DepositHistory, error) {
+		return client.GetDepositHistory(ctx, &DepositHistoryParams{Limit: historyLimit, Start: start, End: end})
+	}, func(d DepositHistory) (int64, string) {
+		return d.Timestamp, fmt.Sprint(d.Timestamp, d.Symbol, d.Amount, d.TxId)
+	})
+}
+
+// GetAllWithdrawalHistory pages through all withdrawals between start and end (most recent first).
+func GetAllWithdrawalHistory(ctx context.Context, client History, start, end time.Time) ([]WithdrawalHistory, error) {
+	return util.PageBackwards(start, end, historyLimit, func(end time.Time) ([]WithdrawalHistory, error) {
+		return client.GetWithdrawalHistory(ctx, &WithdrawalHistoryParams{Limit: historyLimit, Start: start, End: end})
+	}, func(w WithdrawalHistory) (int64, string) {
+		return w.Timestamp, fmt.Sprint(w.Timestamp, w.Symbol, w.Amount, w.TxId)
+	})
+}
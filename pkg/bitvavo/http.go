@@ -5,8 +5,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -25,60 +27,99 @@ var (
 
 func httpDelete[T any](
 	ctx context.Context,
+	endpoint string,
 	url string,
 	params url.Values,
-	httpConfg *httpConfig,
+	httpConfig *httpConfig,
 	authConfig *authConfig,
 ) (T, error) {
-	req, _ := http.NewRequestWithContext(ctx, "DELETE", createRequestUrl(url, params), nil)
-	return httpDo[T](req, make([]byte, 0), httpConfg, authConfig)
+	return httpCall[T](ctx, newCall(endpoint, "DELETE", url, params, nil, authConfig), httpConfig, authConfig)
 }
 
 func httpGet[T any](
 	ctx context.Context,
+	endpoint string,
 	url string,
 	params url.Values,
-	httpConfg *httpConfig,
+	httpConfig *httpConfig,
 	authConfig *authConfig,
 ) (T, error) {
-	req, _ := http.NewRequestWithContext(ctx, "GET", createRequestUrl(url, params), nil)
-	return httpDo[T](req, make([]byte, 0), httpConfg, authConfig)
+	return httpCall[T](ctx, newCall(endpoint, "GET", url, params, nil, authConfig), httpConfig, authConfig)
 }
 
 func httpPost[T any](
 	ctx context.Context,
+	endpoint string,
 	url string,
 	body any,
 	params url.Values,
 	httpConfig *httpConfig,
 	authConfig *authConfig,
 ) (T, error) {
-	payload, err := json.Marshal(body)
-	if err != nil {
-		var empty T
-		return empty, err
-	}
-
-	req, _ := http.NewRequestWithContext(ctx, "POST", createRequestUrl(url, params), bytes.NewBuffer(payload))
-	return httpDo[T](req, payload, httpConfig, authConfig)
+	return httpCall[T](ctx, newCall(endpoint, "POST", url, params, body, authConfig), httpConfig, authConfig)
 }
 
 func httpPut[T any](
 	ctx context.Context,
+	endpoint string,
 	url string,
 	body any,
 	params url.Values,
 	httpConfig *httpConfig,
 	authConfig *authConfig,
 ) (T, error) {
-	payload, err := json.Marshal(body)
+	return httpCall[T](ctx, newCall(endpoint, "PUT", url, params, body, authConfig), httpConfig, authConfig)
+}
+
+// httpCall passes call through the middleware of httpConfig, the last invoker signs and sends the request.
+func httpCall[T any](
+	ctx context.Context,
+	call *Call,
+	httpConfig *httpConfig,
+	authConfig *authConfig,
+) (T, error) {
+	var empty T
+
+	send := func(ctx context.Context, call *Call) (any, error) {
+		call.Attempt++
+		if call.Attempt > 1 {
+			httpConfig.metrics.IncRetry(call.Endpoint)
+		}
+
+		payload := make([]byte, 0)
+		if call.Body != nil {
+			var err error
+			if payload, err = json.Marshal(call.Body); err != nil {
+				return empty, err
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, call.Method, createRequestUrl(apiURL+call.Path, call.Params), bytes.NewReader(payload))
+		if err != nil {
+			return empty, err
+		}
+		for key, values := range call.Header {
+			req.Header[key] = values
+		}
+
+		return httpDo[T](req, payload, httpConfig, authConfig)
+	}
+
+	invoker := send
+	if httpConfig.guard != nil {
+		invoker = httpConfig.guard(send)
+	}
+
+	result, err := chain(httpConfig.middleware, invoker)(ctx, call)
 	if err != nil {
-		var empty T
 		return empty, err
 	}
 
-	req, _ := http.NewRequestWithContext(ctx, "PUT", createRequestUrl(url, params), bytes.NewBuffer(payload))
-	return httpDo[T](req, payload, httpConfig, authConfig)
+	value, ok := result.(T)
+	if !ok {
+		return empty, ErrMiddlewareResult(call.Endpoint, result, empty)
+	}
+	return value, nil
 }
 
 func httpDo[T any](
@@ -92,13 +133,32 @@ func httpDo[T any](
 		return empty, err
 	}
 
-	debug(httpConfig.printer, fmt.Sprint("http request ", request.Method, " url=", request.URL.String()))
+	var (
+		ctx   = request.Context()
+		attrs []any
+		log   = func(level slog.Level, msg string, args ...any) {
+			if httpConfig.printer == nil && !httpConfig.logger.Enabled(ctx, level) {
+				return
+			}
+			if attrs == nil {
+				attrs = requestAttrs(request)
+			}
+			logTo(ctx, httpConfig.logger, httpConfig.printer, level, msg, slices.Concat(attrs, args)...)
+		}
+	)
+
+	log(slog.LevelDebug, "http request", slog.String("query", request.URL.RawQuery))
+	log(LevelTrace, "http request body", slog.Any("headers", headers(request.Header)), slog.Any("body", util.LogBytes(body)))
 
+	start := time.Now()
 	response, err := httpConfig.client.Do(request)
+	latency := time.Since(start)
 	if err != nil {
-		debug(httpConfig.printer, fmt.Sprint("http response error: ", err))
+		httpConfig.metrics.ObserveRequest(endpointOf(request), 0, latency)
+		log(slog.LevelError, "http request failed", slog.Duration("latency", latency), slog.Any("error", err))
 		return empty, err
 	}
+	httpConfig.metrics.ObserveRequest(endpointOf(request), response.StatusCode, latency)
 
 	defer func() {
 		_ = response.Body.Close()
@@ -108,22 +168,24 @@ func httpDo[T any](
 		return empty, err
 	}
 
-	if response.StatusCode > http.StatusIMUsed {
-		return empty, unwrapErr(response, httpConfig.printer)
+	b, err := io.ReadAll(response.Body)
+	if err != nil {
+		return empty, err
 	}
 
-	return unwrapBody[T](response, httpConfig.printer)
-}
+	level := util.IfOrElse(response.StatusCode > http.StatusIMUsed, func() slog.Level { return slog.LevelWarn }, slog.LevelDebug)
+	log(level, "http response", slog.Int("status", response.StatusCode), slog.Duration("latency", latency), slog.String("ratelimit", response.Header.Get(headerRatelimit)))
+	log(LevelTrace, "http response body", slog.Int("status", response.StatusCode), slog.Any("body", util.LogBytes(b)))
 
-func unwrapBody[T any](response *http.Response, printer DebugPrinter) (T, error) {
-	var data T
-	b, err := io.ReadAll(response.Body)
-	if err != nil {
-		return data, err
+	if response.StatusCode > http.StatusIMUsed {
+		return empty, unwrapErr(response.StatusCode, b)
 	}
 
-	debug(printer, fmt.Sprint("http response body: ", string(b), " statusCode=", response.StatusCode))
+	return unwrapBody[T](b)
+}
 
+func unwrapBody[T any](b []byte) (T, error) {
+	var data T
 	if err := json.Unmarshal(b, &data); err != nil {
 		return data, err
 	}
@@ -131,17 +193,10 @@ func unwrapBody[T any](response *http.Response, printer DebugPrinter) (T, error)
 	return data, nil
 }
 
-func unwrapErr(response *http.Response, printer DebugPrinter) error {
-	b, err := io.ReadAll(response.Body)
-	if err != nil {
-		return err
-	}
-
-	debug(printer, fmt.Sprint("http response body: ", string(b), " statusCode=", response.StatusCode))
-
+func unwrapErr(statusCode int, b []byte) error {
 	var apiError *ApiError
 	if err := json.Unmarshal(b, &apiError); err != nil {
-		return ErrNOKResponse(response.StatusCode, b)
+		return ErrNOKResponse(statusCode, b)
 	}
 	return apiError
 }
@@ -156,8 +211,8 @@ func updateRateLimits(
 				return ErrHeaderNoValue(headerRatelimit)
 			}
 			rateLimit := util.MustInt64(value[0])
-			debug(httpConfig.printer, fmt.Sprint("http rate limit is currently: ", rateLimit))
 			httpConfig.updateRateLimit(rateLimit)
+			httpConfig.metrics.SetRateLimitRemaining(rateLimit)
 		}
 		if key == headerRatelimitResetAt {
 			if len(value) == 0 {
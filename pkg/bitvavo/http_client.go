@@ -3,6 +3,7 @@ package bitvavo
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"sync"
@@ -181,6 +182,8 @@ func WithWindowTime(windowTimeMs uint16) HttpOption {
 	}
 }
 
+// WithDebugPrinter prints every log line, including request and response bodies, to printer.
+// Prefer WithLogger for structured and leveled logging.
 func WithDebugPrinter(printer DebugPrinter) HttpOption {
 	return func(c *httpClient) {
 		c.httpConfig.printer = printer
@@ -204,6 +207,12 @@ type httpConfig struct {
 	updateRateLimitResetAt func(resetAt time.Time)
 	client                 *http.Client
 	printer                DebugPrinter
+	logger                 *slog.Logger
+	metrics                Metrics
+	middleware             []Middleware
+
+	// runs after all middleware, right before the request is sent (see: WithWithdrawalGuard)
+	guard Middleware
 }
 
 type httpClient struct {
@@ -222,6 +231,8 @@ func NewPublicHTTPClient(options ...HttpOption) PublicAPI {
 		updateRateLimit:        client.updateRateLimit,
 		updateRateLimitResetAt: client.updateRateLimitResetAt,
 		client:                 http.DefaultClient,
+		logger:                 discardLogger,
+		metrics:                noopMetrics{},
 	}
 
 	for _, opt := range options {
@@ -242,6 +253,7 @@ func (c *httpClient) GetRateLimitResetAt() time.Time {
 func (c *httpClient) GetTime(ctx context.Context) (int64, error) {
 	resp, err := httpGet[map[string]float64](
 		ctx,
+		"GetTime",
 		fmt.Sprintf("%s/time", apiURL),
 		emptyParams,
 		c.httpConfig,
@@ -257,6 +269,7 @@ func (c *httpClient) GetTime(ctx context.Context) (int64, error) {
 func (c *httpClient) GetMarkets(ctx context.Context) ([]Market, error) {
 	return httpGet[[]Market](
 		ctx,
+		"GetMarkets",
 		fmt.Sprintf("%s/markets", apiURL),
 		emptyParams,
 		c.httpConfig,
@@ -270,6 +283,7 @@ func (c *httpClient) GetMarket(ctx context.Context, market string) (Market, erro
 
 	return httpGet[Market](
 		ctx,
+		"GetMarket",
 		fmt.Sprintf("%s/markets", apiURL),
 		params,
 		c.httpConfig,
@@ -280,6 +294,7 @@ func (c *httpClient) GetMarket(ctx context.Context, market string) (Market, erro
 func (c *httpClient) GetAssets(ctx context.Context) ([]Asset, error) {
 	return httpGet[[]Asset](
 		ctx,
+		"GetAssets",
 		fmt.Sprintf("%s/assets", apiURL),
 		emptyParams,
 		c.httpConfig,
@@ -293,6 +308,7 @@ func (c *httpClient) GetAsset(ctx context.Context, symbol string) (Asset, error)
 
 	return httpGet[Asset](
 		ctx,
+		"GetAsset",
 		fmt.Sprintf("%s/assets", apiURL),
 		params,
 		c.httpConfig,
@@ -308,6 +324,7 @@ func (c *httpClient) GetOrderBook(ctx context.Context, market string, depth ...u
 
 	return httpGet[Book](
 		ctx,
+		"GetOrderBook",
 		fmt.Sprintf("%s/%s/book", apiURL, market),
 		params,
 		c.httpConfig,
@@ -322,6 +339,7 @@ func (c *httpClient) GetTrades(ctx context.Context, market string, opt ...Params
 	}
 	return httpGet[[]Trade](
 		ctx,
+		"GetTrades",
 		fmt.Sprintf("%s/%s/trades", apiURL, market),
 		params,
 		c.httpConfig,
@@ -338,6 +356,7 @@ func (c *httpClient) GetCandles(ctx context.Context, market string, interval Int
 
 	return httpGet[[]CandleOnly](
 		ctx,
+		"GetCandles",
 		fmt.Sprintf("%s/%s/candles", apiURL, market),
 		params,
 		c.httpConfig,
@@ -348,6 +367,7 @@ func (c *httpClient) GetCandles(ctx context.Context, market string, interval Int
 func (c *httpClient) GetTickerPrices(ctx context.Context) ([]TickerPrice, error) {
 	return httpGet[[]TickerPrice](
 		ctx,
+		"GetTickerPrices",
 		fmt.Sprintf("%s/ticker/price", apiURL),
 		emptyParams,
 		c.httpConfig,
@@ -361,6 +381,7 @@ func (c *httpClient) GetTickerPrice(ctx context.Context, market string) (TickerP
 
 	return httpGet[TickerPrice](
 		ctx,
+		"GetTickerPrice",
 		fmt.Sprintf("%s/ticker/price", apiURL),
 		params,
 		c.httpConfig,
@@ -371,6 +392,7 @@ func (c *httpClient) GetTickerPrice(ctx context.Context, market string) (TickerP
 func (c *httpClient) GetTickerBooks(ctx context.Context) ([]TickerBook, error) {
 	return httpGet[[]TickerBook](
 		ctx,
+		"GetTickerBooks",
 		fmt.Sprintf("%s/ticker/book", apiURL),
 		emptyParams,
 		c.httpConfig,
@@ -384,6 +406,7 @@ func (c *httpClient) GetTickerBook(ctx context.Context, market string) (TickerBo
 
 	return httpGet[TickerBook](
 		ctx,
+		"GetTickerBook",
 		fmt.Sprintf("%s/ticker/book", apiURL),
 		params,
 		c.httpConfig,
@@ -394,6 +417,7 @@ func (c *httpClient) GetTickerBook(ctx context.Context, market string) (TickerBo
 func (c *httpClient) GetTickers24h(ctx context.Context) ([]Ticker24hData, error) {
 	return httpGet[[]Ticker24hData](
 		ctx,
+		"GetTickers24h",
 		fmt.Sprintf("%s/ticker/24h", apiURL),
 		emptyParams,
 		c.httpConfig,
@@ -407,6 +431,7 @@ func (c *httpClient) GetTicker24h(ctx context.Context, market string) (Ticker24h
 
 	return httpGet[Ticker24hData](
 		ctx,
+		"GetTicker24h",
 		fmt.Sprintf("%s/ticker/24h", apiURL),
 		params,
 		c.httpConfig,
@@ -433,6 +458,8 @@ func NewPrivateHTTPClient(apiKey, apiSecret string, options ...HttpOption) Priva
 		updateRateLimit:        client.updateRateLimit,
 		updateRateLimitResetAt: client.updateRateLimitResetAt,
 		client:                 http.DefaultClient,
+		logger:                 discardLogger,
+		metrics:                noopMetrics{},
 	}
 	client.authConfig = &authConfig{
 		apiKey:     apiKey,
@@ -455,6 +482,7 @@ func (c *httpClient) GetBalance(ctx context.Context, symbol ...string) ([]Balanc
 
 	return httpGet[[]Balance](
 		ctx,
+		"GetBalance",
 		fmt.Sprintf("%s/balance", apiURL),
 		params,
 		c.httpConfig,
@@ -465,6 +493,7 @@ func (c *httpClient) GetBalance(ctx context.Context, symbol ...string) ([]Balanc
 func (c *httpClient) GetAccount(ctx context.Context) (Account, error) {
 	return httpGet[Account](
 		ctx,
+		"GetAccount",
 		fmt.Sprintf("%s/account", apiURL),
 		emptyParams,
 		c.httpConfig,
@@ -481,6 +510,7 @@ func (c *httpClient) GetOrders(ctx context.Context, market string, opt ...Params
 
 	return httpGet[[]Order](
 		ctx,
+		"GetOrders",
 		fmt.Sprintf("%s/orders", apiURL),
 		params,
 		c.httpConfig,
@@ -496,6 +526,7 @@ func (c *httpClient) GetOrdersOpen(ctx context.Context, market ...string) ([]Ord
 
 	return httpGet[[]Order](
 		ctx,
+		"GetOrdersOpen",
 		fmt.Sprintf("%s/ordersOpen", apiURL),
 		params,
 		c.httpConfig,
@@ -510,6 +541,7 @@ func (c *httpClient) GetOrder(ctx context.Context, market string, orderId string
 
 	return httpGet[Order](
 		ctx,
+		"GetOrder",
 		fmt.Sprintf("%s/order", apiURL),
 		params,
 		c.httpConfig,
@@ -525,6 +557,7 @@ func (c *httpClient) CancelOrders(ctx context.Context, market ...string) ([]stri
 
 	resp, err := httpDelete[[]map[string]string](
 		ctx,
+		"CancelOrders",
 		fmt.Sprintf("%s/orders", apiURL),
 		params,
 		c.httpConfig,
@@ -549,6 +582,7 @@ func (c *httpClient) CancelOrder(ctx context.Context, market string, orderId str
 
 	resp, err := httpDelete[map[string]string](
 		ctx,
+		"CancelOrder",
 		fmt.Sprintf("%s/order", apiURL),
 		params,
 		c.httpConfig,
@@ -568,6 +602,7 @@ func (c *httpClient) NewOrder(ctx context.Context, market string, side Side, ord
 
 	return httpPost[Order](
 		ctx,
+		"NewOrder",
 		fmt.Sprintf("%s/order", apiURL),
 		order,
 		emptyParams,
@@ -582,6 +617,7 @@ func (c *httpClient) UpdateOrder(ctx context.Context, market string, orderId str
 
 	return httpPut[Order](
 		ctx,
+		"UpdateOrder",
 		fmt.Sprintf("%s/order", apiURL),
 		order,
 		emptyParams,
@@ -599,6 +635,7 @@ func (c *httpClient) GetTradesHistoric(ctx context.Context, market string, opt .
 
 	return httpGet[[]TradeHistoric](
 		ctx,
+		"GetTradesHistoric",
 		fmt.Sprintf("%s/trades", apiURL),
 		params,
 		c.httpConfig,
@@ -612,6 +649,7 @@ func (c *httpClient) GetDepositAsset(ctx context.Context, symbol string) (Deposi
 
 	return httpGet[DepositAsset](
 		ctx,
+		"GetDepositAsset",
 		fmt.Sprintf("%s/deposit", apiURL),
 		params,
 		c.httpConfig,
@@ -626,6 +664,7 @@ func (c *httpClient) GetDepositHistory(ctx context.Context, opt ...Params) ([]De
 	}
 	return httpGet[[]DepositHistory](
 		ctx,
+		"GetDepositHistory",
 		fmt.Sprintf("%s/depositHistory", apiURL),
 		params,
 		c.httpConfig,
@@ -640,6 +679,7 @@ func (c *httpClient) GetWithdrawalHistory(ctx context.Context, opt ...Params) ([
 	}
 	return httpGet[[]WithdrawalHistory](
 		ctx,
+		"GetWithdrawalHistory",
 		fmt.Sprintf("%s/withdrawalHistory", apiURL),
 		params,
 		c.httpConfig,
@@ -654,6 +694,7 @@ func (c *httpClient) Withdraw(ctx context.Context, symbol string, amount string,
 
 	return httpPost[WithDrawalResponse](
 		ctx,
+		"Withdraw",
 		fmt.Sprintf("%s/withdrawal", apiURL),
 		withdrawal,
 		emptyParams,
@@ -0,0 +1,132 @@
+package bitvavo
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+)
+
+var ErrBookOutOfSync = errors.New("book update is out of sequence, a new snapshot is required")
+
+// LocalBook is an order book maintained from a snapshot (see: GetOrderBook) and the updates of the BookListener.
+// It is safe for concurrent use.
+type LocalBook struct {
+	mu     sync.RWMutex
+	market string
+	nonce  int64
+	bids   map[float64]Page
+	asks   map[float64]Page
+}
+
+// NewLocalBook creates a book from a snapshot.
+func NewLocalBook(snapshot Book) *LocalBook {
+	b := &LocalBook{
+		market: snapshot.Market,
+		nonce:  snapshot.Nonce,
+		bids:   make(map[float64]Page),
+		asks:   make(map[float64]Page),
+	}
+	applyPages(b.bids, snapshot.Bids)
+	applyPages(b.asks, snapshot.Asks)
+
+	return b
+}
+
+// Apply applies an update from the BookListener.
+// Updates which are older than the book are ignored, ErrBookOutOfSync is returned if an update has been missed.
+func (b *LocalBook) Apply(update Book) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if update.Nonce <= b.nonce {
+		return nil
+	}
+	if update.Nonce != b.nonce+1 {
+		return ErrBookOutOfSync
+	}
+
+	applyPages(b.bids, update.Bids)
+	applyPages(b.asks, update.Asks)
+	b.nonce = update.Nonce
+
+	return nil
+}
+
+// Market returns the market of the book (e.g: ETH-EUR)
+func (b *LocalBook) Market() string {
+	return b.market
+}
+
+// Nonce returns the nonce of the last applied update.
+func (b *LocalBook) Nonce() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.nonce
+}
+
+// Book returns a copy of the book, bids sorted from high to low and asks from low to high.
+//
+// Optionally provide the depth (single value) to return the top depth orders only.
+func (b *LocalBook) Book(depth ...uint64) Book {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return Book{
+		Market: b.market,
+		Nonce:  b.nonce,
+		Bids:   sortedPages(b.bids, true, depth...),
+		Asks:   sortedPages(b.asks, false, depth...),
+	}
+}
+
+// BestBid returns the highest bid, false if there are no bids.
+func (b *LocalBook) BestBid() (Page, bool) {
+	bids := b.Book(1).Bids
+	if len(bids) == 0 {
+		return Page{}, false
+	}
+	return bids[0], true
+}
+
+// BestAsk returns the lowest ask, false if there are no asks.
+func (b *LocalBook) BestAsk() (Page, bool) {
+	asks := b.Book(1).Asks
+	if len(asks) == 0 {
+		return Page{}, false
+	}
+	return asks[0], true
+}
+
+func applyPages(levels map[float64]Page, pages []Page) {
+	for _, page := range pages {
+		price := util.ParseFloat(page.Price)
+		if util.ParseFloat(page.Size) == 0 {
+			delete(levels, price)
+		} else {
+			levels[price] = page
+		}
+	}
+}
+
+func sortedPages(levels map[float64]Page, descending bool, depth ...uint64) []Page {
+	prices := make([]float64, 0, len(levels))
+	for price := range levels {
+		prices = append(prices, price)
+	}
+	sort.Float64s(prices)
+	if descending {
+		sort.Sort(sort.Reverse(sort.Float64Slice(prices)))
+	}
+	if len(depth) > 0 && uint64(len(prices)) > depth[0] {
+		prices = prices[:depth[0]]
+	}
+
+	pages := make([]Page, len(prices))
+	for i, price := range prices {
+		pages[i] = levels[price]
+	}
+	return pages
+}
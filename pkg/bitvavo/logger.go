@@ -0,0 +1,97 @@
+package bitvavo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/socket"
+)
+
+// LevelTrace is more verbose than slog.LevelDebug, request/response bodies and websocket frames are only logged at this level
+// because they contain balances, addresses and orders.
+const LevelTrace = socket.LevelTrace
+
+const redacted = "[REDACTED]"
+
+// WithLogger logs requests and responses of the http client to logger.
+//
+// Requests are logged at debug level with method, path, market, status and latency, failed requests at warn
+// or error level. Headers and bodies are only logged at LevelTrace, with the API key and signature redacted.
+func WithLogger(logger *slog.Logger) HttpOption {
+	return func(c *httpClient) {
+		c.httpConfig.logger = logger
+	}
+}
+
+// WithWebSocketLogger logs connection events at info/warn level, (un)subscriptions at debug level
+// and received frames at LevelTrace to logger.
+func WithWebSocketLogger(logger *slog.Logger) WebSocketOption {
+	return func(ws *WebSocket) {
+		ws.logger = logger
+	}
+}
+
+// logTo logs to logger and prints the message with its attributes to printer, if there is one.
+func logTo(ctx context.Context, logger *slog.Logger, printer DebugPrinter, level slog.Level, msg string, args ...any) {
+	logger.Log(ctx, level, msg, args...)
+
+	if printer != nil {
+		record := slog.NewRecord(time.Time{}, level, msg, 0)
+		record.Add(args...)
+
+		line := new(strings.Builder)
+		line.WriteString(msg)
+		record.Attrs(func(attr slog.Attr) bool {
+			fmt.Fprintf(line, " %s=%s", attr.Key, attr.Value.Resolve())
+			return true
+		})
+		debug(printer, line.String())
+	}
+}
+
+// requestAttrs returns the method, path and market (if any) of request.
+func requestAttrs(request *http.Request) []any {
+	attrs := []any{
+		slog.String("method", request.Method),
+		slog.String("path", request.URL.Path),
+	}
+
+	market := request.URL.Query().Get("market")
+	if segments := strings.Split(request.URL.Path, "/"); market == "" && len(segments) == 4 && strings.Contains(segments[2], "-") {
+		market = segments[2]
+	}
+	if market != "" {
+		attrs = append(attrs, slog.String("market", market))
+	}
+
+	return attrs
+}
+
+// headers logs the headers of a request as group, with the API key and signature redacted.
+type headers http.Header
+
+func (h headers) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(h))
+	for key, values := range h {
+		value := strings.Join(values, ",")
+		if key == headerAccessKey || key == headerAccessSignature {
+			value = redacted
+		}
+		attrs = append(attrs, slog.String(key, value))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// discardHandler is the default handler, it drops all records.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+var discardLogger = slog.New(discardHandler{})
@@ -0,0 +1,64 @@
+package bitvavo
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+)
+
+func TestLogRedactsCredentials(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{Level: LevelTrace}))
+
+	request, _ := http.NewRequest("GET", apiURL+"/ETH-EUR/book", nil)
+	if err := setHeaders(request, nil, &authConfig{apiKey: "MY_API_KEY", apiSecret: "MY_API_SECRET", windowTime: 1000}); err != nil {
+		t.Fatal(err)
+	}
+
+	logTo(context.Background(), logger, nil, LevelTrace, "http request body", append(requestAttrs(request), slog.Any("headers", headers(request.Header)))...)
+
+	line := buffer.String()
+	test.AssertEqual(t, false, strings.Contains(line, "MY_API_KEY"))
+	test.AssertEqual(t, false, strings.Contains(line, request.Header.Get(headerAccessSignature)))
+	test.AssertEqual(t, true, strings.Contains(line, "market=ETH-EUR"))
+	test.AssertEqual(t, true, strings.Contains(line, "headers.Bitvavo-Access-Key="+redacted))
+}
+
+func TestLogLevels(t *testing.T) {
+	var buffer bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	logTo(context.Background(), logger, nil, LevelTrace, "http response body", slog.String("body", "balances"))
+	test.AssertEqual(t, "", buffer.String())
+
+	logTo(context.Background(), logger, nil, slog.LevelDebug, "http response", slog.Int("status", 200))
+	test.AssertEqual(t, true, strings.Contains(buffer.String(), "status=200"))
+}
+
+type countingValue struct {
+	count *int
+}
+
+func (v countingValue) LogValue() slog.Value {
+	*v.count++
+	return slog.StringValue("body")
+}
+
+func TestLogTraceIsLazy(t *testing.T) {
+	var (
+		buffer   bytes.Buffer
+		resolved = 0
+		logger   = slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	)
+
+	logTo(context.Background(), logger, nil, LevelTrace, "http response body", slog.Any("body", countingValue{&resolved}))
+	test.AssertEqual(t, 0, resolved)
+
+	logTo(context.Background(), logger, nil, slog.LevelDebug, "http response", slog.Any("body", countingValue{&resolved}))
+	test.AssertEqual(t, 1, resolved)
+}
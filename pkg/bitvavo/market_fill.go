@@ -0,0 +1,127 @@
+package bitvavo
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+)
+
+var ErrAmountRequired = errors.New("either amount or amountQuote is required")
+
+const (
+	// marketProtection is the fraction from the best price after which the remainder of a market order is canceled.
+	marketProtection = 0.1
+
+	estimateEpsilon = 1e-12
+)
+
+// MarketFill is the estimated result of a market order.
+type MarketFill struct {
+	// The amount which fills in base currency and its value in quote currency (without the fee).
+	Amount      float64
+	AmountQuote float64
+
+	// The average and the worst price of the fills.
+	AveragePrice float64
+	WorstPrice   float64
+
+	// The mid price of the best bid and best ask, 0 if a side of the book is empty.
+	MidPrice float64
+
+	// How much worse the average price is than the mid price, as a fraction (e.g: 0.001 is 0.1%).
+	Slippage float64
+
+	// The fee in quote currency.
+	Fee float64
+
+	// True when the market protection cancels the remainder of the order, because it would fill at a price
+	// more than 10% worse than the best price (see: OrderNew.DisableMarketProtection).
+	MarketProtection bool
+
+	// True when the order does not fill completely, because of the MarketProtection or because the book is not deep enough.
+	Partial bool
+}
+
+// EstimateMarketFill walks the book (e.g: GetOrderBook or LocalBook.Book) to estimate the fill of a market order for
+// the amount or amountQuote of order. The fee is based on the taker fee (see: Account.Fees).
+//
+// Like the exchange, the fee of a buy for amountQuote is part of amountQuote.
+func EstimateMarketFill(book Book, side Side, order OrderNew, fees Fee) (MarketFill, error) {
+	var (
+		amount      = util.ParseFloat(order.Amount)
+		amountQuote = util.ParseFloat(order.AmountQuote)
+		rate        = util.ParseFloat(fees.Taker)
+		buy         = side == SideBuy
+		levels      = sortedLevels(util.IfOrElse(buy, func() []Page { return book.Asks }, book.Bids), buy)
+		estimate    MarketFill
+	)
+	if amount <= 0 && amountQuote <= 0 {
+		return estimate, ErrAmountRequired
+	}
+
+	bids, asks := sortedLevels(book.Bids, false), sortedLevels(book.Asks, true)
+	if len(bids) > 0 && len(asks) > 0 {
+		estimate.MidPrice = (util.ParseFloat(bids[0].Price) + util.ParseFloat(asks[0].Price)) / 2
+	}
+
+	for _, level := range levels {
+		var (
+			price = util.ParseFloat(level.Price)
+			size  = util.ParseFloat(level.Size)
+			fill  float64
+		)
+		if amount > 0 {
+			fill = min(size, amount-estimate.Amount)
+		} else {
+			left := amountQuote - estimate.AmountQuote - util.IfOrElse(buy, func() float64 { return estimate.Fee }, 0)
+			fill = min(size, util.IfOrElse(buy, func() float64 { return left / (price * (1 + rate)) }, left/price))
+		}
+		if fill <= estimateEpsilon {
+			break
+		}
+		if best := util.ParseFloat(levels[0].Price); !order.DisableMarketProtection &&
+			((buy && price > best*(1+marketProtection)) || (!buy && price < best*(1-marketProtection))) {
+			estimate.MarketProtection = true
+			break
+		}
+
+		estimate.Amount += fill
+		estimate.AmountQuote += fill * price
+		estimate.Fee += fill * price * rate
+		estimate.WorstPrice = price
+	}
+
+	if estimate.Amount > 0 {
+		estimate.AveragePrice = estimate.AmountQuote / estimate.Amount
+	}
+	if estimate.MidPrice > 0 && estimate.AveragePrice > 0 {
+		estimate.Slippage = util.IfOrElse(buy, func() float64 { return estimate.AveragePrice/estimate.MidPrice - 1 }, 1-estimate.AveragePrice/estimate.MidPrice)
+	}
+
+	if amount > 0 {
+		estimate.Partial = estimate.Amount < amount-estimateEpsilon
+	} else {
+		spent := estimate.AmountQuote + util.IfOrElse(buy, func() float64 { return estimate.Fee }, 0)
+		estimate.Partial = spent < amountQuote-estimateEpsilon
+	}
+
+	return estimate, nil
+}
+
+// sortedLevels returns a copy of pages sorted by price, from low to high if ascending.
+func sortedLevels(pages []Page, ascending bool) []Page {
+	levels := make([]Page, 0, len(pages))
+	for _, page := range pages {
+		if util.ParseFloat(page.Size) > 0 {
+			levels = append(levels, page)
+		}
+	}
+	sort.SliceStable(levels, func(i, j int) bool {
+		if ascending {
+			return util.ParseFloat(levels[i].Price) < util.ParseFloat(levels[j].Price)
+		}
+		return util.ParseFloat(levels[i].Price) > util.ParseFloat(levels[j].Price)
+	})
+	return levels
+}
@@ -0,0 +1,58 @@
+package bitvavo
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+)
+
+func TestEstimateMarketFill(t *testing.T) {
+	book := Book{
+		Market: "ETH-EUR",
+		Asks:   []Page{{Price: "105", Size: "1"}, {Price: "100", Size: "1"}, {Price: "120", Size: "5"}},
+		Bids:   []Page{{Price: "99", Size: "2"}, {Price: "80", Size: "10"}},
+	}
+	fees := Fee{Taker: "0.0025"}
+
+	estimate, err := EstimateMarketFill(book, SideBuy, OrderNew{Amount: "1.5"}, fees)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, 1.5, estimate.Amount)
+	test.AssertEqual(t, 152.5, estimate.AmountQuote)
+	test.AssertEqual(t, 152.5/1.5, estimate.AveragePrice)
+	test.AssertEqual(t, 105.0, estimate.WorstPrice)
+	test.AssertEqual(t, 99.5, estimate.MidPrice)
+	test.AssertEqual(t, "0.021776", fmt.Sprintf("%.6f", estimate.Slippage))
+	test.AssertEqual(t, 152.5*0.0025, estimate.Fee)
+	test.AssertEqual(t, false, estimate.Partial)
+
+	// the market protection cancels the remainder at 120
+	estimate, _ = EstimateMarketFill(book, SideBuy, OrderNew{Amount: "3"}, fees)
+	test.AssertEqual(t, 2.0, estimate.Amount)
+	test.AssertEqual(t, true, estimate.MarketProtection)
+	test.AssertEqual(t, true, estimate.Partial)
+
+	estimate, _ = EstimateMarketFill(book, SideBuy, OrderNew{Amount: "3", DisableMarketProtection: true}, fees)
+	test.AssertEqual(t, 3.0, estimate.Amount)
+	test.AssertEqual(t, 120.0, estimate.WorstPrice)
+	test.AssertEqual(t, false, estimate.MarketProtection)
+
+	// the fee of a buy is part of amountQuote
+	estimate, _ = EstimateMarketFill(book, SideBuy, OrderNew{AmountQuote: "100.25"}, fees)
+	test.AssertEqual(t, 1.0, estimate.Amount)
+	test.AssertEqual(t, 0.25, estimate.Fee)
+	test.AssertEqual(t, false, estimate.Partial)
+
+	estimate, _ = EstimateMarketFill(book, SideSell, OrderNew{AmountQuote: "99"}, Fee{})
+	test.AssertEqual(t, 1.0, estimate.Amount)
+	test.AssertEqual(t, "0.005025", fmt.Sprintf("%.6f", estimate.Slippage))
+
+	estimate, _ = EstimateMarketFill(book, SideSell, OrderNew{Amount: "20", DisableMarketProtection: true}, fees)
+	test.AssertEqual(t, 12.0, estimate.Amount)
+	test.AssertEqual(t, true, estimate.Partial)
+
+	_, err = EstimateMarketFill(book, SideSell, OrderNew{}, fees)
+	test.AssertEqual(t, ErrAmountRequired, err)
+}
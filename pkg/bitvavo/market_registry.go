@@ -0,0 +1,388 @@
+package bitvavo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+)
+
+var (
+	ErrUnknownMarket        = errors.New("unknown market")
+	ErrAmountBelowMinimum   = errors.New("amount is below the minimum order size of the market")
+	ErrAmountAboveMaximum   = errors.New("amount is above the maximum order size of the market")
+	ErrNotionalBelowMinimum = errors.New("amount * price is below the minimum order size in quote currency of the market")
+	ErrNotionalAboveMaximum = errors.New("amount * price is above the maximum order size in quote currency of the market")
+)
+
+// MarketRules are the precision and order size rules of a market.
+type MarketRules struct {
+	Market Market
+
+	// Decimals of the base asset, amounts are rounded to this number of decimals.
+	BaseDecimals int64
+
+	// Decimals of the quote asset, amountQuote is rounded to this number of decimals.
+	QuoteDecimals int64
+}
+
+// RoundPrice rounds price to the number of significant digits of PricePrecision.
+func (r MarketRules) RoundPrice(price float64) string {
+	if price == 0 || r.Market.PricePrecision <= 0 {
+		return util.FormatFloat(price)
+	}
+
+	decimals := r.Market.PricePrecision - 1 - int64(math.Floor(math.Log10(math.Abs(price))))
+	return formatDecimals(roundDecimals(price, decimals), decimals)
+}
+
+// FloorAmount rounds amount down to the decimals of the base asset, so it never exceeds the available amount.
+func (r MarketRules) FloorAmount(amount float64) string {
+	return floorDecimals(amount, r.BaseDecimals)
+}
+
+// FloorAmountQuote rounds amountQuote down to the decimals of the quote asset.
+func (r MarketRules) FloorAmountQuote(amountQuote float64) string {
+	return floorDecimals(amountQuote, r.QuoteDecimals)
+}
+
+// Validate checks amount (base currency) and the notional (amount * price) against the minimum and maximum order size.
+// The notional is not checked if price is 0.
+func (r MarketRules) Validate(amount float64, price float64) error {
+	if minimum := util.ParseFloat(r.Market.MinOrderInBaseAsset); minimum > 0 && amount < minimum {
+		return ErrAmountBelowMinimum
+	}
+	if maximum := util.ParseFloat(r.Market.MaxOrderInBaseAsset); maximum > 0 && amount > maximum {
+		return ErrAmountAboveMaximum
+	}
+	if price <= 0 {
+		return nil
+	}
+	notional := amount * price
+	if minimum := util.ParseFloat(r.Market.MinOrderInQuoteAsset); minimum > 0 && notional < minimum {
+		return ErrNotionalBelowMinimum
+	}
+	if maximum := util.ParseFloat(r.Market.MaxOrderInQuoteAsset); maximum > 0 && notional > maximum {
+		return ErrNotionalAboveMaximum
+	}
+	return nil
+}
+
+// MarketChange is emitted by the MarketRegistry when a market appeared, changed status or disappeared on refresh.
+type MarketChange struct {
+	Market Market
+
+	// The status before the refresh, empty if Added is true.
+	Previous MarketStatus
+
+	// True if the market is new.
+	Added bool
+
+	// True if the market is no longer listed, Market is the market before the refresh.
+	Removed bool
+}
+
+// marketSubscriber queues the changes of a subscriber, so a refresh never blocks on a subscriber which doesn't read.
+type marketSubscriber struct {
+	chn    chan MarketChange
+	notify chan struct{}
+	done   chan struct{}
+
+	mu      sync.Mutex
+	pending []MarketChange
+}
+
+func newMarketSubscriber() *marketSubscriber {
+	s := &marketSubscriber{
+		chn:    make(chan MarketChange),
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.chn)
+		for {
+			s.mu.Lock()
+			pending := s.pending
+			s.pending = nil
+			s.mu.Unlock()
+
+			for _, change := range pending {
+				select {
+				case s.chn <- change:
+				case <-s.done:
+					return
+				}
+			}
+
+			select {
+			case <-s.notify:
+			case <-s.done:
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// send queues changes, it doesn't block.
+func (s *marketSubscriber) send(changes []MarketChange) {
+	s.mu.Lock()
+	s.pending = append(s.pending, changes...)
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// MarketRegistry holds all markets and assets (see: GetMarkets and GetAssets) indexed by pair, base and quote.
+// It is safe for concurrent use.
+type MarketRegistry struct {
+	client PublicAPI
+
+	mu          sync.RWMutex
+	markets     map[string]Market
+	assets      map[string]Asset
+	byBase      map[string][]string
+	byQuote     map[string][]string
+	subscribers map[*marketSubscriber]struct{}
+}
+
+// NewMarketRegistry creates a registry and loads all markets and assets with client.
+// Pass a client with a cache (see: WithCache) to share the reference data with the rest of the application.
+func NewMarketRegistry(ctx context.Context, client PublicAPI) (*MarketRegistry, error) {
+	r := &MarketRegistry{
+		client:      client,
+		subscribers: make(map[*marketSubscriber]struct{}),
+	}
+	if err := r.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Refresh reloads all markets and assets and notifies the subscribers of new, changed and delisted markets.
+// The changes are queued for every subscriber, so it doesn't wait for the subscribers to receive them.
+func (r *MarketRegistry) Refresh(ctx context.Context) error {
+	markets, err := r.client.GetMarkets(ctx)
+	if err != nil {
+		return err
+	}
+	assets, err := r.client.GetAssets(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		marketsBySymbol = make(map[string]Market, len(markets))
+		assetsBySymbol  = make(map[string]Asset, len(assets))
+		byBase          = make(map[string][]string)
+		byQuote         = make(map[string][]string)
+	)
+	for _, asset := range assets {
+		assetsBySymbol[asset.Symbol] = asset
+	}
+	for _, market := range markets {
+		marketsBySymbol[market.Market] = market
+		byBase[market.Base] = append(byBase[market.Base], market.Market)
+		byQuote[market.Quote] = append(byQuote[market.Quote], market.Market)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changes := make([]MarketChange, 0)
+	if r.markets != nil {
+		for _, market := range markets {
+			previous, ok := r.markets[market.Market]
+			if !ok {
+				changes = append(changes, MarketChange{Market: market, Added: true})
+			} else if previous.Status != market.Status {
+				changes = append(changes, MarketChange{Market: market, Previous: previous.Status})
+			}
+		}
+		removed := make([]MarketChange, 0)
+		for symbol, market := range r.markets {
+			if _, ok := marketsBySymbol[symbol]; !ok {
+				removed = append(removed, MarketChange{Market: market, Previous: market.Status, Removed: true})
+			}
+		}
+		sort.Slice(removed, func(i, j int) bool { return removed[i].Market.Market < removed[j].Market.Market })
+		changes = append(changes, removed...)
+	}
+	r.markets, r.assets, r.byBase, r.byQuote = marketsBySymbol, assetsBySymbol, byBase, byQuote
+
+	if len(changes) > 0 {
+		for subscriber := range r.subscribers {
+			subscriber.send(changes)
+		}
+	}
+
+	return nil
+}
+
+// Run refreshes the registry every interval until ctx is done, refresh errors are ignored and retried on the next interval.
+func (r *MarketRegistry) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = r.Refresh(ctx)
+		}
+	}
+}
+
+// Subscribe returns a channel which receives new, changed and delisted markets on every refresh.
+// Changes are queued until they are received, call unsubscribe when done: it drops the queued changes and closes the channel.
+func (r *MarketRegistry) Subscribe() (<-chan MarketChange, func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subscriber := newMarketSubscriber()
+	r.subscribers[subscriber] = struct{}{}
+
+	var once sync.Once
+	return subscriber.chn, func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			delete(r.subscribers, subscriber)
+			close(subscriber.done)
+		})
+	}
+}
+
+// Market returns the market by pair (e.g: ETH-EUR)
+func (r *MarketRegistry) Market(market string) (Market, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.markets[market]
+	return m, ok
+}
+
+// Pair returns the market of base and quote (e.g: ETH, EUR)
+func (r *MarketRegistry) Pair(base string, quote string) (Market, bool) {
+	return r.Market(base + "-" + quote)
+}
+
+// ByBase returns all markets with base as base currency (e.g: ETH), sorted by market.
+func (r *MarketRegistry) ByBase(base string) []Market {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.lookup(r.byBase[base])
+}
+
+// ByQuote returns all markets with quote as quote currency (e.g: EUR), sorted by market.
+func (r *MarketRegistry) ByQuote(quote string) []Market {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.lookup(r.byQuote[quote])
+}
+
+// Markets returns all markets sorted by market.
+func (r *MarketRegistry) Markets() []Market {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	markets := make([]Market, 0, len(r.markets))
+	for _, market := range r.markets {
+		markets = append(markets, market)
+	}
+	sort.Slice(markets, func(i, j int) bool { return markets[i].Market < markets[j].Market })
+	return markets
+}
+
+// Trading returns all markets with status trading, sorted by market.
+func (r *MarketRegistry) Trading() []Market {
+	markets := r.Markets()
+	trading := make([]Market, 0, len(markets))
+	for _, market := range markets {
+		if market.Status == MarketStatusTrading {
+			trading = append(trading, market)
+		}
+	}
+	return trading
+}
+
+// Asset returns the asset by symbol (e.g: ETH)
+func (r *MarketRegistry) Asset(symbol string) (Asset, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a, ok := r.assets[symbol]
+	return a, ok
+}
+
+// Rules returns the precision and order size rules of market (e.g: ETH-EUR)
+func (r *MarketRegistry) Rules(market string) (MarketRules, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.markets[market]
+	if !ok {
+		return MarketRules{}, ErrUnknownMarket
+	}
+
+	rules := MarketRules{Market: m, BaseDecimals: 8, QuoteDecimals: 8}
+	if base, ok := r.assets[m.Base]; ok {
+		rules.BaseDecimals = base.Decimals
+	}
+	if quote, ok := r.assets[m.Quote]; ok {
+		rules.QuoteDecimals = quote.Decimals
+	}
+	return rules, nil
+}
+
+func (r *MarketRegistry) lookup(symbols []string) []Market {
+	markets := make([]Market, len(symbols))
+	for i, symbol := range symbols {
+		markets[i] = r.markets[symbol]
+	}
+	sort.Slice(markets, func(i, j int) bool { return markets[i].Market < markets[j].Market })
+	return markets
+}
+
+// roundDecimals rounds value to the nearest number with decimals.
+func roundDecimals(value float64, decimals int64) float64 {
+	factor := math.Pow10(int(decimals))
+	// compensate for representation errors (e.g: 1.005 * 100 = 100.49999999999999)
+	return math.Round(value*factor*(1+1e-12)) / factor
+}
+
+// floorDecimals rounds value down to decimals. It truncates the decimal representation of value without floating
+// point noise (see: util.FormatFloat), multiplying would suffer from representation errors (e.g: 0.29 * 100 = 28.999999999999996).
+func floorDecimals(value float64, decimals int64) string {
+	if value < 0 || decimals < 0 {
+		factor := math.Pow10(int(decimals))
+		return formatDecimals(math.Floor(value*factor)/factor, decimals)
+	}
+
+	integer, fraction, _ := strings.Cut(util.FormatFloat(value), ".")
+	if decimals == 0 {
+		return integer
+	}
+	if int64(len(fraction)) > decimals {
+		fraction = fraction[:decimals]
+	}
+	return integer + "." + fraction + strings.Repeat("0", int(decimals)-len(fraction))
+}
+
+func formatDecimals(value float64, decimals int64) string {
+	return strconv.FormatFloat(value, 'f', int(max(decimals, 0)), 64)
+}
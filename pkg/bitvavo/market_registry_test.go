@@ -0,0 +1,105 @@
+package bitvavo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+)
+
+type referenceAPI struct {
+	PublicAPI
+	markets []Market
+	assets  []Asset
+}
+
+func (a *referenceAPI) GetMarkets(context.Context) ([]Market, error) {
+	return a.markets, nil
+}
+
+func (a *referenceAPI) GetAssets(context.Context) ([]Asset, error) {
+	return a.assets, nil
+}
+
+func TestMarketRegistry(t *testing.T) {
+	api := &referenceAPI{
+		markets: []Market{
+			{Market: "ETH-EUR", Base: "ETH", Quote: "EUR", Status: MarketStatusTrading, PricePrecision: 5},
+			{Market: "BTC-EUR", Base: "BTC", Quote: "EUR", Status: MarketStatusTrading, PricePrecision: 5},
+			{Market: "ETH-BTC", Base: "ETH", Quote: "BTC", Status: MarketStatusHalted, PricePrecision: 5},
+		},
+		assets: []Asset{{Symbol: "ETH", Decimals: 8}, {Symbol: "EUR", Decimals: 2}},
+	}
+
+	registry, err := NewMarketRegistry(context.Background(), api)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	market, ok := registry.Pair("ETH", "BTC")
+	test.AssertEqual(t, true, ok)
+	test.AssertEqual(t, MarketStatusHalted, market.Status)
+	test.AssertEqual(t, 2, len(registry.ByBase("ETH")))
+	test.AssertEqual(t, "BTC-EUR", registry.ByQuote("EUR")[0].Market)
+	test.AssertEqual(t, 2, len(registry.Trading()))
+
+	changes, unsubscribe := registry.Subscribe()
+	// a subscriber which doesn't read doesn't block a refresh
+	_, unsubscribeIdle := registry.Subscribe()
+	defer unsubscribeIdle()
+
+	api.markets = []Market{
+		{Market: "ETH-EUR", Base: "ETH", Quote: "EUR", Status: MarketStatusTrading},
+		{Market: "ETH-BTC", Base: "ETH", Quote: "BTC", Status: MarketStatusTrading},
+		{Market: "SOL-EUR", Base: "SOL", Quote: "EUR", Status: MarketStatusAuction},
+	}
+	for i := 0; i < 2; i++ {
+		if err := registry.Refresh(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	change := <-changes
+	test.AssertEqual(t, "ETH-BTC", change.Market.Market)
+	test.AssertEqual(t, MarketStatusHalted, change.Previous)
+	change = <-changes
+	test.AssertEqual(t, "SOL-EUR", change.Market.Market)
+	test.AssertEqual(t, true, change.Added)
+	change = <-changes
+	test.AssertEqual(t, "BTC-EUR", change.Market.Market)
+	test.AssertEqual(t, true, change.Removed)
+	test.AssertEqual(t, 0, len(registry.ByBase("BTC")))
+
+	unsubscribe()
+	_, open := <-changes
+	test.AssertEqual(t, false, open)
+}
+
+func TestMarketRules(t *testing.T) {
+	rules := MarketRules{
+		Market: Market{
+			PricePrecision:       5,
+			MinOrderInBaseAsset:  "0.001",
+			MinOrderInQuoteAsset: "5",
+			MaxOrderInQuoteAsset: "1000000",
+		},
+		BaseDecimals:  8,
+		QuoteDecimals: 2,
+	}
+
+	test.AssertEqual(t, "2512.3", rules.RoundPrice(2512.3456))
+	test.AssertEqual(t, "123460", rules.RoundPrice(123456.7))
+	test.AssertEqual(t, "0.00012346", rules.RoundPrice(0.0001234567))
+	test.AssertEqual(t, "0.29000000", rules.FloorAmount(0.29))
+	test.AssertEqual(t, "0.12345678", rules.FloorAmount(0.123456789))
+	test.AssertEqual(t, "0.12345678", rules.FloorAmount(0.123456789999999))
+	test.AssertEqual(t, "0.40000000", rules.FloorAmount(1-0.6))
+	test.AssertEqual(t, "12.00000000", rules.FloorAmount(12))
+	test.AssertEqual(t, "0.00000001", rules.FloorAmount(1e-8))
+	test.AssertEqual(t, "10.99", rules.FloorAmountQuote(10.999))
+
+	test.AssertEqual(t, ErrAmountBelowMinimum, rules.Validate(0.0001, 2500))
+	test.AssertEqual(t, ErrNotionalBelowMinimum, rules.Validate(0.001, 2500))
+	test.AssertEqual(t, nil, rules.Validate(0.01, 2500))
+	test.AssertEqual(t, nil, rules.Validate(0.01, 0))
+}
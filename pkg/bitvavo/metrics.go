@@ -0,0 +1,96 @@
+package bitvavo
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Metrics receives measurements of the http client and websockets (see: WithMetrics and WithWebSocketMetrics).
+// Implement it to bind the measurements to Prometheus, OpenTelemetry or any other metrics library.
+//
+// All methods must be safe for concurrent use and should return quickly, they are called inline.
+type Metrics interface {
+	// ObserveRequest is called after every http request with the endpoint (e.g: "GET /{market}/book"),
+	// the status code (0 if no response was received) and the latency.
+	ObserveRequest(endpoint string, status int, latency time.Duration)
+
+	// SetRateLimitRemaining is called with the remaining rate limit weight of every http response.
+	SetRateLimitRemaining(remaining int64)
+
+	// IncRetry is called before an operation is retried (e.g: "websocket" when reconnecting failed and is tried again)
+	IncRetry(operation string)
+
+	// IncReconnect is called when the websocket has reconnected.
+	IncReconnect()
+
+	// IncMessage is called for every event received on a websocket with the channel (e.g: ticker) and market (e.g: ETH-EUR)
+	// The market is empty for events without a market.
+	IncMessage(channel string, market string)
+
+	// IncDecodeError is called when a websocket message could not be decoded, the channel is empty if it's unknown.
+	IncDecodeError(channel string)
+
+	// ObserveBackpressure is called when the websocket could not buffer a received message because the listener
+	// is not consuming fast enough, with the time the websocket was blocked.
+	ObserveBackpressure(wait time.Duration)
+
+	// AddDropped is called with the number of received messages that were never delivered to the listener because
+	// the websocket was closed.
+	AddDropped(count int)
+}
+
+// WithMetrics reports the requests and the rate limit of the http client to metrics.
+func WithMetrics(metrics Metrics) HttpOption {
+	return func(c *httpClient) {
+		c.httpConfig.metrics = metrics
+	}
+}
+
+// WithWebSocketMetrics reports messages, decode errors, reconnects and backpressure of the websocket to metrics.
+func WithWebSocketMetrics(metrics Metrics) WebSocketOption {
+	return func(ws *WebSocket) {
+		ws.metrics = metrics
+	}
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveRequest(string, int, time.Duration) {}
+func (noopMetrics) SetRateLimitRemaining(int64)               {}
+func (noopMetrics) IncRetry(string)                           {}
+func (noopMetrics) IncReconnect()                             {}
+func (noopMetrics) IncMessage(string, string)                 {}
+func (noopMetrics) IncDecodeError(string)                     {}
+func (noopMetrics) ObserveBackpressure(time.Duration)         {}
+func (noopMetrics) AddDropped(int)                            {}
+
+// endpointOf returns the method and path of request with the market replaced by {market} to keep the number of endpoints low.
+func endpointOf(request *http.Request) string {
+	path := strings.TrimPrefix(request.URL.Path, "/v2")
+	if segments := strings.Split(path, "/"); len(segments) == 3 && strings.Contains(segments[1], "-") {
+		segments[1] = "{market}"
+		path = strings.Join(segments, "/")
+	}
+	return request.Method + " " + path
+}
+
+// channelOf returns the channel on which event is received, false if the event is not a market data or account event.
+func channelOf(event WebSocketEvent) (Channel, bool) {
+	switch event {
+	case EventCandle:
+		return ChannelCandles, true
+	case EventTicker:
+		return ChannelTicker, true
+	case EventTicker24h:
+		return ChannelTicker24h, true
+	case EventTrade:
+		return ChannelTrades, true
+	case EventBook:
+		return ChannelBook, true
+	case EventOrder, EventFill:
+		return ChannelAccount, true
+	default:
+		return Channel{}, false
+	}
+}
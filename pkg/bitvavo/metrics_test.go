@@ -0,0 +1,60 @@
+package bitvavo
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+)
+
+type countingMetrics struct {
+	noopMetrics
+	mu           sync.Mutex
+	messages     map[string]int
+	decodeErrors map[string]int
+}
+
+func (m *countingMetrics) IncMessage(channel string, market string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages[channel+" "+market]++
+}
+
+func (m *countingMetrics) IncDecodeError(channel string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.decodeErrors[channel]++
+}
+
+func TestEndpointOf(t *testing.T) {
+	for url, expected := range map[string]string{
+		apiURL + "/markets?market=ETH-EUR": "GET /markets",
+		apiURL + "/ETH-EUR/book?depth=1":   "GET /{market}/book",
+		apiURL + "/ticker/price":           "GET /ticker/price",
+	} {
+		request, _ := http.NewRequest("GET", url, nil)
+		test.AssertEqual(t, expected, endpointOf(request))
+	}
+}
+
+func TestMessageHandlerMetrics(t *testing.T) {
+	metrics := &countingMetrics{messages: make(map[string]int), decodeErrors: make(map[string]int)}
+
+	handler := newMessageHandler(func(data WebSocketEventData, err error) {
+		if err == nil && data.Event == EventTicker {
+			var ticker Ticker
+			_ = data.Decode(&ticker)
+		}
+	}, &WebSocket{metrics: metrics, logger: discardLogger})
+
+	handler([]byte(`{"event":"subscribed","subscriptions":{"ticker":["ETH-EUR"]}}`))
+	handler([]byte(`{"event":"ticker","market":"ETH-EUR","bestBid":"2500"}`))
+	handler([]byte(`{"event":"ticker","market":"ETH-EUR","bestBid":2500}`))
+	handler([]byte(`not json`))
+
+	test.AssertEqual(t, 2, metrics.messages["ticker ETH-EUR"])
+	test.AssertEqual(t, 1, len(metrics.messages))
+	test.AssertEqual(t, 1, metrics.decodeErrors["ticker"])
+	test.AssertEqual(t, 1, metrics.decodeErrors[""])
+}
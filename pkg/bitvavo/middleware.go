@@ -0,0 +1,98 @@
+package bitvavo
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var ErrMiddlewareResult = func(endpoint string, result any, expected any) error {
+	return fmt.Errorf("middleware returned %T for %s, expected: %T", result, endpoint, expected)
+}
+
+// Call is a single logical API call passing through the middleware (see: WithMiddleware).
+type Call struct {
+	// The name of the API method (e.g: GetMarkets, NewOrder)
+	Endpoint string
+
+	// The http method (e.g: GET)
+	Method string
+
+	// The path of the endpoint without the API url (e.g: /ETH-EUR/book)
+	Path string
+
+	// The query params, middleware can change them before calling next.
+	Params url.Values
+
+	// The request body for POST and PUT (e.g: OrderNew), nil otherwise.
+	Body any
+
+	// Extra headers to send with the request (e.g: for tagging requests), they are not part of the signature.
+	Header http.Header
+
+	// True if the call requires authentication.
+	Private bool
+
+	// The number of times the call has been sent to the exchange, it's incremented right before sending.
+	// So it's 0 before the first attempt and after next returns it's the attempt which produced the result.
+	// A middleware can retry a call by calling next again.
+	Attempt int
+}
+
+// Market returns the market the call is for (e.g: ETH-EUR) or an empty string if it isn't for a single market.
+func (c *Call) Market() string {
+	if market := c.Params.Get("market"); market != "" {
+		return market
+	}
+	if segments := strings.Split(c.Path, "/"); len(segments) == 3 && strings.Contains(segments[1], "-") {
+		return segments[1]
+	}
+	switch body := c.Body.(type) {
+	case OrderNew:
+		return body.Market
+	case OrderUpdate:
+		return body.Market
+	}
+	return ""
+}
+
+// Invoker performs a call and returns the decoded result (e.g: []Market for GetMarkets) or an error.
+type Invoker func(ctx context.Context, call *Call) (any, error)
+
+// Middleware wraps an Invoker, it can inspect or change the call before calling next and the result and error after.
+// Returning without calling next short-circuits the call (e.g: to return a cached result), the result must then be
+// of the same type as the endpoint returns.
+type Middleware func(next Invoker) Invoker
+
+// WithMiddleware wraps every API call in middleware, the first middleware is the outermost.
+// The request is signed after all middleware ran, right before it is sent.
+func WithMiddleware(middleware ...Middleware) HttpOption {
+	return func(c *httpClient) {
+		c.httpConfig.middleware = append(c.httpConfig.middleware, middleware...)
+	}
+}
+
+func newCall(endpoint string, method string, url string, params url.Values, body any, authConfig *authConfig) *Call {
+	if params == nil {
+		params = make(map[string][]string)
+	}
+	return &Call{
+		Endpoint: endpoint,
+		Method:   method,
+		Path:     strings.TrimPrefix(url, apiURL),
+		Params:   maps.Clone(params),
+		Body:     body,
+		Header:   make(http.Header),
+		Private:  authConfig != nil,
+	}
+}
+
+func chain(middleware []Middleware, invoker Invoker) Invoker {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		invoker = middleware[i](invoker)
+	}
+	return invoker
+}
@@ -0,0 +1,88 @@
+package bitvavo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+func respond(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func TestMiddlewareOrderAndRetry(t *testing.T) {
+	var (
+		requests = 0
+		trace    = make([]string, 0)
+	)
+	transport := roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		requests++
+		test.AssertEqual(t, "audit", request.Header.Get("X-Tag"))
+		test.AssertEqual(t, true, request.Header.Get(headerAccessSignature) != "")
+		if requests == 1 {
+			return nil, errors.New("connection reset")
+		}
+		return respond(http.StatusOK, `{"orderId":"1"}`), nil
+	})
+
+	retry := func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (any, error) {
+			for {
+				result, err := next(ctx, call)
+				if err == nil || call.Attempt == 3 {
+					return result, err
+				}
+			}
+		}
+	}
+	audit := func(next Invoker) Invoker {
+		return func(ctx context.Context, call *Call) (any, error) {
+			call.Header.Set("X-Tag", "audit")
+			result, err := next(ctx, call)
+			trace = append(trace, call.Endpoint+" "+call.Market()+" "+call.Path)
+			return result, err
+		}
+	}
+
+	client := NewPrivateHTTPClient("key", "secret", WithHttpClient(&http.Client{Transport: transport}), WithMiddleware(retry, audit))
+	orderId, err := client.CancelOrder(context.Background(), "ETH-EUR", "1")
+
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, "1", orderId)
+	test.AssertEqual(t, 2, requests)
+	test.AssertEqual(t, "CancelOrder ETH-EUR /order,CancelOrder ETH-EUR /order", strings.Join(trace, ","))
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	transport := roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		t.Fatal("request should not be sent")
+		return nil, nil
+	})
+
+	cached := func(result any) Middleware {
+		return func(next Invoker) Invoker {
+			return func(ctx context.Context, call *Call) (any, error) {
+				return result, nil
+			}
+		}
+	}
+
+	client := NewPublicHTTPClient(WithHttpClient(&http.Client{Transport: transport}), WithMiddleware(cached([]Market{{Market: "ETH-EUR"}})))
+	markets, err := client.GetMarkets(context.Background())
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, "ETH-EUR", markets[0].Market)
+
+	_, err = client.GetAssets(context.Background())
+	test.AssertEqual(t, ErrMiddlewareResult("GetAssets", []Market{}, []Asset{}).Error(), err.Error())
+}
@@ -83,6 +83,11 @@ var (
 	orderTypes               = orderType.Enum()
 )
 
+// ParseOrderType parses an order type (e.g: market, stopLossLimit), it returns nil for any other value.
+func ParseOrderType(value string) *OrderType {
+	return orderTypes.Parse(value)
+}
+
 type OrderTriggerType enum.Member[string]
 
 var (
@@ -0,0 +1,922 @@
+package bitvavo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+)
+
+const paperEpsilon = 1e-12
+
+var ErrPaperNotSupported = errors.New("not supported by the paper client")
+
+// the same errors as returned by the exchange
+var (
+	errPaperInsufficientBalance = &ApiError{Code: 216, Message: "You do not have sufficient balance to complete this operation."}
+	errPaperOrderNotFound       = &ApiError{Code: 240, Message: "No order found. Please be aware that simultaneously updating the same order may return this error."}
+	errPaperParameterRequired   = func(param string) error {
+		return &ApiError{Code: 203, Message: fmt.Sprintf("%s parameter is required.", param)}
+	}
+)
+
+type PaperOption func(*PaperClient)
+
+// WithPaperBalance sets the starting balance for symbol (e.g: EUR)
+func WithPaperBalance(symbol string, amount string) PaperOption {
+	return func(c *PaperClient) {
+		c.balances[symbol] = &paperBalance{available: util.ParseFloat(amount)}
+	}
+}
+
+// WithPaperFees sets the fees that are used for the simulated fills.
+// Use the fees of your account (see: GetAccount) to get realistic results.
+func WithPaperFees(fees Fee) PaperOption {
+	return func(c *PaperClient) {
+		c.fees = fees
+	}
+}
+
+type paperBalance struct {
+	available float64
+	inOrder   float64
+}
+
+type paperOrder struct {
+	order       Order
+	base        string
+	quote       string
+	remaining   float64
+	amountQuote float64
+	price       float64
+	trigger     float64
+	filled      float64
+	filledQuote float64
+	fee         float64
+	hold        float64
+	holdSymbol  string
+}
+
+func (o *paperOrder) isBuy() bool {
+	return o.order.Side == SideBuy
+}
+
+func (o *paperOrder) isOpen() bool {
+	switch o.order.Status {
+	case OrderStatusNew, OrderStatusPartiallyFilled, OrderStatusAwaitingTrigger:
+		return true
+	}
+	return false
+}
+
+func (o *paperOrder) isMarket() bool {
+	return isMarketOrderType(o.order.OrderType)
+}
+
+// triggersBelow reports whether a stop order triggers when the reference price drops to the trigger price.
+func (o *paperOrder) triggersBelow() bool {
+	isStopLoss := o.order.OrderType == OrderTypeStopLoss || o.order.OrderType == OrderTypeStopLossLimit
+	return isStopLoss != o.isBuy()
+}
+
+// done reports whether nothing is left to fill.
+func (o *paperOrder) done() bool {
+	if o.amountQuote > 0 {
+		return o.amountQuote-o.filledQuote-o.fee <= paperEpsilon
+	}
+	return o.remaining <= paperEpsilon
+}
+
+// PaperClient is a PrivateAPI which simulates the account in memory, while market data comes from the exchange.
+//
+// Balances, orders and fills only exist in memory. Orders are matched against the order book of the exchange:
+// market orders and crossing limit orders fill immediately against a snapshot (or the book maintained by UpdateBook),
+// resting limit orders and stop orders are matched on every UpdateBook and UpdateTicker.
+// Paper orders do not remove liquidity from the real book.
+//
+// Order and fill events are emitted on OrderListener and FillListener, so a strategy can be switched between
+// paper and live trading by swapping the client and listeners.
+type PaperClient struct {
+	PublicAPI
+
+	mu          sync.Mutex
+	fees        Fee
+	nextId      uint64
+	balances    map[string]*paperBalance
+	orders      []*paperOrder
+	fills       []Fill
+	withdrawals []WithdrawalHistory
+	books       map[string]*LocalBook
+	tickers     map[string]Ticker
+
+	orderListener *paperListener[OrderEvent]
+	fillListener  *paperListener[FillEvent]
+}
+
+var _ PrivateAPI = (*PaperClient)(nil)
+
+// NewPaperClient creates a paper trading client which uses public for all market data.
+func NewPaperClient(public PublicAPI, options ...PaperOption) *PaperClient {
+	client := &PaperClient{
+		PublicAPI: public,
+		balances:  make(map[string]*paperBalance),
+		books:     make(map[string]*LocalBook),
+		tickers:   make(map[string]Ticker),
+		orderListener: newPaperListener(func(e OrderEvent) string {
+			return e.Value.Market
+		}),
+		fillListener: newPaperListener(func(e FillEvent) string {
+			return e.Value.Market
+		}),
+	}
+
+	for _, opt := range options {
+		opt(client)
+	}
+
+	return client
+}
+
+// OrderListener returns a listener which emits the order events of this client, just like NewOrderListener.
+func (c *PaperClient) OrderListener() Listener[OrderEvent] {
+	return c.orderListener
+}
+
+// FillListener returns a listener which emits the fill events of this client, just like NewFillListener.
+func (c *PaperClient) FillListener() Listener[FillEvent] {
+	return c.fillListener
+}
+
+// UpdateBook applies an update (or snapshot) from the BookListener and matches the open orders of that market.
+// A new snapshot is retrieved when updates have been missed.
+func (c *PaperClient) UpdateBook(ctx context.Context, update Book) error {
+	c.mu.Lock()
+	book, ok := c.books[update.Market]
+	if ok && book.Apply(update) == nil {
+		c.emit(c.match(update.Market))
+		c.mu.Unlock()
+		return nil
+	}
+	c.mu.Unlock()
+
+	snapshot, err := c.GetOrderBook(ctx, update.Market)
+	if err != nil {
+		return err
+	}
+	snapshot.Market = update.Market
+
+	c.mu.Lock()
+	c.books[update.Market] = NewLocalBook(snapshot)
+	c.emit(c.match(update.Market))
+	c.mu.Unlock()
+
+	return nil
+}
+
+// UpdateTicker updates the best bid, best ask and last price of the market from the TickerListener and matches the open orders of that market.
+func (c *PaperClient) UpdateTicker(ticker Ticker) {
+	c.mu.Lock()
+	current := c.tickers[ticker.Market]
+	current.Market = ticker.Market
+	if ticker.BestBid != "" {
+		current.BestBid = ticker.BestBid
+		current.BestBidSize = ticker.BestBidSize
+	}
+	if ticker.BestAsk != "" {
+		current.BestAsk = ticker.BestAsk
+		current.BestAskSize = ticker.BestAskSize
+	}
+	if ticker.LastPrice != "" {
+		current.LastPrice = ticker.LastPrice
+	}
+	c.tickers[ticker.Market] = current
+
+	c.emit(c.match(ticker.Market))
+	c.mu.Unlock()
+}
+
+// Run feeds book and ticker events (e.g: from BookListener and TickerListener) into the client until ctx is done
+// or both channels are closed. Either channel may be nil.
+func (c *PaperClient) Run(ctx context.Context, books <-chan BookEvent, tickers <-chan TickerEvent) error {
+	for books != nil || tickers != nil {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-books:
+			if !ok {
+				books = nil
+			} else if event.Error == nil {
+				if err := c.UpdateBook(ctx, event.Value); err != nil {
+					return err
+				}
+			}
+		case event, ok := <-tickers:
+			if !ok {
+				tickers = nil
+			} else if event.Error == nil {
+				c.UpdateTicker(event.Value)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *PaperClient) GetBalance(_ context.Context, symbol ...string) ([]Balance, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	balances := make([]Balance, 0, len(c.balances))
+	for s, b := range c.balances {
+		if len(symbol) > 0 && symbol[0] != s {
+			continue
+		}
+		balances = append(balances, Balance{
+			Symbol:    s,
+			Available: util.FormatFloat(b.available),
+			InOrder:   util.FormatFloat(b.inOrder),
+		})
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Symbol < balances[j].Symbol })
+
+	return balances, nil
+}
+
+func (c *PaperClient) GetAccount(_ context.Context) (Account, error) {
+	return Account{Fees: c.fees}, nil
+}
+
+func (c *PaperClient) GetTradesHistoric(_ context.Context, market string, opt ...Params) ([]TradeHistoric, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	trades := make([]TradeHistoric, 0)
+	for i := len(c.fills) - 1; i >= 0; i-- {
+		if c.fills[i].Market == market {
+			trades = append(trades, TradeHistoric(c.fills[i]))
+		}
+	}
+
+	return limitOf(trades, opt...), nil
+}
+
+func (c *PaperClient) GetOrders(_ context.Context, market string, opt ...Params) ([]Order, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	orders := make([]Order, 0)
+	for i := len(c.orders) - 1; i >= 0; i-- {
+		if c.orders[i].order.Market == market {
+			orders = append(orders, c.orders[i].order)
+		}
+	}
+
+	return limitOf(orders, opt...), nil
+}
+
+func (c *PaperClient) GetOrdersOpen(_ context.Context, market ...string) ([]Order, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	orders := make([]Order, 0)
+	for _, o := range c.orders {
+		if o.isOpen() && (len(market) == 0 || market[0] == o.order.Market) {
+			orders = append(orders, o.order)
+		}
+	}
+
+	return orders, nil
+}
+
+func (c *PaperClient) GetOrder(_ context.Context, market string, orderId string) (Order, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o, err := c.find(market, orderId)
+	if err != nil {
+		return Order{}, err
+	}
+	return o.order, nil
+}
+
+func (c *PaperClient) CancelOrders(_ context.Context, market ...string) ([]string, error) {
+	c.mu.Lock()
+	orderIds := make([]string, 0)
+	orders := make([]Order, 0)
+	for _, o := range c.orders {
+		if o.isOpen() && (len(market) == 0 || market[0] == o.order.Market) {
+			c.close(o, OrderStatusCanceled)
+			orderIds = append(orderIds, o.order.OrderId)
+			orders = append(orders, o.order)
+		}
+	}
+	c.emit(orders, nil)
+	c.mu.Unlock()
+
+	return orderIds, nil
+}
+
+func (c *PaperClient) CancelOrder(_ context.Context, market string, orderId string) (string, error) {
+	c.mu.Lock()
+	o, err := c.find(market, orderId)
+	if err != nil || !o.isOpen() {
+		c.mu.Unlock()
+		return "", errPaperOrderNotFound
+	}
+	c.close(o, OrderStatusCanceled)
+	c.emit([]Order{o.order}, nil)
+	c.mu.Unlock()
+
+	return orderId, nil
+}
+
+func (c *PaperClient) NewOrder(ctx context.Context, market string, side Side, orderType OrderType, order OrderNew) (Order, error) {
+	base, quote, ok := strings.Cut(market, "-")
+	if !ok {
+		return Order{}, errPaperParameterRequired("market")
+	}
+
+	o := &paperOrder{
+		base:        base,
+		quote:       quote,
+		remaining:   util.ParseFloat(order.Amount),
+		amountQuote: util.ParseFloat(order.AmountQuote),
+		price:       util.ParseFloat(order.Price),
+		trigger:     util.ParseFloat(order.TriggerAmount),
+	}
+
+	isStop := orderType != OrderTypeMarket && orderType != OrderTypeLimit
+	if o.remaining <= 0 && (orderType != OrderTypeMarket || o.amountQuote <= 0) {
+		return Order{}, errPaperParameterRequired("amount")
+	}
+	if !isMarketOrderType(orderType) && o.price <= 0 {
+		return Order{}, errPaperParameterRequired("price")
+	}
+	if isStop && o.trigger <= 0 {
+		return Order{}, errPaperParameterRequired("triggerAmount")
+	}
+
+	if err := c.ensureBook(ctx, market); err != nil {
+		return Order{}, err
+	}
+
+	c.mu.Lock()
+
+	c.nextId++
+	now := time.Now().UnixMilli()
+	o.order = Order{
+		OrderId:             fmt.Sprintf("paper-%d", c.nextId),
+		Market:              market,
+		Created:             now,
+		Updated:             now,
+		Status:              util.IfOrElse(isStop, func() OrderStatus { return OrderStatusAwaitingTrigger }, OrderStatusNew),
+		Side:                side,
+		OrderType:           orderType,
+		Amount:              order.Amount,
+		AmountRemaining:     order.Amount,
+		Price:               order.Price,
+		TriggerAmount:       order.TriggerAmount,
+		TriggerPrice:        order.TriggerAmount,
+		TriggerType:         order.TriggerType,
+		TriggerReference:    order.TriggerReference,
+		TimeInForce:         util.IfOrElse(order.TimeInForce.Value == "", func() TimeInForce { return TimeInForceDefault }, order.TimeInForce),
+		PostOnly:            order.PostOnly,
+		SelfTradePrevention: util.IfOrElse(order.SelfTradePrevention.Value == "", func() SelfTradePrevention { return SelfTradePreventionDefault }, order.SelfTradePrevention),
+		Visible:             true,
+		FilledAmount:        "0",
+		FilledAmountQuote:   "0",
+		FeePaid:             "0",
+		FeeCurrency:         quote,
+	}
+
+	if err := c.hold(o); err != nil {
+		c.mu.Unlock()
+		return Order{}, err
+	}
+	c.orders = append(c.orders, o)
+
+	orders, fills := []Order{o.order}, make([]Fill, 0)
+	if !isStop {
+		fills = c.take(o)
+		orders = append(orders, o.order)
+	}
+	moreOrders, moreFills := c.match(market)
+	result := o.order
+	c.emit(append(orders, moreOrders...), append(fills, moreFills...))
+	c.mu.Unlock()
+
+	return result, nil
+}
+
+func (c *PaperClient) UpdateOrder(_ context.Context, market string, orderId string, order OrderUpdate) (Order, error) {
+	c.mu.Lock()
+
+	o, err := c.find(market, orderId)
+	if err != nil || !o.isOpen() {
+		c.mu.Unlock()
+		return Order{}, errPaperOrderNotFound
+	}
+
+	c.release(o)
+	previous := *o
+	if order.Amount != "" {
+		o.remaining = util.ParseFloat(order.Amount) - o.filled
+		o.order.Amount = order.Amount
+	}
+	if order.AmountRemaining != "" {
+		o.remaining = util.ParseFloat(order.AmountRemaining)
+		o.order.Amount = util.FormatFloat(o.filled + o.remaining)
+	}
+	if order.Price != "" {
+		o.price = util.ParseFloat(order.Price)
+		o.order.Price = order.Price
+	}
+	if order.TriggerAmount != "" {
+		o.trigger = util.ParseFloat(order.TriggerAmount)
+		o.order.TriggerAmount = order.TriggerAmount
+		o.order.TriggerPrice = order.TriggerAmount
+	}
+	if order.TimeInForce.Value != "" {
+		o.order.TimeInForce = order.TimeInForce
+	}
+	o.order.PostOnly = order.PostOnly
+	o.order.AmountRemaining = util.FormatFloat(o.remaining)
+	o.order.Updated = time.Now().UnixMilli()
+
+	if err := c.hold(o); err != nil {
+		*o = previous
+		_ = c.hold(o)
+		c.mu.Unlock()
+		return Order{}, err
+	}
+
+	orders, fills := c.match(market)
+	result := o.order
+	c.emit(append([]Order{result}, orders...), fills)
+	c.mu.Unlock()
+
+	return result, nil
+}
+
+func (c *PaperClient) GetDepositAsset(_ context.Context, _ string) (DepositAsset, error) {
+	return DepositAsset{}, ErrPaperNotSupported
+}
+
+func (c *PaperClient) GetDepositHistory(_ context.Context, _ ...Params) ([]DepositHistory, error) {
+	return make([]DepositHistory, 0), nil
+}
+
+func (c *PaperClient) GetWithdrawalHistory(_ context.Context, _ ...Params) ([]WithdrawalHistory, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	withdrawals := make([]WithdrawalHistory, len(c.withdrawals))
+	copy(withdrawals, c.withdrawals)
+	return withdrawals, nil
+}
+
+// Withdraw deducts amount from the simulated balance, nothing is sent.
+func (c *PaperClient) Withdraw(_ context.Context, symbol string, amount string, address string, withdrawal Withdrawal) (WithDrawalResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := c.balance(symbol)
+	value := util.ParseFloat(amount)
+	if value <= 0 || b.available < value {
+		return WithDrawalResponse{}, errPaperInsufficientBalance
+	}
+	b.available -= value
+
+	c.withdrawals = append(c.withdrawals, WithdrawalHistory{
+		Timestamp: time.Now().UnixMilli(),
+		Symbol:    symbol,
+		Amount:    amount,
+		Address:   address,
+		PaymentId: withdrawal.PaymentId,
+		Fee:       "0",
+		Status:    WithdrawalHistoryStatusCompleted,
+	})
+
+	return WithDrawalResponse{Success: true, Symbol: symbol, Amount: amount}, nil
+}
+
+// isMarketOrderType reports whether orders of orderType fill without a limit price.
+func isMarketOrderType(orderType OrderType) bool {
+	return orderType == OrderTypeMarket || orderType == OrderTypeStopLoss || orderType == OrderTypeTakeProfit
+}
+
+// ensureBook retrieves a snapshot of the book if there is no local book for market yet.
+func (c *PaperClient) ensureBook(ctx context.Context, market string) error {
+	c.mu.Lock()
+	_, ok := c.books[market]
+	c.mu.Unlock()
+	if ok {
+		return nil
+	}
+
+	snapshot, err := c.GetOrderBook(ctx, market)
+	if err != nil {
+		return err
+	}
+	snapshot.Market = market
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.books[market]; !ok {
+		c.books[market] = NewLocalBook(snapshot)
+	}
+
+	return nil
+}
+
+// match triggers stop orders and fills resting orders of market, it returns the changed orders and new fills.
+func (c *PaperClient) match(market string) ([]Order, []Fill) {
+	var (
+		orders = make([]Order, 0)
+		fills  = make([]Fill, 0)
+	)
+
+	for _, o := range c.orders {
+		if o.order.Market != market || !o.isOpen() {
+			continue
+		}
+
+		if o.order.Status == OrderStatusAwaitingTrigger {
+			ref, ok := c.reference(market, o.order.TriggerReference)
+			if !ok || (o.triggersBelow() && ref > o.trigger) || (!o.triggersBelow() && ref < o.trigger) {
+				continue
+			}
+			o.order.Status = OrderStatusNew
+			fills = append(fills, c.take(o)...)
+			orders = append(orders, o.order)
+			continue
+		}
+
+		if n := len(o.order.Fills); c.fill(o, c.levels(o), false) && len(o.order.Fills) > n {
+			fills = append(fills, o.order.Fills[n:]...)
+			orders = append(orders, o.order)
+		}
+	}
+
+	return orders, fills
+}
+
+// take fills an incoming order against the book (taker), the remaining amount rests for limit orders
+// unless the time in force says otherwise.
+func (c *PaperClient) take(o *paperOrder) []Fill {
+	levels := c.levels(o)
+	n := len(o.order.Fills)
+
+	if !o.isMarket() {
+		if o.order.PostOnly && len(levels) > 0 {
+			c.close(o, OrderStatusCanceledPo)
+			return nil
+		}
+		if o.order.TimeInForce == TimeInForceFok && available(levels) < o.remaining-paperEpsilon {
+			c.close(o, OrderStatusCanceledFok)
+			return nil
+		}
+	}
+
+	c.fill(o, levels, true)
+
+	if !o.done() {
+		if o.isMarket() {
+			c.close(o, OrderStatusCanceled)
+		} else if o.order.TimeInForce == TimeInForceIoc {
+			c.close(o, OrderStatusCanceledIoc)
+		}
+	}
+
+	return o.order.Fills[n:]
+}
+
+// levels returns the opposite side of the book which can be filled by o (all levels for market orders).
+// Without a local book, the best bid/ask of the ticker is used.
+func (c *PaperClient) levels(o *paperOrder) []Page {
+	var pages []Page
+	if book, ok := c.books[o.order.Market]; ok {
+		pages = util.IfOrElse(o.isBuy(), func() []Page { return book.Book().Asks }, book.Book().Bids)
+	} else if ticker, ok := c.tickers[o.order.Market]; ok {
+		if o.isBuy() && ticker.BestAsk != "" {
+			pages = []Page{{Price: ticker.BestAsk, Size: ticker.BestAskSize}}
+		} else if !o.isBuy() && ticker.BestBid != "" {
+			pages = []Page{{Price: ticker.BestBid, Size: ticker.BestBidSize}}
+		}
+	}
+
+	if o.isMarket() {
+		return pages
+	}
+
+	crossing := make([]Page, 0)
+	for _, page := range pages {
+		price := util.ParseFloat(page.Price)
+		if (o.isBuy() && price > o.price) || (!o.isBuy() && price < o.price) {
+			break
+		}
+		crossing = append(crossing, page)
+	}
+	return crossing
+}
+
+// fill fills o against levels, takers fill at the price of the level, makers at their own price.
+// It returns false if the order got canceled due to insufficient balance.
+func (c *PaperClient) fill(o *paperOrder, levels []Page, taker bool) bool {
+	rate := util.ParseFloat(util.IfOrElse(taker, func() string { return c.fees.Taker }, c.fees.Maker))
+	c.release(o)
+
+	for _, level := range levels {
+		if o.done() {
+			break
+		}
+
+		var (
+			price  = util.IfOrElse(taker, func() float64 { return util.ParseFloat(level.Price) }, o.price)
+			amount = min(util.ParseFloat(level.Size), o.remaining)
+		)
+		if o.amountQuote > 0 {
+			left := o.amountQuote - o.filledQuote - o.fee
+			amount = min(util.ParseFloat(level.Size), util.IfOrElse(o.isBuy(), func() float64 { return left / (price * (1 + rate)) }, left/price))
+		}
+
+		var (
+			quoteAmount = amount * price
+			fee         = quoteAmount * rate
+			base        = c.balance(o.base)
+			quote       = c.balance(o.quote)
+		)
+
+		if o.isBuy() {
+			if quote.available < quoteAmount+fee-paperEpsilon {
+				c.close(o, OrderStatusCanceled)
+				return false
+			}
+			quote.available -= quoteAmount + fee
+			base.available += amount
+		} else {
+			if base.available < amount-paperEpsilon {
+				c.close(o, OrderStatusCanceled)
+				return false
+			}
+			base.available -= amount
+			quote.available += quoteAmount - fee
+		}
+
+		now := time.Now().UnixMilli()
+		o.filled += amount
+		o.filledQuote += quoteAmount
+		o.fee += fee
+		if o.amountQuote == 0 {
+			o.remaining -= amount
+		}
+
+		fill := Fill{
+			FillId:      fmt.Sprintf("%s-%d", o.order.OrderId, len(o.order.Fills)+1),
+			Market:      o.order.Market,
+			OrderId:     o.order.OrderId,
+			Timestamp:   now,
+			Amount:      util.FormatFloat(amount),
+			Side:        o.order.Side,
+			Price:       util.FormatFloat(price),
+			Taker:       taker,
+			Fee:         util.FormatFloat(fee),
+			FeeCurrency: o.quote,
+			Settled:     true,
+		}
+		c.fills = append(c.fills, fill)
+
+		o.order.Fills = append(o.order.Fills, fill)
+		o.order.FilledAmount = util.FormatFloat(o.filled)
+		o.order.FilledAmountQuote = util.FormatFloat(o.filledQuote)
+		o.order.FeePaid = util.FormatFloat(o.fee)
+		o.order.AmountRemaining = util.FormatFloat(max(o.remaining, 0))
+		o.order.Status = util.IfOrElse(o.done(), func() OrderStatus { return OrderStatusFilled }, OrderStatusPartiallyFilled)
+		o.order.Updated = now
+	}
+
+	if o.isOpen() {
+		// can only fail if the balance got lower, which is not possible while holding the lock
+		_ = c.hold(o)
+	}
+
+	return true
+}
+
+// reference returns the price for a trigger reference from the ticker (or local book for best bid/ask).
+func (c *PaperClient) reference(market string, ref OrderTriggerRef) (float64, bool) {
+	var bid, ask float64
+	if book, ok := c.books[market]; ok {
+		if page, ok := book.BestBid(); ok {
+			bid = util.ParseFloat(page.Price)
+		}
+		if page, ok := book.BestAsk(); ok {
+			ask = util.ParseFloat(page.Price)
+		}
+	}
+
+	ticker := c.tickers[market]
+	if bid == 0 {
+		bid = util.ParseFloat(ticker.BestBid)
+	}
+	if ask == 0 {
+		ask = util.ParseFloat(ticker.BestAsk)
+	}
+
+	var price float64
+	switch ref {
+	case OrderTriggerRefBestBid:
+		price = bid
+	case OrderTriggerRefBestAsk:
+		price = ask
+	case OrderTriggerRefMidPrice:
+		price = util.IfOrElse(bid > 0 && ask > 0, func() float64 { return (bid + ask) / 2 }, 0)
+	default:
+		price = util.ParseFloat(ticker.LastPrice)
+	}
+
+	return price, price > 0
+}
+
+// hold puts the balance needed for the remaining amount of o on hold.
+// Buy orders without a price (market orders) are checked once they fill.
+func (c *PaperClient) hold(o *paperOrder) error {
+	var amount float64
+	if o.isBuy() && o.price > 0 {
+		o.holdSymbol = o.quote
+		amount = o.remaining * o.price * (1 + util.ParseFloat(c.fees.Taker))
+	} else if !o.isBuy() {
+		o.holdSymbol = o.base
+		amount = util.IfOrElse(o.amountQuote > 0, func() float64 { return 0 }, o.remaining)
+	}
+	if amount <= 0 {
+		return nil
+	}
+
+	b := c.balance(o.holdSymbol)
+	if b.available < amount-paperEpsilon {
+		return errPaperInsufficientBalance
+	}
+	amount = min(amount, b.available)
+	b.available -= amount
+	b.inOrder += amount
+	o.hold = amount
+	o.order.OnHold = util.FormatFloat(amount)
+	o.order.OnHoldCurrency = o.holdSymbol
+
+	return nil
+}
+
+func (c *PaperClient) release(o *paperOrder) {
+	if o.hold == 0 {
+		return
+	}
+	b := c.balance(o.holdSymbol)
+	b.inOrder -= o.hold
+	b.available += o.hold
+	o.hold = 0
+	o.order.OnHold = "0"
+}
+
+func (c *PaperClient) close(o *paperOrder, status OrderStatus) {
+	c.release(o)
+	o.order.Status = status
+	o.order.Updated = time.Now().UnixMilli()
+}
+
+func (c *PaperClient) find(market string, orderId string) (*paperOrder, error) {
+	for _, o := range c.orders {
+		if o.order.OrderId == orderId && o.order.Market == market {
+			return o, nil
+		}
+	}
+	return nil, errPaperOrderNotFound
+}
+
+func (c *PaperClient) balance(symbol string) *paperBalance {
+	b, ok := c.balances[symbol]
+	if !ok {
+		b = new(paperBalance)
+		c.balances[symbol] = b
+	}
+	return b
+}
+
+// emit queues the events, it's called with the lock held so events are emitted in the order of the state changes.
+func (c *PaperClient) emit(orders []Order, fills []Fill) {
+	for _, order := range orders {
+		c.orderListener.send(OrderEvent{Value: order})
+	}
+	for _, fill := range fills {
+		c.fillListener.send(FillEvent{Value: fill})
+	}
+}
+
+func available(levels []Page) float64 {
+	total := 0.0
+	for _, level := range levels {
+		total += util.ParseFloat(level.Size)
+	}
+	return total
+}
+
+func limitOf[T any](values []T, opt ...Params) []T {
+	if len(opt) == 0 {
+		return values
+	}
+	limit := util.ParseFloat(opt[0].Params().Get("limit"))
+	if limit > 0 && int(limit) < len(values) {
+		return values[:int(limit)]
+	}
+	return values
+}
+
+// paperListener emits events of the paper client for the subscribed markets.
+// Events are queued without a limit, so the client never blocks on a subscriber which doesn't read.
+type paperListener[T any] struct {
+	mu      sync.Mutex
+	ready   *sync.Cond
+	pending []T
+	chn     chan T
+	markets map[string]bool
+	market  func(T) string
+	closed  bool
+}
+
+func newPaperListener[T any](market func(T) string) *paperListener[T] {
+	l := &paperListener[T]{
+		chn:     make(chan T),
+		markets: make(map[string]bool),
+		market:  market,
+	}
+	l.ready = sync.NewCond(&l.mu)
+
+	go func() {
+		defer close(l.chn)
+		for {
+			l.mu.Lock()
+			for len(l.pending) == 0 && !l.closed {
+				l.ready.Wait()
+			}
+			if len(l.pending) == 0 {
+				l.mu.Unlock()
+				return
+			}
+			event := l.pending[0]
+			l.pending = l.pending[1:]
+			l.mu.Unlock()
+
+			l.chn <- event
+		}
+	}()
+
+	return l
+}
+
+func (l *paperListener[T]) Subscribe(markets []string) (<-chan T, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, market := range markets {
+		l.markets[market] = true
+	}
+	return l.chn, nil
+}
+
+func (l *paperListener[T]) Unsubscribe(markets []string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.markets) == 0 {
+		return ErrNoSubscriptions
+	}
+	for _, market := range markets {
+		delete(l.markets, market)
+	}
+	return nil
+}
+
+func (l *paperListener[T]) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// the queued events are still delivered
+	l.closed = true
+	l.ready.Signal()
+	return nil
+}
+
+// send queues event, it doesn't block.
+func (l *paperListener[T]) send(event T) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.closed && l.markets[l.market(event)] {
+		l.pending = append(l.pending, event)
+		l.ready.Signal()
+	}
+}
@@ -0,0 +1,106 @@
+package bitvavo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+)
+
+type bookAPI struct {
+	PublicAPI
+	book Book
+}
+
+func (a *bookAPI) GetOrderBook(_ context.Context, _ string, _ ...uint64) (Book, error) {
+	return a.book, nil
+}
+
+func TestPaperClientMarketOrder(t *testing.T) {
+	api := &bookAPI{book: Book{
+		Nonce: 1,
+		Asks:  []Page{{Price: "100", Size: "1"}, {Price: "101", Size: "2"}},
+		Bids:  []Page{{Price: "99", Size: "1"}},
+	}}
+	client := NewPaperClient(api, WithPaperBalance("EUR", "1000"), WithPaperFees(Fee{Taker: "0.01", Maker: "0"}))
+
+	order, err := client.NewOrder(context.Background(), "ETH-EUR", SideBuy, OrderTypeMarket, OrderNew{Amount: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.AssertEqual(t, OrderStatusFilled, order.Status)
+	test.AssertEqual(t, 2, len(order.Fills))
+	test.AssertEqual(t, "201", order.FilledAmountQuote)
+	test.AssertEqual(t, "2.01", order.FeePaid)
+
+	balances, _ := client.GetBalance(context.Background())
+	test.AssertEqual(t, Balance{Symbol: "ETH", Available: "2", InOrder: "0"}, balances[0])
+	test.AssertEqual(t, Balance{Symbol: "EUR", Available: "796.99", InOrder: "0"}, balances[1])
+}
+
+func TestPaperClientRestingLimitOrder(t *testing.T) {
+	api := &bookAPI{book: Book{
+		Nonce: 1,
+		Asks:  []Page{{Price: "100", Size: "1"}},
+		Bids:  []Page{{Price: "98", Size: "1"}},
+	}}
+	client := NewPaperClient(api, WithPaperBalance("EUR", "99"))
+
+	fills, _ := client.FillListener().Subscribe([]string{"ETH-EUR"})
+
+	order, err := client.NewOrder(context.Background(), "ETH-EUR", SideBuy, OrderTypeLimit, OrderNew{Amount: "1", Price: "99"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, OrderStatusNew, order.Status)
+	test.AssertEqual(t, "99", order.OnHold)
+
+	_, err = client.NewOrder(context.Background(), "ETH-EUR", SideBuy, OrderTypeLimit, OrderNew{Amount: "1", Price: "99"})
+	test.AssertEqual[error](t, errPaperInsufficientBalance, err)
+
+	// an ask drops below the limit price
+	if err := client.UpdateBook(context.Background(), Book{Market: "ETH-EUR", Nonce: 2, Asks: []Page{{Price: "98.5", Size: "3"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	fill := (<-fills).Value
+	test.AssertEqual(t, order.OrderId, fill.OrderId)
+	test.AssertEqual(t, "99", fill.Price)
+	test.AssertEqual(t, false, fill.Taker)
+
+	filled, _ := client.GetOrder(context.Background(), "ETH-EUR", order.OrderId)
+	test.AssertEqual(t, OrderStatusFilled, filled.Status)
+}
+
+func TestPaperClientSubscriberNotReading(t *testing.T) {
+	api := &bookAPI{book: Book{
+		Nonce: 1,
+		Asks:  []Page{{Price: "100", Size: "1"}},
+		Bids:  []Page{{Price: "98", Size: "1"}},
+	}}
+	client := NewPaperClient(api, WithPaperBalance("EUR", "100000"))
+	orders, _ := client.OrderListener().Subscribe([]string{"ETH-EUR"})
+
+	// more events than a buffered channel would hold, nobody reads them
+	for i := 0; i < 1500; i++ {
+		order, err := client.NewOrder(context.Background(), "ETH-EUR", SideBuy, OrderTypeLimit, OrderNew{Amount: "1", Price: "50"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.CancelOrder(context.Background(), "ETH-EUR", order.OrderId); err != nil {
+			t.Fatal(err)
+		}
+	}
+	test.AssertEqual(t, nil, client.OrderListener().Close())
+
+	// the queued events are delivered in order (placed, matched and canceled for every order)
+	first := <-orders
+	test.AssertEqual(t, "paper-1", first.Value.OrderId)
+	test.AssertEqual(t, OrderStatusNew, first.Value.Status)
+	count := 1
+	for range orders {
+		count++
+	}
+	test.AssertEqual(t, 4500, count)
+}
@@ -0,0 +1,221 @@
+package bitvavo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/orsinium-labs/enum"
+)
+
+// recordingMagic is the header of a recording in binary format.
+var recordingMagic = []byte("BVREC1\n")
+
+type RecordFormat enum.Member[string]
+
+var (
+	recordFormat = enum.NewBuilder[string, RecordFormat]()
+	// RecordFormatJSON writes a JSON object per line: {"timestamp":<unix nanoseconds>,"frame":<raw frame>}
+	RecordFormatJSON = recordFormat.Add(RecordFormat{"json"})
+	// RecordFormatBinary writes a header followed by: <unix nanoseconds int64><frame length uint32><raw frame> (big endian)
+	RecordFormatBinary = recordFormat.Add(RecordFormat{"binary"})
+)
+
+type recordLine struct {
+	Timestamp int64           `json:"timestamp"`
+	Frame     json.RawMessage `json:"frame"`
+}
+
+type recorder struct {
+	mu     sync.Mutex
+	writer io.Writer
+	format RecordFormat
+	header bool
+}
+
+// WithWebSocketRecorder records every raw frame received by the websocket with the time it was received.
+// Use NewReplay to replay the recording.
+func WithWebSocketRecorder(writer io.Writer, format RecordFormat) WebSocketOption {
+	return func(ws *WebSocket) {
+		ws.recorder = &recorder{writer: writer, format: format}
+	}
+}
+
+// WithWebSocketReplay feeds a recording into the websocket instead of connecting to the exchange.
+// Subscribing and authenticating has no effect during a replay, the recorded events are emitted as they were received.
+func WithWebSocketReplay(replay *Replay) WebSocketOption {
+	return func(ws *WebSocket) {
+		ws.replay = replay
+	}
+}
+
+func (r *recorder) record(at time.Time, frame []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.format == RecordFormatBinary {
+		if !r.header {
+			if _, err := r.writer.Write(recordingMagic); err != nil {
+				return err
+			}
+			r.header = true
+		}
+
+		record := make([]byte, 12, 12+len(frame))
+		binary.BigEndian.PutUint64(record, uint64(at.UnixNano()))
+		binary.BigEndian.PutUint32(record[8:], uint32(len(frame)))
+		_, err := r.writer.Write(append(record, frame...))
+		return err
+	}
+
+	line, err := json.Marshal(recordLine{Timestamp: at.UnixNano(), Frame: frame})
+	if err != nil {
+		return err
+	}
+	_, err = r.writer.Write(append(line, '\n'))
+	return err
+}
+
+type ReplayOption func(*Replay)
+
+// WithReplaySpeed replays the recording speed times faster than it was recorded (e.g: 10).
+// A speed of 0 replays without any delay.
+//
+// Default: 1 (original speed)
+func WithReplaySpeed(speed float64) ReplayOption {
+	return func(r *Replay) {
+		r.speed = speed
+	}
+}
+
+// WithReplayStepwise only replays the next frame when Step has been called.
+func WithReplayStepwise() ReplayOption {
+	return func(r *Replay) {
+		r.step = make(chan struct{})
+	}
+}
+
+// Replay replays a recording made with WithWebSocketRecorder, the format is detected automatically.
+// A Replay can only be used once.
+type Replay struct {
+	reader *bufio.Reader
+	speed  float64
+	step   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+func NewReplay(reader io.Reader, options ...ReplayOption) *Replay {
+	r := &Replay{
+		reader: bufio.NewReader(reader),
+		speed:  1,
+		done:   make(chan struct{}),
+	}
+
+	for _, opt := range options {
+		opt(r)
+	}
+
+	return r
+}
+
+// Step releases the next frame when replaying stepwise.
+// It blocks until the replay picked up the step and returns false if the replay has finished.
+func (r *Replay) Step() bool {
+	if r.step == nil {
+		return false
+	}
+	select {
+	case r.step <- struct{}{}:
+		return true
+	case <-r.done:
+		return false
+	}
+}
+
+// Done is closed when the whole recording has been replayed.
+func (r *Replay) Done() <-chan struct{} {
+	return r.done
+}
+
+// Run replays the recording into messageFunc, the same func as accepted by NewWebSocket, so it can be used without
+// any websocket or listener. It blocks until the recording has been replayed or ctx is done.
+func (r *Replay) Run(ctx context.Context, messageFunc func(WebSocketEventData, error)) error {
+	return r.run(ctx, newMessageHandler(messageFunc, newDefaultWebSocket()))
+}
+
+func (r *Replay) run(ctx context.Context, emit func([]byte)) error {
+	defer r.once.Do(func() { close(r.done) })
+
+	header, err := r.reader.Peek(len(recordingMagic))
+	isBinary := err == nil && bytes.Equal(header, recordingMagic)
+	if isBinary {
+		_, _ = r.reader.Discard(len(recordingMagic))
+	}
+
+	var previous int64
+	for {
+		timestamp, frame, err := r.next(isBinary)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if r.step != nil {
+			select {
+			case <-r.step:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		} else if r.speed > 0 && previous > 0 && timestamp > previous {
+			select {
+			case <-time.After(time.Duration(float64(timestamp-previous) / r.speed)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		previous = timestamp
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		emit(frame)
+	}
+}
+
+func (r *Replay) next(isBinary bool) (int64, []byte, error) {
+	if isBinary {
+		header := make([]byte, 12)
+		if _, err := io.ReadFull(r.reader, header); err != nil {
+			return 0, nil, err
+		}
+		frame := make([]byte, binary.BigEndian.Uint32(header[8:]))
+		if _, err := io.ReadFull(r.reader, frame); err != nil {
+			return 0, nil, err
+		}
+		return int64(binary.BigEndian.Uint64(header)), frame, nil
+	}
+
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) == 0 {
+			if err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+
+		var record recordLine
+		if err := json.Unmarshal(line, &record); err != nil {
+			return 0, nil, err
+		}
+		return record.Timestamp, record.Frame, nil
+	}
+}
@@ -0,0 +1,76 @@
+package bitvavo
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+)
+
+var recordedFrames = []string{
+	`{"event":"subscribed","subscriptions":{"ticker":["ETH-EUR"]}}`,
+	`{"event":"ticker","market":"ETH-EUR","bestBid":"2500"}`,
+	`{"errorCode":110,"error":"Invalid endpoint."}`,
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	for _, format := range []RecordFormat{RecordFormatJSON, RecordFormatBinary} {
+		var buffer bytes.Buffer
+		r := &recorder{writer: &buffer, format: format}
+
+		start := time.Now()
+		for i, frame := range recordedFrames {
+			if err := r.record(start.Add(time.Duration(i)*time.Hour), []byte(frame)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		events := make([]WebSocketEventData, 0)
+		errs := make([]error, 0)
+		replay := NewReplay(&buffer, WithReplaySpeed(0))
+		err := replay.Run(context.Background(), func(data WebSocketEventData, err error) {
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				events = append(events, data)
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		test.AssertEqual(t, 2, len(events))
+		test.AssertEqual(t, EventSubscribed, events[0].Event)
+
+		var ticker Ticker
+		if err := events[1].Decode(&ticker); err != nil {
+			t.Fatal(err)
+		}
+		test.AssertEqual(t, "2500", ticker.BestBid)
+
+		test.AssertEqual(t, 1, len(errs))
+		test.AssertEqual(t, "code 110: Invalid endpoint.", errs[0].Error())
+	}
+}
+
+func TestReplayStepwise(t *testing.T) {
+	var buffer bytes.Buffer
+	r := &recorder{writer: &buffer, format: RecordFormatJSON}
+	for _, frame := range recordedFrames[:2] {
+		_ = r.record(time.Now(), []byte(frame))
+	}
+
+	received := make(chan WebSocketEventData, 2)
+	replay := NewReplay(&buffer, WithReplayStepwise())
+	go func() {
+		_ = replay.Run(context.Background(), func(data WebSocketEventData, _ error) { received <- data })
+	}()
+
+	test.AssertEqual(t, true, replay.Step())
+	test.AssertEqual(t, EventSubscribed, (<-received).Event)
+	test.AssertEqual(t, true, replay.Step())
+	test.AssertEqual(t, EventTicker, (<-received).Event)
+	test.AssertEqual(t, false, replay.Step())
+}
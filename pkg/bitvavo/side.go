@@ -10,3 +10,8 @@ var (
 	SideSell = side.Add(Side{"sell"})
 	sides    = side.Enum()
 )
+
+// ParseSide parses buy or sell, it returns nil for any other value.
+func ParseSide(value string) *Side {
+	return sides.Parse(value)
+}
@@ -33,6 +33,11 @@ var (
 	intervals   = interval.Enum()
 )
 
+// ParseInterval parses an interval (e.g: 1m, 1h), it returns nil for any other value.
+func ParseInterval(value string) *Interval {
+	return intervals.Parse(value)
+}
+
 type Subscription struct {
 	Markets   []string
 	Intervals []Interval
@@ -0,0 +1,169 @@
+package bitvavo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+)
+
+// orderSpanTTL is how long the span of NewOrder is kept for linking if the order never reaches a final status
+// or its fills never all arrive.
+const orderSpanTTL = time.Hour * 24
+
+// Span is a single traced operation, it maps directly onto an OpenTelemetry span.
+type Span interface {
+	// SetAttribute sets an attribute, value is a string, int, int64 or bool.
+	SetAttribute(key string, value any)
+
+	// RecordError records err and marks the span as failed.
+	RecordError(err error)
+
+	// End completes the span.
+	End()
+}
+
+// Tracer starts spans, implement it with OpenTelemetry or any other tracing library.
+type Tracer interface {
+	// Start starts a span as child of the span in ctx (if any), links are spans which caused this span but are not its parent.
+	// It returns the span and ctx containing the span.
+	Start(ctx context.Context, name string, links ...Span) (context.Context, Span)
+}
+
+// Tracing traces API calls and order/fill events with a Tracer.
+//
+// Use the same Tracing for the http client (see: WithTracing) and the order and fill listeners (see: WithWebSocketTracing)
+// to link the EventOrder and EventFill spans to the NewOrder span of the same order.
+type Tracing struct {
+	tracer Tracer
+
+	mu     sync.Mutex
+	orders map[string]orderSpan
+}
+
+type orderSpan struct {
+	span    Span
+	created time.Time
+
+	// the amount of the fill events and the filled amount of the order once it's final, -1 before
+	fills  float64
+	filled float64
+}
+
+func NewTracing(tracer Tracer) *Tracing {
+	return &Tracing{
+		tracer: tracer,
+		orders: make(map[string]orderSpan),
+	}
+}
+
+// WithTracing creates a span for every API call with the endpoint, market and error code.
+// The span is started before any other middleware (see: WithMiddleware), so it covers retries.
+func WithTracing(tracing *Tracing) HttpOption {
+	return func(c *httpClient) {
+		c.httpConfig.middleware = append([]Middleware{tracing.middleware}, c.httpConfig.middleware...)
+	}
+}
+
+// WithWebSocketTracing creates a span for every order and fill event, linked to the NewOrder span of the same order.
+// Market data events are not traced.
+func WithWebSocketTracing(tracing *Tracing) WebSocketOption {
+	return func(ws *WebSocket) {
+		ws.tracing = tracing
+	}
+}
+
+func (t *Tracing) middleware(next Invoker) Invoker {
+	return func(ctx context.Context, call *Call) (any, error) {
+		ctx, span := t.tracer.Start(ctx, "bitvavo."+call.Endpoint)
+		defer span.End()
+
+		span.SetAttribute("bitvavo.endpoint", call.Endpoint)
+		span.SetAttribute("http.request.method", call.Method)
+		span.SetAttribute("url.path", call.Path)
+		if market := call.Market(); market != "" {
+			span.SetAttribute("bitvavo.market", market)
+		}
+
+		result, err := next(ctx, call)
+		span.SetAttribute("bitvavo.attempts", call.Attempt)
+
+		if err != nil {
+			var apiError *ApiError
+			if errors.As(err, &apiError) {
+				span.SetAttribute("bitvavo.error_code", apiError.Code)
+			}
+			span.RecordError(err)
+			return result, err
+		}
+
+		if order, ok := result.(Order); ok && call.Endpoint == "NewOrder" && order.OrderId != "" {
+			span.SetAttribute("bitvavo.order_id", order.OrderId)
+			t.remember(order.OrderId, span)
+		}
+
+		return result, err
+	}
+}
+
+func (t *Tracing) remember(orderId string, span Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for id, order := range t.orders {
+		if now.Sub(order.created) > orderSpanTTL {
+			delete(t.orders, id)
+		}
+	}
+	t.orders[orderId] = orderSpan{span: span, created: now, filled: -1}
+}
+
+// event creates a span for an order or fill event, linked to the NewOrder span of the order.
+func (t *Tracing) event(data WebSocketEventData) {
+	if data.orderId == "" {
+		return
+	}
+
+	t.mu.Lock()
+	order, ok := t.orders[data.orderId]
+	if ok {
+		if data.Event == EventFill {
+			order.fills += util.ParseFloat(data.amount)
+		} else if isFinalStatus(data.status) && data.filled != "" {
+			order.filled = util.ParseFloat(data.filled)
+		}
+		// the last fill may arrive after the order event with the final status
+		if order.filled >= 0 && order.fills >= order.filled-1e-12 {
+			delete(t.orders, data.orderId)
+		} else {
+			t.orders[data.orderId] = order
+		}
+	}
+	t.mu.Unlock()
+
+	links := make([]Span, 0, 1)
+	if ok {
+		links = append(links, order.span)
+	}
+
+	_, span := t.tracer.Start(context.Background(), "bitvavo.event."+data.Event.Value, links...)
+	defer span.End()
+
+	span.SetAttribute("bitvavo.market", data.market)
+	span.SetAttribute("bitvavo.order_id", data.orderId)
+	if data.status != "" {
+		span.SetAttribute("bitvavo.order_status", data.status)
+	}
+}
+
+func isFinalStatus(status string) bool {
+	switch status {
+	case OrderStatusNew.Value, OrderStatusAwaitingTrigger.Value, OrderStatusPartiallyFilled.Value, "":
+		return false
+	default:
+		return true
+	}
+}
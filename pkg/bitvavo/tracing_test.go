@@ -0,0 +1,82 @@
+package bitvavo
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+)
+
+type recordedSpan struct {
+	name       string
+	links      []Span
+	attributes map[string]any
+	err        error
+	ended      bool
+}
+
+func (s *recordedSpan) SetAttribute(key string, value any) { s.attributes[key] = value }
+func (s *recordedSpan) RecordError(err error)              { s.err = err }
+func (s *recordedSpan) End()                               { s.ended = true }
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, links ...Span) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span := &recordedSpan{name: name, links: links, attributes: make(map[string]any)}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+const tracedOrder = `{"orderId":"1","market":"ETH-EUR","status":"new","side":"buy","orderType":"limit","selfTradePrevention":"decrementAndCancel"}`
+
+func TestTracingLinksEventsToNewOrder(t *testing.T) {
+	tracer := new(recordingTracer)
+	tracing := NewTracing(tracer)
+
+	transport := roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		if request.Method == "DELETE" {
+			return respond(http.StatusBadRequest, `{"errorCode":240,"error":"No order found."}`), nil
+		}
+		return respond(http.StatusOK, tracedOrder), nil
+	})
+	client := NewPrivateHTTPClient("key", "secret", WithHttpClient(&http.Client{Transport: transport}), WithTracing(tracing))
+
+	if _, err := client.NewOrder(context.Background(), "ETH-EUR", SideBuy, OrderTypeLimit, OrderNew{Amount: "1", Price: "2000"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := client.CancelOrder(context.Background(), "ETH-EUR", "2")
+	test.AssertEqual(t, true, err != nil)
+
+	handler := newMessageHandler(func(WebSocketEventData, error) {}, &WebSocket{metrics: noopMetrics{}, logger: discardLogger, tracing: tracing})
+	// the last fill arrives after the order event with the final status
+	handler([]byte(`{"event":"fill","market":"ETH-EUR","orderId":"1","fillId":"f1","amount":"0.4"}`))
+	handler([]byte(`{"event":"order","market":"ETH-EUR","orderId":"1","status":"filled","amount":"1","amountRemaining":"0"}`))
+	handler([]byte(`{"event":"fill","market":"ETH-EUR","orderId":"1","fillId":"f2","amount":"0.6"}`))
+	handler([]byte(`{"event":"order","market":"ETH-EUR","orderId":"1","status":"filled","amount":"1","amountRemaining":"0"}`))
+
+	test.AssertEqual(t, 6, len(tracer.spans))
+
+	newOrder, cancel, fill, order, lastFill, late := tracer.spans[0], tracer.spans[1], tracer.spans[2], tracer.spans[3], tracer.spans[4], tracer.spans[5]
+
+	test.AssertEqual(t, "bitvavo.NewOrder", newOrder.name)
+	test.AssertEqual(t, true, newOrder.ended)
+	test.AssertEqual(t, "ETH-EUR", newOrder.attributes["bitvavo.market"])
+	test.AssertEqual(t, "1", newOrder.attributes["bitvavo.order_id"])
+
+	test.AssertEqual(t, 240, cancel.attributes["bitvavo.error_code"])
+	test.AssertEqual(t, true, cancel.err != nil)
+
+	test.AssertEqual(t, "bitvavo.event.fill", fill.name)
+	test.AssertEqual(t, Span(newOrder), fill.links[0])
+	test.AssertEqual(t, Span(newOrder), order.links[0])
+	test.AssertEqual(t, Span(newOrder), lastFill.links[0])
+	test.AssertEqual(t, 0, len(late.links))
+}
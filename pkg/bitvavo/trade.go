@@ -49,6 +49,10 @@ func (t *TradeParams) Params() url.Values {
 
 type TradeHistoric Fill
 
+func (t *TradeHistoric) UnmarshalJSON(bytes []byte) error {
+	return (*Fill)(t).UnmarshalJSON(bytes)
+}
+
 type Trade struct {
 	// The trade ID of the returned trade (UUID).
 	Id string `json:"id"`
@@ -0,0 +1,32 @@
+package bitvavo
+
+import (
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+)
+
+func TestTradeHistoricUnmarshal(t *testing.T) {
+	// the trades endpoint returns the fill id as id
+	bytes := []byte(`[{"id":"108c3633-0276-4480-a902-17a01829deae","orderId":"1d671998-3d44-4df4-965f-0d48bd129a1b","timestamp":1542967486256,"market":"BTC-EUR","side":"buy","amount":"0.005","price":"5000.1","taker":true,"fee":"0.03","feeCurrency":"EUR","settled":true}]`)
+
+	var trades []TradeHistoric
+	if err := json.Unmarshal(bytes, &trades); err != nil {
+		t.Fatal(err)
+	}
+
+	test.AssertEqual(t, TradeHistoric{
+		FillId:      "108c3633-0276-4480-a902-17a01829deae",
+		Market:      "BTC-EUR",
+		OrderId:     "1d671998-3d44-4df4-965f-0d48bd129a1b",
+		Timestamp:   1542967486256,
+		Amount:      "0.005",
+		Side:        SideBuy,
+		Price:       "5000.1",
+		Taker:       true,
+		Fee:         "0.03",
+		FeeCurrency: "EUR",
+		Settled:     true,
+	}, trades[0])
+}
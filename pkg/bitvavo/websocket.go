@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -58,10 +59,21 @@ var (
 type WebSocketEventData struct {
 	Event  WebSocketEvent
 	Reader io.Reader
+
+	market        string
+	orderId       string
+	status        string
+	amount        string
+	filled        string
+	onDecodeError func()
 }
 
 func (d *WebSocketEventData) Decode(v any) error {
-	return json.NewDecoder(d.Reader).Decode(v)
+	err := json.NewDecoder(d.Reader).Decode(v)
+	if err != nil && d.onDecodeError != nil {
+		d.onDecodeError()
+	}
+	return err
 }
 
 func (d *WebSocketEventData) UnmarshalJSON(b []byte) error {
@@ -78,6 +90,17 @@ func (d *WebSocketEventData) UnmarshalJSON(b []byte) error {
 
 	d.Event = *webSocketEvents.Parse(event)
 	d.Reader = bytes.NewReader(b)
+	d.market = util.GetOrEmpty[string]("market", j)
+	if d.Event == EventOrder || d.Event == EventFill {
+		d.orderId = util.GetOrEmpty[string]("orderId", j)
+		d.status = util.GetOrEmpty[string]("status", j)
+		d.amount = util.GetOrEmpty[string]("amount", j)
+		d.filled = util.GetOrEmpty[string]("filledAmount", j)
+		if d.Event == EventOrder && d.filled == "" && d.amount != "" {
+			// order events have no filledAmount
+			d.filled = util.FormatFloat(util.ParseFloat(d.amount) - util.ParseFloat(util.GetOrEmpty[string]("amountRemaining", j)))
+		}
+	}
 
 	return nil
 }
@@ -85,9 +108,15 @@ func (d *WebSocketEventData) UnmarshalJSON(b []byte) error {
 type WebSocketOption func(*WebSocket)
 
 type WebSocket struct {
-	socket     *socket.Socket
-	printer    DebugPrinter
-	httpClient *http.Client
+	socket      *socket.Socket
+	printer     DebugPrinter
+	logger      *slog.Logger
+	httpClient  *http.Client
+	recorder    *recorder
+	replay      *Replay
+	metrics     Metrics
+	tracing     *Tracing
+	onReconnect func()
 }
 
 func WithWebSocketHttpClient(client *http.Client) WebSocketOption {
@@ -96,12 +125,22 @@ func WithWebSocketHttpClient(client *http.Client) WebSocketOption {
 	}
 }
 
+// WithWebSocketDebugPrinter prints every log line, including received frames, to printer.
+// Prefer WithWebSocketLogger for structured and leveled logging.
 func WithWebSocketDebugPrinter(printer DebugPrinter) WebSocketOption {
 	return func(ws *WebSocket) {
 		ws.printer = printer
 	}
 }
 
+// WithWebSocketReconnectFunc calls fn after the websocket reconnected and resubscribed,
+// use it to reconcile state which may have changed while disconnected (e.g: GetOrdersOpen).
+func WithWebSocketReconnectFunc(fn func()) WebSocketOption {
+	return func(ws *WebSocket) {
+		ws.onReconnect = fn
+	}
+}
+
 func WithWebSocketDefaultDebugPrinter() WebSocketOption {
 	return func(ws *WebSocket) {
 		ws.printer = NewDefaultDebugPrinter()
@@ -114,38 +153,43 @@ func NewWebSocket(
 	reconnectFunc func(),
 	options ...WebSocketOption,
 ) (*WebSocket, error) {
-	ws := new(WebSocket)
-	ws.httpClient = http.DefaultClient
+	ws := newDefaultWebSocket()
 
 	for _, opt := range options {
 		opt(ws)
 	}
 
-	onMessage := func(bytes []byte) {
-		var data WebSocketEventData
-		if err := json.Unmarshal(bytes, &data); err != nil {
-			var wsError WebSocketError
-			if err := json.Unmarshal(bytes, &wsError); err != nil {
-				messageFunc(data, err)
-			} else {
-				messageFunc(data, &wsError)
+	onLog := func(level slog.Level, msg string, args ...any) {
+		logTo(ctx, ws.logger, ws.printer, level, msg, args...)
+	}
+
+	opts := &socket.Options{
+		Url:         websocketURL,
+		HttpClient:  ws.httpClient,
+		MessageFunc: newMessageHandler(messageFunc, ws),
+		ReconnectFunc: func() {
+			ws.metrics.IncReconnect()
+			reconnectFunc()
+			if ws.onReconnect != nil {
+				ws.onReconnect()
+			}
+		},
+		RetryFunc:        func() { ws.metrics.IncRetry("websocket") },
+		BackpressureFunc: ws.metrics.ObserveBackpressure,
+		DropFunc:         ws.metrics.AddDropped,
+		LogFunc:          onLog,
+	}
+	if ws.recorder != nil {
+		opts.RecordFunc = func(at time.Time, bytes []byte) {
+			if err := ws.recorder.record(at, bytes); err != nil {
+				onLog(slog.LevelWarn, "websocket recording failed", slog.Any("error", err))
 			}
-		} else {
-			messageFunc(data, nil)
 		}
 	}
-
-	onDebug := func(message string) {
-		debug(ws.printer, message)
+	if ws.replay != nil {
+		opts.ReplayFunc = ws.replay.run
 	}
 
-	opts := &socket.Options{
-		Url:           websocketURL,
-		HttpClient:    ws.httpClient,
-		MessageFunc:   onMessage,
-		ReconnectFunc: reconnectFunc,
-		DebugFunc:     onDebug,
-	}
 	s, err := socket.NewSocket(ctx, opts)
 	if err != nil {
 		return nil, err
@@ -155,6 +199,45 @@ func NewWebSocket(
 	return ws, nil
 }
 
+func newDefaultWebSocket() *WebSocket {
+	return &WebSocket{
+		httpClient: http.DefaultClient,
+		logger:     discardLogger,
+		metrics:    noopMetrics{},
+	}
+}
+
+// newMessageHandler decodes raw frames into events or errors for messageFunc, using the logger, metrics and tracing of ws.
+func newMessageHandler(messageFunc func(WebSocketEventData, error), ws *WebSocket) func([]byte) {
+	metrics, logger := ws.metrics, ws.logger
+	return func(bytes []byte) {
+		var data WebSocketEventData
+		if err := json.Unmarshal(bytes, &data); err != nil {
+			var wsError WebSocketError
+			if err := json.Unmarshal(bytes, &wsError); err != nil {
+				metrics.IncDecodeError("")
+				logger.Warn("websocket message could not be decoded", slog.Any("error", err))
+				messageFunc(data, err)
+			} else {
+				logger.Warn("websocket error received", slog.Int("code", wsError.Code), slog.String("action", wsError.Action), slog.String("error", wsError.Message))
+				messageFunc(data, &wsError)
+			}
+		} else {
+			if channel, ok := channelOf(data.Event); ok {
+				metrics.IncMessage(channel.Value, data.market)
+				data.onDecodeError = func() {
+					metrics.IncDecodeError(channel.Value)
+					logger.Warn("websocket event could not be decoded", slog.String("channel", channel.Value), slog.String("market", data.market))
+				}
+			}
+			if ws.tracing != nil {
+				ws.tracing.event(data)
+			}
+			messageFunc(data, nil)
+		}
+	}
+}
+
 func (w *WebSocket) Authenticate(apiKey string, apiSecret string) error {
 	timestamp := time.Now().UnixMilli()
 	msg := messageOut{
@@ -172,6 +255,7 @@ func (w *WebSocket) Subscribe(subscriptions []Subscription) error {
 		Action:   "subscribe",
 		Channels: mapToChannels(subscriptions),
 	}
+	w.logSubscriptions("websocket subscribe", subscriptions)
 
 	return w.socket.SendJSON(context.Background(), msg)
 }
@@ -181,10 +265,17 @@ func (w *WebSocket) Unsubscribe(subscriptions []Subscription) error {
 		Action:   "unsubscribe",
 		Channels: mapToChannels(subscriptions),
 	}
+	w.logSubscriptions("websocket unsubscribe", subscriptions)
 
 	return w.socket.SendJSON(context.Background(), msg)
 }
 
+func (w *WebSocket) logSubscriptions(msg string, subscriptions []Subscription) {
+	for _, subscription := range subscriptions {
+		logTo(context.Background(), w.logger, w.printer, slog.LevelDebug, msg, slog.String("channel", subscription.Channel.Value), slog.Any("markets", subscription.Markets))
+	}
+}
+
 func mapToChannels(subscriptions []Subscription) []channelOut {
 	channels := make([]channelOut, len(subscriptions))
 
@@ -0,0 +1,413 @@
+package bitvavo
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+)
+
+var (
+	ErrWithdrawalGuardNotEnabled = errors.New("the withdrawal guard is not enabled on a private client (see: WithWithdrawalGuard)")
+	ErrWithdrawalsDisabled       = errors.New("withdrawals of the asset are not enabled")
+	ErrWithdrawalBelowMinimum    = errors.New("amount is below the minimum withdrawal amount of the asset")
+	ErrWithdrawalFeeNotCovered   = errors.New("amount does not cover the withdrawal fee of the asset")
+	ErrAddressNotAllowed         = errors.New("address is not on the allow-list")
+	ErrNetworkNotSupported       = errors.New("network of the address is not supported by the asset")
+	ErrWithdrawalDailyCap        = errors.New("daily withdrawal cap of the asset exceeded")
+	ErrWithdrawalNotApproved     = errors.New("withdrawal has not been approved")
+	ErrWithdrawalNotConfirmed    = errors.New("withdrawal must be prepared and confirmed")
+	ErrUnknownWithdrawal         = errors.New("unknown or expired withdrawal")
+	ErrWithdrawalUnreadable      = errors.New("withdrawal can't be checked, the request body is not a withdrawal")
+)
+
+// WithdrawalAddress is an address on the allow-list of the WithdrawalGuard.
+type WithdrawalAddress struct {
+	// The short name of the asset (e.g: BTC)
+	Symbol string
+
+	// The network of the address (e.g: ETH), it must be one of the networks of the asset (see: Asset.Networks).
+	// An empty network is not checked.
+	Network string
+
+	Address string
+
+	// The payment id (note, memo or tag) which must be used with the address, empty if none.
+	PaymentId string
+}
+
+// WithdrawalRequest is a withdrawal which passed the checks of the WithdrawalGuard.
+type WithdrawalRequest struct {
+	// The id to confirm a prepared withdrawal (see: WithdrawalGuard.Prepare), empty otherwise.
+	Id string
+
+	Withdrawal Withdrawal
+
+	// The network of the address on the allow-list.
+	Network string
+
+	// The withdrawal fee of the asset, 0 for internal withdrawals.
+	Fee float64
+
+	// The amount which is deducted from the balance and the amount which is received on the address.
+	Deducted float64
+	Received float64
+
+	// When a prepared withdrawal can't be confirmed anymore.
+	ExpiresAt time.Time
+}
+
+type WithdrawalGuardOption func(*WithdrawalGuard)
+
+// WithAllowedAddresses adds addresses to the allow-list, a withdrawal to any other address is rejected.
+func WithAllowedAddresses(addresses ...WithdrawalAddress) WithdrawalGuardOption {
+	return func(g *WithdrawalGuard) {
+		g.addresses = append(g.addresses, addresses...)
+	}
+}
+
+// WithWithdrawalDailyCap limits the amount of symbol (e.g: BTC) which is withdrawn on a day (UTC), including the fees.
+//
+// Default: no limit
+func WithWithdrawalDailyCap(symbol string, amount float64) WithdrawalGuardOption {
+	return func(g *WithdrawalGuard) {
+		g.caps[symbol] = amount
+	}
+}
+
+// WithWithdrawalApproval calls approve before a withdrawal is sent (e.g: to ask a human), the withdrawal is rejected
+// with ErrWithdrawalNotApproved if it returns false.
+//
+// Default: all withdrawals which pass the checks are approved
+func WithWithdrawalApproval(approve func(ctx context.Context, request WithdrawalRequest) bool) WithdrawalGuardOption {
+	return func(g *WithdrawalGuard) {
+		g.approve = approve
+	}
+}
+
+// WithWithdrawalConfirmation only sends withdrawals which were prepared and confirmed within expiry
+// (see: WithdrawalGuard.Prepare), Withdraw on the client returns ErrWithdrawalNotConfirmed.
+//
+// Default: Withdraw on the client is allowed, prepared withdrawals expire after 5 minutes
+func WithWithdrawalConfirmation(expiry time.Duration) WithdrawalGuardOption {
+	return func(g *WithdrawalGuard) {
+		g.confirmation = true
+		g.expiry = expiry
+	}
+}
+
+type confirmedWithdrawal struct{}
+
+// sentWithdrawal is a withdrawal which has been sent by the guard.
+type sentWithdrawal struct {
+	Withdrawal
+	deducted float64
+	at       time.Time
+}
+
+// matches reports whether withdrawal in the history can be the sent withdrawal, the response of a withdrawal
+// has no id to look it up by. The clocks of the exchange and the guard may differ by a minute.
+func (s sentWithdrawal) matches(withdrawal WithdrawalHistory) bool {
+	return s.Address == withdrawal.Address &&
+		s.PaymentId == withdrawal.PaymentId &&
+		util.ParseFloat(s.Amount) == util.ParseFloat(withdrawal.Amount) &&
+		withdrawal.Timestamp >= s.at.Add(-time.Minute).UnixMilli()
+}
+
+// WithdrawalGuard checks every withdrawal of a private client before it is sent (see: WithWithdrawalGuard):
+// withdrawals of the asset must be enabled, the amount must be at least the minimum withdrawal amount and cover the fee,
+// the address must be on the allow-list and the daily cap of the asset may not be exceeded. Funds sent can't be recovered,
+// so without an allow-list all withdrawals are rejected.
+//
+// Withdrawals can be sent in 2 steps: Prepare checks the withdrawal and Confirm sends it.
+//
+// A guard belongs to a single client, it is safe for concurrent use.
+type WithdrawalGuard struct {
+	client       PrivateAPI
+	addresses    []WithdrawalAddress
+	caps         map[string]float64
+	approve      func(ctx context.Context, request WithdrawalRequest) bool
+	confirmation bool
+	expiry       time.Duration
+	now          func() time.Time
+
+	// sends one withdrawal at a time, so the daily cap can't be exceeded together
+	send sync.Mutex
+
+	mu       sync.Mutex
+	prepared map[string]WithdrawalRequest
+	day      time.Time
+	sent     map[string][]sentWithdrawal
+}
+
+func NewWithdrawalGuard(options ...WithdrawalGuardOption) *WithdrawalGuard {
+	g := &WithdrawalGuard{
+		caps:     make(map[string]float64),
+		approve:  func(context.Context, WithdrawalRequest) bool { return true },
+		expiry:   time.Minute * 5,
+		now:      time.Now,
+		prepared: make(map[string]WithdrawalRequest),
+		sent:     make(map[string][]sentWithdrawal),
+	}
+
+	for _, opt := range options {
+		opt(g)
+	}
+
+	return g
+}
+
+// WithWithdrawalGuard checks every withdrawal with guard before it is sent. The guard runs after all middleware
+// (see: WithMiddleware) regardless of the order of the options, so it checks every attempt as it is sent.
+func WithWithdrawalGuard(guard *WithdrawalGuard) HttpOption {
+	return func(c *httpClient) {
+		guard.client = c
+		c.httpConfig.guard = guard.middleware
+	}
+}
+
+// Prepare checks a withdrawal without sending it, it can be sent with Confirm until it expires.
+func (g *WithdrawalGuard) Prepare(ctx context.Context, symbol string, amount string, address string, withdrawal Withdrawal) (WithdrawalRequest, error) {
+	if g.client == nil {
+		return WithdrawalRequest{}, ErrWithdrawalGuardNotEnabled
+	}
+
+	withdrawal.Symbol = symbol
+	withdrawal.Amount = amount
+	withdrawal.Address = address
+
+	request, err := g.check(ctx, withdrawal)
+	if err != nil {
+		return WithdrawalRequest{}, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return WithdrawalRequest{}, err
+	}
+	request.Id = hex.EncodeToString(id)
+	request.ExpiresAt = g.now().Add(g.expiry)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.expire()
+	g.prepared[request.Id] = request
+	return request, nil
+}
+
+// Confirm asks for approval (see: WithWithdrawalApproval) and sends a prepared withdrawal, the checks run again
+// right before it is sent. A prepared withdrawal can be confirmed once.
+func (g *WithdrawalGuard) Confirm(ctx context.Context, id string) (WithDrawalResponse, error) {
+	if g.client == nil {
+		return WithDrawalResponse{}, ErrWithdrawalGuardNotEnabled
+	}
+
+	g.mu.Lock()
+	g.expire()
+	request, ok := g.prepared[id]
+	g.mu.Unlock()
+	if !ok {
+		return WithDrawalResponse{}, ErrUnknownWithdrawal
+	}
+
+	if !g.approve(ctx, request) {
+		g.Discard(id)
+		return WithDrawalResponse{}, ErrWithdrawalNotApproved
+	}
+
+	w := request.Withdrawal
+	return g.client.Withdraw(context.WithValue(ctx, confirmedWithdrawal{}, id), w.Symbol, w.Amount, w.Address, w)
+}
+
+// Discard removes a prepared withdrawal, it returns false if it doesn't exist or expired.
+func (g *WithdrawalGuard) Discard(id string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.expire()
+	_, ok := g.prepared[id]
+	delete(g.prepared, id)
+	return ok
+}
+
+func (g *WithdrawalGuard) middleware(next Invoker) Invoker {
+	return func(ctx context.Context, call *Call) (any, error) {
+		if call.Endpoint != "Withdraw" && (call.Method != "POST" || call.Path != "/withdrawal") {
+			return next(ctx, call)
+		}
+
+		// check the body as it is marshalled and send exactly that
+		body := call.Body
+		withdrawal, payload, err := readWithdrawal(body)
+		if err != nil {
+			return nil, err
+		}
+		call.Body = payload
+		defer func() { call.Body = body }()
+
+		g.send.Lock()
+		defer g.send.Unlock()
+
+		id, confirmed := ctx.Value(confirmedWithdrawal{}).(string)
+		if confirmed {
+			g.mu.Lock()
+			g.expire()
+			prepared, ok := g.prepared[id]
+			delete(g.prepared, id)
+			g.mu.Unlock()
+
+			if !ok || prepared.Withdrawal != withdrawal {
+				return nil, ErrUnknownWithdrawal
+			}
+		} else if g.confirmation {
+			return nil, ErrWithdrawalNotConfirmed
+		}
+
+		request, err := g.check(ctx, withdrawal)
+		if err != nil {
+			return nil, err
+		}
+		if !confirmed && !g.approve(ctx, request) {
+			return nil, ErrWithdrawalNotApproved
+		}
+
+		result, err := next(ctx, call)
+		if err == nil {
+			g.mu.Lock()
+			g.rollover()
+			g.sent[withdrawal.Symbol] = append(g.sent[withdrawal.Symbol], sentWithdrawal{withdrawal, request.Deducted, g.now()})
+			g.mu.Unlock()
+		}
+		return result, err
+	}
+}
+
+// readWithdrawal marshals body and reads it back as a withdrawal, it fails if body has fields which a withdrawal doesn't have.
+func readWithdrawal(body any) (Withdrawal, json.RawMessage, error) {
+	if body == nil {
+		return Withdrawal{}, nil, ErrWithdrawalUnreadable
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return Withdrawal{}, nil, errors.Join(ErrWithdrawalUnreadable, err)
+	}
+
+	var withdrawal Withdrawal
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&withdrawal); err != nil {
+		return Withdrawal{}, nil, errors.Join(ErrWithdrawalUnreadable, err)
+	}
+	return withdrawal, payload, nil
+}
+
+// check returns the request of withdrawal if it passes all checks.
+func (g *WithdrawalGuard) check(ctx context.Context, withdrawal Withdrawal) (WithdrawalRequest, error) {
+	asset, err := g.client.GetAsset(ctx, withdrawal.Symbol)
+	if err != nil {
+		return WithdrawalRequest{}, err
+	}
+	if asset.WithdrawalStatus != WithdrawalStatusTrading {
+		return WithdrawalRequest{}, ErrWithdrawalsDisabled
+	}
+
+	amount := util.ParseFloat(withdrawal.Amount)
+	if amount <= 0 || amount < util.ParseFloat(asset.WithdrawalMinAmount) {
+		return WithdrawalRequest{}, ErrWithdrawalBelowMinimum
+	}
+
+	fee := util.IfOrElse(withdrawal.Internal, func() float64 { return 0 }, util.ParseFloat(asset.WithdrawalFee))
+	request := WithdrawalRequest{
+		Withdrawal: withdrawal,
+		Fee:        fee,
+		Deducted:   util.IfOrElse(withdrawal.AddWithdrawalFee, func() float64 { return amount + fee }, amount),
+		Received:   util.IfOrElse(withdrawal.AddWithdrawalFee, func() float64 { return amount }, amount-fee),
+	}
+	if request.Received <= 0 {
+		return WithdrawalRequest{}, ErrWithdrawalFeeNotCovered
+	}
+
+	index := slices.IndexFunc(g.addresses, func(a WithdrawalAddress) bool {
+		return a.Symbol == withdrawal.Symbol && a.Address == withdrawal.Address && a.PaymentId == withdrawal.PaymentId
+	})
+	if index < 0 {
+		return WithdrawalRequest{}, ErrAddressNotAllowed
+	}
+	request.Network = g.addresses[index].Network
+	if request.Network != "" && !slices.Contains(asset.Networks, request.Network) {
+		return WithdrawalRequest{}, ErrNetworkNotSupported
+	}
+
+	if limit, ok := g.caps[withdrawal.Symbol]; ok {
+		withdrawn, err := g.withdrawn(ctx, withdrawal.Symbol)
+		if err != nil {
+			return WithdrawalRequest{}, err
+		}
+		if withdrawn+request.Deducted > limit {
+			return WithdrawalRequest{}, ErrWithdrawalDailyCap
+		}
+	}
+
+	return request, nil
+}
+
+// withdrawn returns the amount of symbol which has been withdrawn today, the withdrawal history of the account
+// also has the withdrawals of other clients and the guard knows the withdrawals which are not in the history yet.
+func (g *WithdrawalGuard) withdrawn(ctx context.Context, symbol string) (float64, error) {
+	g.mu.Lock()
+	g.rollover()
+	day, sent := g.day, slices.Clone(g.sent[symbol])
+	g.mu.Unlock()
+
+	history, err := g.client.GetWithdrawalHistory(ctx, &WithdrawalHistoryParams{Symbol: symbol, Start: day})
+	if err != nil {
+		return 0, err
+	}
+
+	withdrawn := 0.0
+	inHistory := make([]bool, len(sent))
+	for _, withdrawal := range history {
+		for i := range sent {
+			if !inHistory[i] && sent[i].matches(withdrawal) {
+				inHistory[i] = true
+				break
+			}
+		}
+		if withdrawal.Status != WithdrawalHistoryStatusCanceled {
+			withdrawn += util.ParseFloat(withdrawal.Amount)
+		}
+	}
+	for i, withdrawal := range sent {
+		if !inHistory[i] {
+			withdrawn += withdrawal.deducted
+		}
+	}
+	return withdrawn, nil
+}
+
+// expire removes the prepared withdrawals which expired.
+func (g *WithdrawalGuard) expire() {
+	now := g.now()
+	for id, request := range g.prepared {
+		if now.After(request.ExpiresAt) {
+			delete(g.prepared, id)
+		}
+	}
+}
+
+// rollover forgets the sent withdrawals when a new day (UTC) started.
+func (g *WithdrawalGuard) rollover() {
+	day := g.now().UTC().Truncate(24 * time.Hour)
+	if !day.Equal(g.day) {
+		g.day = day
+		clear(g.sent)
+	}
+}
@@ -0,0 +1,182 @@
+package bitvavo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+)
+
+const allowedAddress = "bc1qallowed"
+
+// newGuardedClient returns a client with guard which withdraws BTC with a fee of 0.0001, 0.3 BTC has been withdrawn today.
+func newGuardedClient(guard *WithdrawalGuard, status *string, sent *int, options ...HttpOption) PrivateAPI {
+	transport := roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		switch request.URL.Path {
+		case "/v2/assets":
+			return respond(http.StatusOK, `{"symbol":"BTC","depositStatus":"OK","withdrawalStatus":"`+*status+`","withdrawalFee":"0.0001","withdrawalMinAmount":"0.001","networks":["BTC"]}`), nil
+		case "/v2/withdrawalHistory":
+			return respond(http.StatusOK, `[{"symbol":"BTC","amount":"0.3","status":"completed"},{"symbol":"BTC","amount":"5","status":"canceled"}]`), nil
+		}
+		*sent++
+		return respond(http.StatusOK, `{"success":true,"symbol":"BTC","amount":"0.1"}`), nil
+	})
+	return NewPrivateHTTPClient("key", "secret", append([]HttpOption{WithHttpClient(&http.Client{Transport: transport}), WithWithdrawalGuard(guard)}, options...)...)
+}
+
+func TestWithdrawalGuard(t *testing.T) {
+	var (
+		status = "OK"
+		sent   = 0
+		guard  = NewWithdrawalGuard(
+			WithAllowedAddresses(WithdrawalAddress{Symbol: "BTC", Network: "BTC", Address: allowedAddress}),
+			WithWithdrawalDailyCap("BTC", 1),
+		)
+		client = newGuardedClient(guard, &status, &sent)
+		ctx    = context.Background()
+	)
+
+	_, err := client.Withdraw(ctx, "BTC", "0.1", "bc1qother", Withdrawal{})
+	test.AssertEqual(t, ErrAddressNotAllowed, err)
+	_, err = client.Withdraw(ctx, "BTC", "0.1", allowedAddress, Withdrawal{PaymentId: "memo"})
+	test.AssertEqual(t, ErrAddressNotAllowed, err)
+	_, err = client.Withdraw(ctx, "BTC", "0.0005", allowedAddress, Withdrawal{})
+	test.AssertEqual(t, ErrWithdrawalBelowMinimum, err)
+
+	// 0.3 + 0.8
+	_, err = client.Withdraw(ctx, "BTC", "0.8", allowedAddress, Withdrawal{})
+	test.AssertEqual(t, ErrWithdrawalDailyCap, err)
+
+	_, err = client.Withdraw(ctx, "BTC", "0.4", allowedAddress, Withdrawal{})
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, 1, sent)
+
+	// 0.4 was sent by the guard, it's not in the history yet
+	_, err = client.Withdraw(ctx, "BTC", "0.6", allowedAddress, Withdrawal{AddWithdrawalFee: true})
+	test.AssertEqual(t, ErrWithdrawalDailyCap, err)
+
+	status = "MAINTENANCE"
+	_, err = client.Withdraw(ctx, "BTC", "0.1", allowedAddress, Withdrawal{})
+	test.AssertEqual(t, ErrWithdrawalsDisabled, err)
+	test.AssertEqual(t, 1, sent)
+}
+
+func TestWithdrawalGuardHistoryAndSent(t *testing.T) {
+	var (
+		sent      = 0
+		history   = `{"symbol":"BTC","amount":"5","address":"bc1qelsewhere","status":"completed"}`
+		transport = roundTripFunc(func(request *http.Request) (*http.Response, error) {
+			switch request.URL.Path {
+			case "/v2/assets":
+				return respond(http.StatusOK, `{"symbol":"BTC","depositStatus":"OK","withdrawalStatus":"OK","withdrawalFee":"0.0001","withdrawalMinAmount":"0.001","networks":["BTC"]}`), nil
+			case "/v2/withdrawalHistory":
+				return respond(http.StatusOK, "["+history+"]"), nil
+			}
+			sent++
+			return respond(http.StatusOK, `{"success":true,"symbol":"BTC","amount":"1"}`), nil
+		})
+		guard = NewWithdrawalGuard(
+			WithAllowedAddresses(WithdrawalAddress{Symbol: "BTC", Address: allowedAddress}),
+			WithWithdrawalDailyCap("BTC", 10),
+		)
+		client = NewPrivateHTTPClient("key", "secret", WithHttpClient(&http.Client{Transport: transport}), WithWithdrawalGuard(guard))
+		ctx    = context.Background()
+	)
+
+	for _, amount := range []string{"1", "2"} {
+		_, err := client.Withdraw(ctx, "BTC", amount, allowedAddress, Withdrawal{})
+		test.AssertEqual(t, nil, err)
+	}
+	test.AssertEqual(t, 2, sent)
+
+	// the first withdrawal of the guard is in the history, the second is not: 5 + 1 + 2
+	history += fmt.Sprintf(`,{"timestamp":%d,"symbol":"BTC","amount":"1","address":"%s","status":"awaiting_processing"}`, time.Now().UnixMilli(), allowedAddress)
+	_, err := client.Withdraw(ctx, "BTC", "2.5", allowedAddress, Withdrawal{})
+	test.AssertEqual(t, ErrWithdrawalDailyCap, err)
+	_, err = client.Withdraw(ctx, "BTC", "2", allowedAddress, Withdrawal{})
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, 3, sent)
+}
+
+func TestWithdrawalGuardChecksTheSentBody(t *testing.T) {
+	var (
+		status = "OK"
+		sent   = 0
+		guard  = NewWithdrawalGuard(WithAllowedAddresses(WithdrawalAddress{Symbol: "BTC", Address: allowedAddress}))
+		body   any
+		client = newGuardedClient(guard, &status, &sent, WithMiddleware(func(next Invoker) Invoker {
+			return func(ctx context.Context, call *Call) (any, error) {
+				if body != nil {
+					call.Body = body
+				}
+				return next(ctx, call)
+			}
+		}))
+		ctx = context.Background()
+	)
+
+	// the middleware is added after the guard, it runs before it
+	body = Withdrawal{Symbol: "BTC", Amount: "0.1", Address: "bc1qother"}
+	_, err := client.Withdraw(ctx, "BTC", "0.1", allowedAddress, Withdrawal{})
+	test.AssertEqual(t, ErrAddressNotAllowed, err)
+
+	body = map[string]any{"symbol": "BTC", "amount": "0.1", "address": allowedAddress, "destination": "bc1qother"}
+	_, err = client.Withdraw(ctx, "BTC", "0.1", allowedAddress, Withdrawal{})
+	test.AssertEqual(t, true, errors.Is(err, ErrWithdrawalUnreadable))
+
+	body = &Withdrawal{Symbol: "BTC", Amount: "0.1", Address: allowedAddress}
+	_, err = client.Withdraw(ctx, "BTC", "0.1", allowedAddress, Withdrawal{})
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, 1, sent)
+}
+
+func TestWithdrawalGuardConfirmation(t *testing.T) {
+	var (
+		status   = "OK"
+		sent     = 0
+		approved = false
+		guard    = NewWithdrawalGuard(
+			WithAllowedAddresses(WithdrawalAddress{Symbol: "BTC", Address: allowedAddress}),
+			WithWithdrawalConfirmation(time.Minute),
+			WithWithdrawalApproval(func(_ context.Context, request WithdrawalRequest) bool { return approved }),
+		)
+		client = newGuardedClient(guard, &status, &sent)
+		ctx    = context.Background()
+		now    = time.Now()
+	)
+	guard.now = func() time.Time { return now }
+
+	_, err := NewWithdrawalGuard().Prepare(ctx, "BTC", "0.1", allowedAddress, Withdrawal{})
+	test.AssertEqual(t, ErrWithdrawalGuardNotEnabled, err)
+	_, err = client.Withdraw(ctx, "BTC", "0.1", allowedAddress, Withdrawal{})
+	test.AssertEqual(t, ErrWithdrawalNotConfirmed, err)
+
+	request, err := guard.Prepare(ctx, "BTC", "0.1", allowedAddress, Withdrawal{})
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, 0.0001, request.Fee)
+	test.AssertEqual(t, 0.0999, request.Received)
+
+	_, err = guard.Confirm(ctx, request.Id)
+	test.AssertEqual(t, ErrWithdrawalNotApproved, err)
+	_, err = guard.Confirm(ctx, request.Id)
+	test.AssertEqual(t, ErrUnknownWithdrawal, err)
+
+	approved = true
+	request, _ = guard.Prepare(ctx, "BTC", "0.1", allowedAddress, Withdrawal{})
+	response, err := guard.Confirm(ctx, request.Id)
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, true, response.Success)
+	test.AssertEqual(t, 1, sent)
+	_, err = guard.Confirm(ctx, request.Id)
+	test.AssertEqual(t, ErrUnknownWithdrawal, err)
+
+	request, _ = guard.Prepare(ctx, "BTC", "0.1", allowedAddress, Withdrawal{})
+	now = now.Add(time.Minute + time.Second)
+	_, err = guard.Confirm(ctx, request.Id)
+	test.AssertEqual(t, ErrUnknownWithdrawal, err)
+	test.AssertEqual(t, 1, sent)
+}
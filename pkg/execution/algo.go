@@ -0,0 +1,413 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+var (
+	ErrInvalidAlgoOrder = errors.New("an algo order needs an amount and a duration")
+	ErrPostOnlyPrice    = errors.New("post only slices need a limit price or a client which implements GetTickerBook")
+	ErrAlgoStarted      = errors.New("algo has already been started")
+	ErrInvalidProfile   = func(exp, act int) error {
+		return fmt.Errorf("expected '%d' weights for the volume profile, but was: %d", exp, act)
+	}
+)
+
+const candlesLimit = 1440
+
+// AlgoOrder is a parent order which is executed in slices over a time window.
+type AlgoOrder struct {
+	Market string
+	Side   bitvavo.Side
+
+	// The total amount in base currency.
+	Amount float64
+
+	// The time window, it starts when the algo runs.
+	Duration time.Duration
+
+	// The number of slices.
+	//
+	// Default: one slice per minute
+	Slices int
+
+	// The limit price cap, slices never execute at a worse price. 0 for market orders.
+	Limit float64
+
+	// Place the slices as maker only, at the best bid (buy) or best ask (sell) capped by Limit.
+	PostOnly bool
+}
+
+// Progress is the state of an algo.
+type Progress struct {
+	Market string
+	Side   bitvavo.Side
+
+	// The total amount in base currency.
+	Amount float64
+
+	// The amount which has been filled, in base and quote currency.
+	Filled      float64
+	FilledQuote float64
+
+	// The number of slices which have been placed and the total number of slices.
+	Slice  int
+	Slices int
+
+	Paused bool
+
+	// True when the time window ended or the amount has been filled.
+	Done bool
+}
+
+// AveragePrice returns the average price of the filled amount.
+func (p Progress) AveragePrice() float64 {
+	return util.IfOrElse(p.Filled > 0, func() float64 { return p.FilledQuote / p.Filled }, 0)
+}
+
+type ProgressEvent bitvavo.ListenerEvent[Progress]
+
+// Candles is the part of bitvavo.PublicAPI needed for a volume profile.
+type Candles interface {
+	GetCandles(ctx context.Context, market string, interval bitvavo.Interval, params ...bitvavo.Params) ([]bitvavo.CandleOnly, error)
+}
+
+// tickerBook is implemented by the HTTP clients and the PaperClient.
+type tickerBook interface {
+	GetTickerBook(ctx context.Context, market string) (bitvavo.TickerBook, error)
+}
+
+// Algo executes an AlgoOrder in slices: each slice is placed at the start of its part of the time window and
+// canceled at the end of it, the amount which did not fill is added to the next slice.
+type Algo struct {
+	client  Client
+	rules   Rules
+	order   AlgoOrder
+	weights []float64
+
+	mu       sync.Mutex
+	started  bool
+	progress Progress
+	signal   chan struct{}
+}
+
+// NewTWAP creates an algo which executes order in equal slices (time weighted average price).
+func NewTWAP(client Client, rules Rules, order AlgoOrder) (*Algo, error) {
+	order.Slices = slicesOf(order)
+	weights := make([]float64, order.Slices)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return newAlgo(client, rules, order, weights)
+}
+
+// NewVWAP creates an algo which executes order in slices sized by profile (volume weighted average price), see: VolumeProfile.
+func NewVWAP(client Client, rules Rules, order AlgoOrder, profile []float64) (*Algo, error) {
+	order.Slices = slicesOf(order)
+	if len(profile) != order.Slices {
+		return nil, ErrInvalidProfile(order.Slices, len(profile))
+	}
+	return newAlgo(client, rules, order, profile)
+}
+
+func newAlgo(client Client, rules Rules, order AlgoOrder, weights []float64) (*Algo, error) {
+	if order.Amount <= 0 || order.Duration <= 0 {
+		return nil, ErrInvalidAlgoOrder
+	}
+	if _, ok := client.(tickerBook); order.PostOnly && order.Limit <= 0 && !ok {
+		return nil, ErrPostOnlyPrice
+	}
+
+	return &Algo{
+		client:  client,
+		rules:   rules,
+		order:   order,
+		weights: weights,
+		signal:  make(chan struct{}, 1),
+		progress: Progress{
+			Market: order.Market,
+			Side:   order.Side,
+			Amount: order.Amount,
+			Slices: order.Slices,
+		},
+	}, nil
+}
+
+// VolumeProfile returns the relative volume of each slice of order when it would start at start,
+// based on the volume at the same time of day over the last days.
+func VolumeProfile(ctx context.Context, candles Candles, order AlgoOrder, start time.Time, days int) ([]float64, error) {
+	var (
+		slices   = slicesOf(order)
+		step     = order.Duration / time.Duration(slices)
+		interval = intervalOf(step)
+		from     = start.Add(-time.Duration(days) * 24 * time.Hour)
+	)
+
+	history, err := util.PageBackwards(from, start, candlesLimit, func(end time.Time) ([]bitvavo.CandleOnly, error) {
+		return candles.GetCandles(ctx, order.Market, interval, &bitvavo.CandleParams{Limit: candlesLimit, Start: from, End: end})
+	}, func(c bitvavo.CandleOnly) (int64, string) { return c.Timestamp, fmt.Sprint(c.Timestamp) })
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		profile = make([]float64, slices)
+		total   float64
+	)
+	for _, candle := range history {
+		// from is at the same time of day as start
+		offset := time.UnixMilli(candle.Timestamp).Sub(from) % (24 * time.Hour)
+		if i := int(offset / step); offset >= 0 && i < slices {
+			volume := util.ParseFloat(candle.Volume)
+			profile[i] += volume
+			total += volume
+		}
+	}
+
+	for i := range profile {
+		profile[i] = util.IfOrElse(total > 0, func() float64 { return profile[i] / total }, 1/float64(slices))
+	}
+	return profile, nil
+}
+
+// Progress returns the current progress.
+func (a *Algo) Progress() Progress {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.progress
+}
+
+// Pause cancels the open slice and stops placing slices until Resume, the amount of the skipped slices is added to the
+// first slice after Resume.
+func (a *Algo) Pause() {
+	a.setPaused(true)
+}
+
+// Resume continues placing slices.
+func (a *Algo) Resume() {
+	a.setPaused(false)
+}
+
+// Run starts the algo and emits the progress after every slice. The open slice is canceled when ctx is done,
+// after which the error of ctx is emitted. The returned channel is closed when the algo stops.
+func (a *Algo) Run(ctx context.Context) <-chan ProgressEvent {
+	chn := make(chan ProgressEvent, 1)
+
+	a.mu.Lock()
+	started := a.started
+	a.started = true
+	a.mu.Unlock()
+
+	if started {
+		chn <- ProgressEvent{Error: ErrAlgoStarted}
+		close(chn)
+		return chn
+	}
+
+	go func() {
+		defer close(chn)
+
+		var (
+			start = time.Now()
+			step  = a.order.Duration / time.Duration(a.order.Slices)
+			slice bitvavo.Order
+		)
+		emit := func(err error) {
+			chn <- util.IfOrElse(err != nil, func() ProgressEvent { return ProgressEvent{Error: err} }, ProgressEvent{Value: a.Progress()})
+		}
+
+		for i := 0; ; {
+			if a.Progress().Paused && ctx.Err() == nil {
+				emit(a.finish(ctx, &slice))
+				select {
+				case <-ctx.Done():
+				case <-a.signal:
+				}
+				continue
+			}
+
+			timer := time.NewTimer(time.Until(start.Add(step * time.Duration(i))))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				if err := a.finish(context.WithoutCancel(ctx), &slice); err != nil {
+					emit(err)
+				}
+				emit(ctx.Err())
+				return
+			case <-a.signal:
+				timer.Stop()
+				continue
+			case <-timer.C:
+			}
+
+			if err := a.finish(ctx, &slice); err != nil {
+				emit(err)
+			}
+			if i == a.order.Slices || a.Progress().Filled >= a.order.Amount {
+				break
+			}
+			// skip the slices which passed while paused, their amount is added to this slice
+			if i+1 < a.order.Slices && time.Now().After(start.Add(step*time.Duration(i+1))) {
+				i++
+				continue
+			}
+
+			var err error
+			slice, err = a.place(ctx, i)
+			i++
+			emit(err)
+		}
+
+		a.mu.Lock()
+		a.progress.Done = true
+		a.mu.Unlock()
+		emit(nil)
+	}()
+
+	return chn
+}
+
+func (a *Algo) setPaused(paused bool) {
+	a.mu.Lock()
+	a.progress.Paused = paused
+	a.mu.Unlock()
+
+	select {
+	case a.signal <- struct{}{}:
+	default:
+	}
+}
+
+// place places slice i for the amount which is behind schedule.
+func (a *Algo) place(ctx context.Context, i int) (bitvavo.Order, error) {
+	rules, err := a.rules.Rules(a.order.Market)
+	if err != nil {
+		return bitvavo.Order{}, err
+	}
+
+	var target, total float64
+	for j, weight := range a.weights {
+		total += weight
+		if j <= i {
+			target += weight
+		}
+	}
+
+	var (
+		progress  = a.Progress()
+		amount    = rules.FloorAmount(a.order.Amount*target/total - progress.Filled)
+		orderType = bitvavo.OrderTypeMarket
+		order     = bitvavo.OrderNew{Amount: amount, PostOnly: a.order.PostOnly}
+		price     = a.order.Limit
+	)
+	if a.order.PostOnly {
+		if price, err = a.price(ctx); err != nil {
+			return bitvavo.Order{}, err
+		}
+	}
+	if price > 0 {
+		orderType, order.Price = bitvavo.OrderTypeLimit, rules.RoundPrice(price)
+	}
+
+	a.mu.Lock()
+	a.progress.Slice = i + 1
+	a.mu.Unlock()
+
+	if err := rules.Validate(util.ParseFloat(amount), util.IfOrElse(price > 0, func() float64 { return price }, progress.AveragePrice())); err != nil {
+		if util.ParseFloat(amount) <= 0 || errors.Is(err, bitvavo.ErrAmountBelowMinimum) || errors.Is(err, bitvavo.ErrNotionalBelowMinimum) {
+			// too small, it's added to the next slice
+			return bitvavo.Order{}, nil
+		}
+		return bitvavo.Order{}, err
+	}
+	return a.client.NewOrder(ctx, a.order.Market, a.order.Side, orderType, order)
+}
+
+// price returns the best price on the own side of the book, capped by the limit.
+func (a *Algo) price(ctx context.Context) (float64, error) {
+	client, ok := a.client.(tickerBook)
+	if !ok {
+		return a.order.Limit, nil
+	}
+	book, err := client.GetTickerBook(ctx, a.order.Market)
+	if err != nil {
+		return 0, err
+	}
+
+	price := util.ParseFloat(util.IfOrElse(a.order.Side == bitvavo.SideBuy, func() string { return book.Bid }, book.Ask))
+	if a.order.Limit > 0 {
+		price = util.IfOrElse(a.order.Side == bitvavo.SideBuy && price > 0, func() float64 { return min(price, a.order.Limit) }, max(price, a.order.Limit))
+	}
+	if price <= 0 {
+		return 0, ErrPostOnlyPrice
+	}
+	return price, nil
+}
+
+// finish cancels the slice if it's still open and adds its fills to the progress.
+func (a *Algo) finish(ctx context.Context, slice *bitvavo.Order) error {
+	if slice.OrderId == "" {
+		return nil
+	}
+
+	order := *slice
+	if isOpen(order) {
+		if _, err := a.client.CancelOrder(ctx, order.Market, order.OrderId); err != nil {
+			return err
+		}
+		var err error
+		if order, err = a.client.GetOrder(ctx, order.Market, order.OrderId); err != nil {
+			return err
+		}
+	}
+	*slice = bitvavo.Order{}
+
+	filled := filledOf(order)
+	filledQuote := util.ParseFloat(order.FilledAmountQuote)
+	if filledQuote == 0 {
+		filledQuote = filled * util.ParseFloat(order.Price)
+	}
+
+	a.mu.Lock()
+	a.progress.Filled += filled
+	a.progress.FilledQuote += filledQuote
+	a.mu.Unlock()
+
+	return nil
+}
+
+func slicesOf(order AlgoOrder) int {
+	if order.Slices > 0 {
+		return order.Slices
+	}
+	return max(int(order.Duration/time.Minute), 1)
+}
+
+var intervals = []struct {
+	interval bitvavo.Interval
+	duration time.Duration
+}{
+	{bitvavo.Interval1h, time.Hour},
+	{bitvavo.Interval30m, 30 * time.Minute},
+	{bitvavo.Interval15m, 15 * time.Minute},
+	{bitvavo.Interval5m, 5 * time.Minute},
+}
+
+// intervalOf returns the largest candle interval which fits in step.
+func intervalOf(step time.Duration) bitvavo.Interval {
+	for _, i := range intervals {
+		if i.duration <= step {
+			return i.interval
+		}
+	}
+	return bitvavo.Interval1m
+}
@@ -0,0 +1,97 @@
+package execution
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+type candlesAPI []bitvavo.CandleOnly
+
+func (c candlesAPI) GetCandles(_ context.Context, _ string, _ bitvavo.Interval, _ ...bitvavo.Params) ([]bitvavo.CandleOnly, error) {
+	return c, nil
+}
+
+func TestTWAP(t *testing.T) {
+	ctx := context.Background()
+	client := newPaperClient(bitvavo.WithPaperBalance("EUR", "1000"))
+
+	algo, err := NewTWAP(client, rules{}, AlgoOrder{Market: "ETH-EUR", Side: bitvavo.SideBuy, Amount: 3, Duration: 90 * time.Millisecond, Slices: 3, Limit: 101})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var progress Progress
+	for event := range algo.Run(ctx) {
+		if event.Error != nil {
+			t.Fatal(event.Error)
+		}
+		progress = event.Value
+	}
+
+	test.AssertEqual(t, true, progress.Done)
+	test.AssertEqual(t, 3, progress.Slice)
+	test.AssertEqual(t, 3.0, progress.Filled)
+	test.AssertEqual(t, 101.0, progress.AveragePrice())
+
+	event := <-algo.Run(ctx)
+	test.AssertEqual(t, ErrAlgoStarted, event.Error)
+}
+
+func TestTWAPPauseAndCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := newPaperClient(bitvavo.WithPaperBalance("EUR", "1000"))
+
+	algo, err := NewTWAP(client, rules{}, AlgoOrder{Market: "ETH-EUR", Side: bitvavo.SideBuy, Amount: 2, Duration: time.Hour, Slices: 2, PostOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := algo.Run(ctx)
+
+	// the first slice joins the best bid
+	event := waitFor(t, events, func(e ProgressEvent) bool { return e.Value.Slice == 1 })
+	test.AssertEqual(t, 0.0, event.Value.Filled)
+	open, _ := client.GetOrdersOpen(ctx, "ETH-EUR")
+	test.AssertEqual(t, 1, len(open))
+	test.AssertEqual(t, "100.00", open[0].Price)
+	test.AssertEqual(t, "1.00000000", open[0].Amount)
+	test.AssertEqual(t, true, open[0].PostOnly)
+
+	algo.Pause()
+	waitFor(t, events, func(e ProgressEvent) bool { return e.Value.Paused })
+	open, _ = client.GetOrdersOpen(ctx, "ETH-EUR")
+	test.AssertEqual(t, 0, len(open))
+
+	algo.Resume()
+	cancel()
+	event = waitFor(t, events, func(e ProgressEvent) bool { return e.Error != nil })
+	test.AssertEqual(t, context.Canceled, event.Error)
+	if _, ok := <-events; ok {
+		t.Fatal("expected closed channel")
+	}
+}
+
+func TestVolumeProfile(t *testing.T) {
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := start.Add(-24 * time.Hour)
+	candles := candlesAPI{
+		{Timestamp: day.Add(10 * time.Minute).UnixMilli(), Volume: "1"},
+		{Timestamp: day.Add(70 * time.Minute).UnixMilli(), Volume: "3"},
+		{Timestamp: day.Add(130 * time.Minute).UnixMilli(), Volume: "100"},
+	}
+	order := AlgoOrder{Market: "ETH-EUR", Side: bitvavo.SideBuy, Amount: 1, Duration: 2 * time.Hour, Slices: 2}
+
+	profile, err := VolumeProfile(context.Background(), candles, order, start, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, 2, len(profile))
+	test.AssertEqual(t, 0.25, profile[0])
+	test.AssertEqual(t, 0.75, profile[1])
+
+	_, err = NewVWAP(newPaperClient(), rules{}, order, profile[:1])
+	test.AssertEqual(t, ErrInvalidProfile(2, 1).Error(), err.Error())
+}
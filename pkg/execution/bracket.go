@@ -0,0 +1,655 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+var (
+	ErrProtectionRequired = errors.New("a bracket needs a stop loss and a take profit trigger")
+	ErrUnknownBracket     = errors.New("unknown bracket")
+)
+
+// Rules returns the precision and order size rules of a market, see: bitvavo.MarketRegistry
+type Rules interface {
+	Rules(market string) (bitvavo.MarketRules, error)
+}
+
+// Protection is a stop loss or take profit of a bracket.
+type Protection struct {
+	// The price at which the order triggers.
+	Trigger float64
+
+	// The limit price once triggered, 0 for a market order.
+	Limit float64
+
+	// The price the trigger is compared with.
+	//
+	// Default: OrderTriggerRefLastTrade
+	Reference bitvavo.OrderTriggerRef
+}
+
+// BracketOrder is an entry order with a stop loss and take profit which are placed once the entry fills.
+type BracketOrder struct {
+	Market string
+	Side   bitvavo.Side
+
+	// OrderTypeMarket or OrderTypeLimit
+	OrderType bitvavo.OrderType
+	Entry     bitvavo.OrderNew
+
+	StopLoss   Protection
+	TakeProfit Protection
+}
+
+// Bracket is the state of a bracket order, it is saved to the BracketStore on every change.
+type Bracket struct {
+	// The order id of the entry.
+	Id     string
+	Market string
+	Side   bitvavo.Side
+
+	StopLoss   Protection
+	TakeProfit Protection
+
+	// The amount of the entry that has been executed, according to the fills.
+	Executed float64
+
+	// The amount received (Executed minus fees paid in base currency).
+	Filled float64
+
+	// The amount covered by stop loss and take profit orders.
+	Protected float64
+
+	// The fill ids of the entry which have been applied.
+	Fills []string
+
+	// The order ids per group (see: OrderGroup): the stop loss, followed by the take profit once it's hit.
+	Groups [][]string
+
+	// The stop loss which is being placed or grown, it's saved before the order is sent so Recover can tell whether it arrived.
+	Pending PendingProtection
+
+	// The filled amount of the entry once it reached a final status, -1 while it's open.
+	EntryFilled float64
+
+	// True when the entry reached a final status, all its fills have been applied and all groups are done.
+	Done bool
+}
+
+// PendingProtection is a stop loss which is being placed or grown, the zero value is none.
+type PendingProtection struct {
+	// The amount which is added to Protected once the order is on the exchange.
+	Amount float64
+
+	// The stop loss which is grown to Total, empty when a new group is placed.
+	OrderId string
+	Total   string
+}
+
+type BracketEvent bitvavo.ListenerEvent[Bracket]
+
+// BracketStore saves brackets, so they can be recovered after a restart (see: FileStore).
+type BracketStore interface {
+	Save(bracket Bracket) error
+
+	// Load returns all brackets which are not done.
+	Load() ([]Bracket, error)
+}
+
+type BracketOption func(*Brackets)
+
+// WithBracketStore saves every change to store.
+func WithBracketStore(store BracketStore) BracketOption {
+	return func(b *Brackets) {
+		b.store = store
+	}
+}
+
+// WithBracketOrderGroup places the stop loss and take profit in groups, use it to share the groups with the rest of the application.
+//
+// Default: NewOrderGroup(client)
+func WithBracketOrderGroup(groups *OrderGroup) BracketOption {
+	return func(b *Brackets) {
+		b.groups = groups
+	}
+}
+
+// Brackets manages bracket orders: once a fill of the entry is confirmed, a stop loss and take profit are placed
+// (as OCO, see: OrderGroup) for the amount that has actually been filled, rounded to the precision of the market.
+// Following fills grow the stop loss and take profit, or add a new pair if they already (partially) executed.
+//
+// Only the stop loss is placed on the exchange, so the filled amount is held once. The take profit is a local leg
+// which follows the ticker, once it's hit the stop loss is canceled and the take profit is placed.
+//
+// Fill, order and ticker events must be fed with Listen, or UpdateFill, UpdateOrder and UpdateTicker.
+// It is safe for concurrent use.
+type Brackets struct {
+	client Client
+	rules  Rules
+	groups *OrderGroup
+	store  BracketStore
+
+	mu       sync.Mutex
+	brackets map[string]*Bracket
+}
+
+func NewBrackets(client Client, rules Rules, options ...BracketOption) *Brackets {
+	b := &Brackets{
+		client:   client,
+		rules:    rules,
+		brackets: make(map[string]*Bracket),
+	}
+
+	for _, opt := range options {
+		opt(b)
+	}
+	if b.groups == nil {
+		b.groups = NewOrderGroup(client)
+	}
+
+	return b
+}
+
+// Submit places the entry order.
+func (b *Brackets) Submit(ctx context.Context, order BracketOrder) (Bracket, error) {
+	if order.StopLoss.Trigger <= 0 || order.TakeProfit.Trigger <= 0 {
+		return Bracket{}, ErrProtectionRequired
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, err := b.client.NewOrder(ctx, order.Market, order.Side, order.OrderType, order.Entry)
+	if err != nil {
+		return Bracket{}, err
+	}
+
+	bracket := &Bracket{
+		Id:          entry.OrderId,
+		Market:      order.Market,
+		Side:        order.Side,
+		StopLoss:    order.StopLoss,
+		TakeProfit:  order.TakeProfit,
+		EntryFilled: -1,
+	}
+	b.brackets[bracket.Id] = bracket
+
+	// market orders (and crossing limit orders) return their fills right away
+	err = b.entry(ctx, bracket, entry)
+	return *copyOfBracket(bracket), errors.Join(err, b.save(bracket))
+}
+
+// Recover loads the brackets from the store and applies the fills and order changes that were missed.
+func (b *Brackets) Recover(ctx context.Context) ([]Bracket, error) {
+	if b.store == nil {
+		return nil, nil
+	}
+	brackets, err := b.store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i := range brackets {
+		bracket := copyOfBracket(&brackets[i])
+		b.brackets[bracket.Id] = bracket
+
+		rules, err := b.rules.Rules(bracket.Market)
+		if err != nil {
+			return nil, err
+		}
+		for _, orderIds := range bracket.Groups {
+			group := Group{Id: orderIds[0], Market: bracket.Market, Side: opposite(bracket.Side), Triggered: len(orderIds) > 1}
+			for _, orderId := range orderIds {
+				order, err := b.client.GetOrder(ctx, bracket.Market, orderId)
+				if err != nil {
+					return nil, err
+				}
+				group.Orders = append(group.Orders, order)
+			}
+			if !group.Triggered {
+				group.Local = []Leg{takeProfitLeg(rules, bracket, util.ParseFloat(group.Orders[0].Amount))}
+			}
+			b.groups.Track(group)
+		}
+	}
+
+	if _, err := b.groups.Reconcile(ctx); err != nil {
+		return nil, err
+	}
+
+	recovered := make([]Bracket, 0, len(brackets))
+	for _, bracket := range brackets {
+		current := b.brackets[bracket.Id]
+		if err := b.resolve(ctx, current); err != nil {
+			return nil, err
+		}
+		if err := b.protect(ctx, current); err != nil {
+			return nil, err
+		}
+		if current.EntryFilled < 0 || current.Executed < current.EntryFilled {
+			entry, err := b.client.GetOrder(ctx, bracket.Market, bracket.Id)
+			if err != nil {
+				return nil, err
+			}
+			if err := b.entry(ctx, current, entry); err != nil {
+				return nil, err
+			}
+		}
+		b.settle(current)
+		if err := b.save(current); err != nil {
+			return nil, err
+		}
+		recovered = append(recovered, *copyOfBracket(current))
+	}
+
+	return recovered, nil
+}
+
+// UpdateFill applies a fill of an entry, it returns false if the fill is not of an entry.
+func (b *Brackets) UpdateFill(ctx context.Context, fill bitvavo.Fill) (Bracket, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bracket, ok := b.brackets[fill.OrderId]
+	if !ok {
+		return Bracket{}, false, nil
+	}
+
+	err := b.fill(ctx, bracket, fill)
+	b.settle(bracket)
+	return *copyOfBracket(bracket), true, errors.Join(err, b.save(bracket))
+}
+
+// UpdateOrder applies an order event of an entry, stop loss or take profit, it returns false if the order is not part of a bracket.
+func (b *Brackets) UpdateOrder(ctx context.Context, order bitvavo.Order) (Bracket, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if bracket, ok := b.brackets[order.OrderId]; ok {
+		if !isOpen(order) {
+			bracket.EntryFilled = filledOf(order)
+		}
+		b.settle(bracket)
+		return *copyOfBracket(bracket), true, b.save(bracket)
+	}
+
+	group, ok, err := b.groups.Update(ctx, order)
+	if !ok {
+		return Bracket{}, false, err
+	}
+	for _, bracket := range b.brackets {
+		if bracket.sync(group) {
+			b.settle(bracket)
+			return *copyOfBracket(bracket), true, errors.Join(err, b.save(bracket))
+		}
+	}
+	return Bracket{}, false, err
+}
+
+// UpdateTicker checks the take profits of the market and returns the brackets of which a take profit was hit.
+func (b *Brackets) UpdateTicker(ctx context.Context, ticker bitvavo.Ticker) ([]Bracket, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	groups, err := b.groups.UpdateTicker(ctx, ticker)
+	errs := []error{err}
+
+	changed := make([]Bracket, 0)
+	for _, bracket := range b.brackets {
+		synced := false
+		for _, group := range groups {
+			synced = bracket.sync(group) || synced
+		}
+		if synced {
+			b.settle(bracket)
+			errs = append(errs, b.save(bracket))
+			changed = append(changed, *copyOfBracket(bracket))
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Id < changed[j].Id })
+
+	return changed, errors.Join(errs...)
+}
+
+// Cancel cancels the entry (if open) and all stop loss and take profit orders of the bracket.
+func (b *Brackets) Cancel(ctx context.Context, id string) (Bracket, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bracket, ok := b.brackets[id]
+	if !ok {
+		return Bracket{}, ErrUnknownBracket
+	}
+
+	var errs []error
+	if bracket.EntryFilled < 0 {
+		if _, err := b.client.CancelOrder(ctx, bracket.Market, bracket.Id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, orderIds := range bracket.Groups {
+		if _, err := b.groups.Cancel(ctx, orderIds[0]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return *copyOfBracket(bracket), errors.Join(append(errs, b.save(bracket))...)
+}
+
+// Bracket returns the bracket by the order id of the entry.
+func (b *Brackets) Bracket(id string) (Bracket, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bracket, ok := b.brackets[id]
+	if !ok {
+		return Bracket{}, false
+	}
+	return *copyOfBracket(bracket), true
+}
+
+// Brackets returns all brackets which are not done, sorted by id.
+func (b *Brackets) Brackets() []Bracket {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	brackets := make([]Bracket, 0, len(b.brackets))
+	for _, bracket := range b.brackets {
+		if !bracket.Done {
+			brackets = append(brackets, *copyOfBracket(bracket))
+		}
+	}
+	sort.Slice(brackets, func(i, j int) bool { return brackets[i].Id < brackets[j].Id })
+	return brackets
+}
+
+// Listen applies fill events (see: bitvavo.FillListener), order events (see: bitvavo.OrderListener) and ticker events
+// (see: bitvavo.TickerListener) and emits the bracket on every change. The returned channel is closed when all channels are closed.
+func (b *Brackets) Listen(ctx context.Context, fills <-chan bitvavo.FillEvent, orders <-chan bitvavo.OrderEvent, tickers <-chan bitvavo.TickerEvent) <-chan BracketEvent {
+	chn := make(chan BracketEvent)
+	go listen(chn, from(fills, func(fill bitvavo.Fill) ([]Bracket, error) {
+		return changed(b.UpdateFill(ctx, fill))
+	}), from(orders, func(order bitvavo.Order) ([]Bracket, error) {
+		return changed(b.UpdateOrder(ctx, order))
+	}), from(tickers, func(ticker bitvavo.Ticker) ([]Bracket, error) {
+		return b.UpdateTicker(ctx, ticker)
+	}))
+	return chn
+}
+
+// entry applies the fills and status of the entry order.
+func (b *Brackets) entry(ctx context.Context, bracket *Bracket, entry bitvavo.Order) error {
+	var errs []error
+	for _, fill := range entry.Fills {
+		if fill.OrderId == "" {
+			fill.OrderId = entry.OrderId
+		}
+		if err := b.fill(ctx, bracket, fill); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if !isOpen(entry) {
+		bracket.EntryFilled = filledOf(entry)
+	}
+	b.settle(bracket)
+	return errors.Join(errs...)
+}
+
+func (b *Brackets) fill(ctx context.Context, bracket *Bracket, fill bitvavo.Fill) error {
+	for _, fillId := range bracket.Fills {
+		if fillId == fill.FillId {
+			return nil
+		}
+	}
+	bracket.Fills = append(bracket.Fills, fill.FillId)
+
+	amount := util.ParseFloat(fill.Amount)
+	bracket.Executed += amount
+	if base, _, _ := strings.Cut(bracket.Market, "-"); fill.FeeCurrency == base {
+		amount -= util.ParseFloat(fill.Fee)
+	}
+	bracket.Filled += amount
+
+	return b.protect(ctx, bracket)
+}
+
+// protect places or grows the stop loss and take profit to cover the filled amount.
+func (b *Brackets) protect(ctx context.Context, bracket *Bracket) error {
+	rules, err := b.rules.Rules(bracket.Market)
+	if err != nil {
+		return err
+	}
+
+	amount := util.ParseFloat(rules.FloorAmount(bracket.Filled - bracket.Protected))
+	if amount <= 0 {
+		return nil
+	}
+
+	// grow the last pair if none of its orders executed yet, otherwise place a new pair
+	if n := len(bracket.Groups); n > 0 {
+		grown, err := b.grow(ctx, rules, bracket, bracket.Groups[n-1], amount)
+		if err != nil || grown {
+			return err
+		}
+	}
+
+	// wait for more fills if the amount is below the minimum order size
+	if err := rules.Validate(amount, bracket.StopLoss.Trigger); errors.Is(err, bitvavo.ErrAmountBelowMinimum) || errors.Is(err, bitvavo.ErrNotionalBelowMinimum) {
+		return nil
+	}
+
+	bracket.Pending = PendingProtection{Amount: amount}
+	if err := b.save(bracket); err != nil {
+		return err
+	}
+	group, err := b.groups.Place(ctx, bracket.Market, opposite(bracket.Side),
+		leg(rules, amount, bracket.StopLoss, bitvavo.OrderTypeStopLoss, bitvavo.OrderTypeStopLossLimit),
+		takeProfitLeg(rules, bracket, amount),
+	)
+	bracket.Pending = PendingProtection{}
+	if err != nil {
+		return err
+	}
+
+	bracket.Groups = append(bracket.Groups, orderIdsOf(group))
+	bracket.Protected += amount
+
+	return nil
+}
+
+// resolve applies the pending stop loss of bracket if it arrived on the exchange before a restart.
+func (b *Brackets) resolve(ctx context.Context, bracket *Bracket) error {
+	pending := bracket.Pending
+	if pending.Amount <= 0 {
+		return nil
+	}
+
+	if pending.OrderId != "" {
+		order, err := b.client.GetOrder(ctx, bracket.Market, pending.OrderId)
+		if err != nil {
+			return err
+		}
+		if util.ParseFloat(order.Amount) == util.ParseFloat(pending.Total) {
+			bracket.Protected += pending.Amount
+		}
+		if _, _, err := b.groups.Update(ctx, order); err != nil {
+			return err
+		}
+		bracket.Pending = PendingProtection{}
+		return nil
+	}
+
+	rules, err := b.rules.Rules(bracket.Market)
+	if err != nil {
+		return err
+	}
+	open, err := b.client.GetOrdersOpen(ctx, bracket.Market)
+	if err != nil {
+		return err
+	}
+	trigger := util.ParseFloat(rules.RoundPrice(bracket.StopLoss.Trigger))
+	for _, order := range open {
+		if order.Side != opposite(bracket.Side) || util.ParseFloat(order.TriggerAmount) != trigger || util.ParseFloat(order.Amount) != pending.Amount || b.contains(order.OrderId) {
+			continue
+		}
+		group := Group{Id: order.OrderId, Market: bracket.Market, Side: order.Side, Orders: []bitvavo.Order{order}, Local: []Leg{takeProfitLeg(rules, bracket, pending.Amount)}}
+		b.groups.Track(group)
+		bracket.Groups = append(bracket.Groups, orderIdsOf(group))
+		bracket.Protected += pending.Amount
+		break
+	}
+	bracket.Pending = PendingProtection{}
+	return nil
+}
+
+// grow adds amount to the stop loss of a pair (the take profit follows it), it returns false if the pair can't grow.
+// The amount of the stop loss is read from the exchange, order events may not have been applied yet.
+func (b *Brackets) grow(ctx context.Context, rules bitvavo.MarketRules, bracket *Bracket, orderIds []string, amount float64) (bool, error) {
+	group, ok := b.groups.Group(orderIds[0])
+	if !ok || !untouched(group) {
+		return false, nil
+	}
+
+	current, err := b.client.GetOrder(ctx, bracket.Market, orderIds[0])
+	if err != nil {
+		return false, err
+	}
+	if !untouched(Group{Orders: []bitvavo.Order{current}}) {
+		return false, nil
+	}
+
+	total := util.ParseFloat(rules.FloorAmount(util.ParseFloat(current.Amount) + amount))
+	if rules.Validate(total, bracket.StopLoss.Trigger) != nil {
+		return false, nil
+	}
+
+	bracket.Pending = PendingProtection{Amount: amount, OrderId: current.OrderId, Total: rules.FloorAmount(total)}
+	if err := b.save(bracket); err != nil {
+		return false, err
+	}
+	updated, err := b.client.UpdateOrder(ctx, bracket.Market, current.OrderId, bitvavo.OrderUpdate{Amount: bracket.Pending.Total})
+	bracket.Pending = PendingProtection{}
+	if err != nil {
+		return false, err
+	}
+	if _, _, err := b.groups.Update(ctx, updated); err != nil {
+		return false, err
+	}
+	bracket.Protected += amount
+	return true, nil
+}
+
+// settle marks the bracket as done once the entry is final, all fills are applied and all groups are done.
+func (b *Brackets) settle(bracket *Bracket) {
+	if bracket.EntryFilled < 0 || bracket.Executed < bracket.EntryFilled-1e-12 {
+		return
+	}
+	for _, orderIds := range bracket.Groups {
+		if group, ok := b.groups.Group(orderIds[0]); ok && !group.Done {
+			return
+		}
+	}
+	bracket.Done = true
+}
+
+func (b *Brackets) save(bracket *Bracket) error {
+	if b.store == nil {
+		return nil
+	}
+	return b.store.Save(*copyOfBracket(bracket))
+}
+
+// contains reports whether orderId is part of any bracket.
+func (b *Brackets) contains(orderId string) bool {
+	for _, bracket := range b.brackets {
+		for _, orderIds := range bracket.Groups {
+			for _, id := range orderIds {
+				if id == orderId {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// sync updates the order ids of group, it returns false if group is not of the bracket.
+func (b *Bracket) sync(group Group) bool {
+	for i, orderIds := range b.Groups {
+		if orderIds[0] == group.Id {
+			b.Groups[i] = orderIdsOf(group)
+			return true
+		}
+	}
+	return false
+}
+
+func orderIdsOf(group Group) []string {
+	orderIds := make([]string, len(group.Orders))
+	for i, order := range group.Orders {
+		orderIds[i] = order.OrderId
+	}
+	return orderIds
+}
+
+func opposite(side bitvavo.Side) bitvavo.Side {
+	return util.IfOrElse(side == bitvavo.SideBuy, func() bitvavo.Side { return bitvavo.SideSell }, bitvavo.SideBuy)
+}
+
+func leg(rules bitvavo.MarketRules, amount float64, protection Protection, market bitvavo.OrderType, limit bitvavo.OrderType) Leg {
+	order := bitvavo.OrderNew{
+		Amount:           rules.FloorAmount(amount),
+		TriggerAmount:    rules.RoundPrice(protection.Trigger),
+		TriggerType:      bitvavo.OrderTriggerTypePrice,
+		TriggerReference: util.IfOrElse(protection.Reference.Value == "", func() bitvavo.OrderTriggerRef { return bitvavo.OrderTriggerRefLastTrade }, protection.Reference),
+	}
+	if protection.Limit <= 0 {
+		return Leg{OrderType: market, Order: order}
+	}
+	order.Price = rules.RoundPrice(protection.Limit)
+	return Leg{OrderType: limit, Order: order}
+}
+
+// takeProfitLeg returns the take profit of bracket as a local leg, the stop loss on the exchange already holds the amount.
+func takeProfitLeg(rules bitvavo.MarketRules, bracket *Bracket, amount float64) Leg {
+	takeProfit := leg(rules, amount, bracket.TakeProfit, bitvavo.OrderTypeTakeProfit, bitvavo.OrderTypeTakeProfitLimit)
+	takeProfit.Local = true
+	return takeProfit
+}
+
+// untouched reports whether none of the orders of group executed or got canceled.
+func untouched(group Group) bool {
+	for _, order := range group.Orders {
+		if order.Status != bitvavo.OrderStatusNew && order.Status != bitvavo.OrderStatusAwaitingTrigger {
+			return false
+		}
+	}
+	return !group.Done
+}
+
+// filledOf returns the filled amount of order, order events have no filledAmount.
+func filledOf(order bitvavo.Order) float64 {
+	if order.FilledAmount != "" {
+		return util.ParseFloat(order.FilledAmount)
+	}
+	return util.ParseFloat(order.Amount) - util.ParseFloat(order.AmountRemaining)
+}
+
+func copyOfBracket(bracket *Bracket) *Bracket {
+	b := *bracket
+	b.Fills = append([]string(nil), bracket.Fills...)
+	b.Groups = make([][]string, len(bracket.Groups))
+	for i, orderIds := range bracket.Groups {
+		b.Groups[i] = append([]string(nil), orderIds...)
+	}
+	return &b
+}
@@ -0,0 +1,165 @@
+package execution
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+// FileStore saves brackets as JSON in a single file, brackets which are done are removed.
+type FileStore struct {
+	path string
+
+	mu sync.Mutex
+}
+
+var _ BracketStore = (*FileStore)(nil)
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+type protectionRecord struct {
+	Trigger   float64 `json:"trigger"`
+	Limit     float64 `json:"limit,omitempty"`
+	Reference string  `json:"reference,omitempty"`
+}
+
+type pendingRecord struct {
+	Amount  float64 `json:"amount"`
+	OrderId string  `json:"orderId,omitempty"`
+	Total   string  `json:"total,omitempty"`
+}
+
+type bracketRecord struct {
+	Id          string           `json:"id"`
+	Market      string           `json:"market"`
+	Side        string           `json:"side"`
+	StopLoss    protectionRecord `json:"stopLoss"`
+	TakeProfit  protectionRecord `json:"takeProfit"`
+	Executed    float64          `json:"executed"`
+	Filled      float64          `json:"filled"`
+	Protected   float64          `json:"protected"`
+	Fills       []string         `json:"fills"`
+	Groups      [][]string       `json:"groups"`
+	Pending     *pendingRecord   `json:"pending,omitempty"`
+	EntryFilled float64          `json:"entryFilled"`
+}
+
+func (s *FileStore) Save(bracket Bracket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.read()
+	if err != nil {
+		return err
+	}
+	if bracket.Done {
+		delete(records, bracket.Id)
+	} else {
+		var pending *pendingRecord
+		if bracket.Pending.Amount > 0 {
+			pending = &pendingRecord{bracket.Pending.Amount, bracket.Pending.OrderId, bracket.Pending.Total}
+		}
+		records[bracket.Id] = bracketRecord{
+			Id:          bracket.Id,
+			Market:      bracket.Market,
+			Side:        bracket.Side.Value,
+			StopLoss:    protectionRecord{bracket.StopLoss.Trigger, bracket.StopLoss.Limit, bracket.StopLoss.Reference.Value},
+			TakeProfit:  protectionRecord{bracket.TakeProfit.Trigger, bracket.TakeProfit.Limit, bracket.TakeProfit.Reference.Value},
+			Executed:    bracket.Executed,
+			Filled:      bracket.Filled,
+			Protected:   bracket.Protected,
+			Fills:       bracket.Fills,
+			Groups:      bracket.Groups,
+			Pending:     pending,
+			EntryFilled: bracket.EntryFilled,
+		}
+	}
+
+	list := make([]bracketRecord, 0, len(records))
+	for _, record := range records {
+		list = append(list, record)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Id < list[j].Id })
+
+	bytes, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so a crash never leaves a partial file behind
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(bytes); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *FileStore) Load() ([]Bracket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	brackets := make([]Bracket, 0, len(records))
+	for _, r := range records {
+		var pending PendingProtection
+		if r.Pending != nil {
+			pending = PendingProtection{Amount: r.Pending.Amount, OrderId: r.Pending.OrderId, Total: r.Pending.Total}
+		}
+		brackets = append(brackets, Bracket{
+			Id:          r.Id,
+			Market:      r.Market,
+			Side:        bitvavo.Side{Value: r.Side},
+			StopLoss:    Protection{Trigger: r.StopLoss.Trigger, Limit: r.StopLoss.Limit, Reference: bitvavo.OrderTriggerRef{Value: r.StopLoss.Reference}},
+			TakeProfit:  Protection{Trigger: r.TakeProfit.Trigger, Limit: r.TakeProfit.Limit, Reference: bitvavo.OrderTriggerRef{Value: r.TakeProfit.Reference}},
+			Executed:    r.Executed,
+			Filled:      r.Filled,
+			Protected:   r.Protected,
+			Fills:       r.Fills,
+			Groups:      r.Groups,
+			Pending:     pending,
+			EntryFilled: r.EntryFilled,
+		})
+	}
+	sort.Slice(brackets, func(i, j int) bool { return brackets[i].Id < brackets[j].Id })
+	return brackets, nil
+}
+
+func (s *FileStore) read() (map[string]bracketRecord, error) {
+	records := make(map[string]bracketRecord)
+
+	bytes, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []bracketRecord
+	if err := json.Unmarshal(bytes, &list); err != nil {
+		return nil, err
+	}
+	for _, record := range list {
+		records[record.Id] = record
+	}
+	return records, nil
+}
@@ -0,0 +1,230 @@
+package execution
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+type rules struct {
+	maxOrderInBaseAsset string
+}
+
+func (r rules) Rules(market string) (bitvavo.MarketRules, error) {
+	return bitvavo.MarketRules{
+		Market:        bitvavo.Market{Market: market, PricePrecision: 5, MinOrderInBaseAsset: "0.001", MaxOrderInBaseAsset: r.maxOrderInBaseAsset},
+		BaseDecimals:  8,
+		QuoteDecimals: 2,
+	}, nil
+}
+
+var bracketOrder = BracketOrder{
+	Market: "ETH-EUR",
+	Side:   bitvavo.SideBuy,
+	StopLoss: Protection{
+		Trigger:   90,
+		Limit:     89,
+		Reference: bitvavo.OrderTriggerRefBestBid,
+	},
+	TakeProfit: Protection{
+		Trigger:   120.000001,
+		Limit:     120,
+		Reference: bitvavo.OrderTriggerRefBestBid,
+	},
+}
+
+func TestBrackets(t *testing.T) {
+	ctx := context.Background()
+	// only the quote currency, the stop loss and take profit must not need more than the filled amount
+	client := newPaperClient(bitvavo.WithPaperBalance("EUR", "200"))
+	fills, _ := client.FillListener().Subscribe([]string{"ETH-EUR"})
+	orders, _ := client.OrderListener().Subscribe([]string{"ETH-EUR"})
+
+	store := NewFileStore(filepath.Join(t.TempDir(), "brackets.json"))
+	brackets := NewBrackets(client, rules{}, WithBracketStore(store))
+	events := brackets.Listen(ctx, fills, orders, nil)
+
+	order := bracketOrder
+	order.OrderType, order.Entry = bitvavo.OrderTypeLimit, bitvavo.OrderNew{Amount: "1", Price: "100"}
+	bracket, err := brackets.Submit(ctx, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, 0.0, bracket.Protected)
+
+	// the entry fills in parts (the paper client fills the resting order against the level on every match),
+	// the stop loss is placed on the first part and grows with the next
+	if err := client.UpdateBook(ctx, bitvavo.Book{Market: "ETH-EUR", Nonce: 2, Asks: []bitvavo.Page{{Price: "99.5", Size: "0.4"}}}); err != nil {
+		t.Fatal(err)
+	}
+	event := waitFor(t, events, func(e BracketEvent) bool { return e.Value.Protected == 1 })
+	test.AssertEqual(t, 1.0, event.Value.Filled)
+	test.AssertEqual(t, 1, len(event.Value.Groups))
+	test.AssertEqual(t, PendingProtection{}, event.Value.Pending)
+
+	open, _ := client.GetOrdersOpen(ctx, "ETH-EUR")
+	test.AssertEqual(t, 1, len(open))
+	test.AssertEqual(t, event.Value.Groups[0][0], open[0].OrderId)
+	test.AssertEqual(t, bitvavo.OrderTypeStopLossLimit, open[0].OrderType)
+	test.AssertEqual(t, bitvavo.SideSell, open[0].Side)
+	test.AssertEqual(t, 1.0, util.ParseFloat(open[0].Amount))
+
+	saved, _ := store.Load()
+	test.AssertEqual(t, 1, len(saved))
+	test.AssertEqual(t, bitvavo.OrderTriggerRefBestBid, saved[0].StopLoss.Reference)
+
+	// the stop loss triggers
+	if err := client.UpdateBook(ctx, bitvavo.Book{Market: "ETH-EUR", Nonce: 3, Bids: []bitvavo.Page{{Price: "100", Size: "0"}, {Price: "89.5", Size: "5"}}}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, events, func(e BracketEvent) bool { return e.Value.Done })
+
+	open, _ = client.GetOrdersOpen(ctx, "ETH-EUR")
+	test.AssertEqual(t, 0, len(open))
+	saved, _ = store.Load()
+	test.AssertEqual(t, 0, len(saved))
+}
+
+func TestBracketsTakeProfit(t *testing.T) {
+	ctx := context.Background()
+	client := newPaperClient(bitvavo.WithPaperBalance("EUR", "200"))
+	orders, _ := client.OrderListener().Subscribe([]string{"ETH-EUR"})
+	tickers := make(chan bitvavo.TickerEvent)
+
+	brackets := NewBrackets(client, rules{})
+	events := brackets.Listen(ctx, nil, orders, tickers)
+
+	order := bracketOrder
+	order.OrderType, order.Entry = bitvavo.OrderTypeMarket, bitvavo.OrderNew{Amount: "1"}
+	bracket, err := brackets.Submit(ctx, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, 1.0, bracket.Protected)
+	stopLoss := bracket.Groups[0][0]
+
+	// the take profit is hit, the stop loss is canceled and the take profit placed
+	go func() {
+		tickers <- bitvavo.TickerEvent{Value: bitvavo.Ticker{Market: "ETH-EUR", BestBid: "120.5"}}
+	}()
+	event := waitFor(t, events, func(e BracketEvent) bool { return len(e.Value.Groups[0]) == 2 })
+	test.AssertEqual(t, false, event.Value.Done)
+
+	canceled, _ := client.GetOrder(ctx, "ETH-EUR", stopLoss)
+	test.AssertEqual(t, bitvavo.OrderStatusCanceled, canceled.Status)
+	takeProfit, _ := client.GetOrder(ctx, "ETH-EUR", event.Value.Groups[0][1])
+	test.AssertEqual(t, bitvavo.OrderTypeLimit, takeProfit.OrderType)
+	test.AssertEqual(t, 1.0, util.ParseFloat(takeProfit.Amount))
+	test.AssertEqual(t, 120.0, util.ParseFloat(takeProfit.Price))
+
+	if err := client.UpdateBook(ctx, bitvavo.Book{Market: "ETH-EUR", Nonce: 2, Bids: []bitvavo.Page{{Price: "121", Size: "5"}}}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, events, func(e BracketEvent) bool { return e.Value.Done })
+
+	open, _ := client.GetOrdersOpen(ctx, "ETH-EUR")
+	test.AssertEqual(t, 0, len(open))
+}
+
+func TestBracketsAddPairAboveMaximum(t *testing.T) {
+	ctx := context.Background()
+	client := newPaperClient(bitvavo.WithPaperBalance("EUR", "200"))
+	fills, _ := client.FillListener().Subscribe([]string{"ETH-EUR"})
+	orders, _ := client.OrderListener().Subscribe([]string{"ETH-EUR"})
+
+	brackets := NewBrackets(client, rules{maxOrderInBaseAsset: "0.8"})
+	events := brackets.Listen(ctx, fills, orders, nil)
+
+	order := bracketOrder
+	order.OrderType, order.Entry = bitvavo.OrderTypeLimit, bitvavo.OrderNew{Amount: "1", Price: "100"}
+	if _, err := brackets.Submit(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+
+	// the entry fills 0.4, 0.4 and 0.2: growing the stop loss to 1 exceeds the maximum order size, a new pair is placed instead
+	if err := client.UpdateBook(ctx, bitvavo.Book{Market: "ETH-EUR", Nonce: 2, Asks: []bitvavo.Page{{Price: "99.5", Size: "0.4"}}}); err != nil {
+		t.Fatal(err)
+	}
+	event := waitFor(t, events, func(e BracketEvent) bool { return e.Value.Protected == 1 })
+	test.AssertEqual(t, 2, len(event.Value.Groups))
+
+	first, _ := client.GetOrder(ctx, "ETH-EUR", event.Value.Groups[0][0])
+	test.AssertEqual(t, 0.8, util.ParseFloat(first.Amount))
+	second, _ := client.GetOrder(ctx, "ETH-EUR", event.Value.Groups[1][0])
+	test.AssertEqual(t, 0.2, util.ParseFloat(second.Amount))
+}
+
+func TestBracketsRecover(t *testing.T) {
+	ctx := context.Background()
+	client := newPaperClient(bitvavo.WithPaperBalance("EUR", "200"))
+	store := NewFileStore(filepath.Join(t.TempDir(), "brackets.json"))
+
+	order := bracketOrder
+	order.OrderType, order.Entry = bitvavo.OrderTypeMarket, bitvavo.OrderNew{Amount: "1"}
+	bracket, err := NewBrackets(client, rules{}, WithBracketStore(store)).Submit(ctx, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, 1.0, bracket.Protected)
+
+	// restart
+	brackets := NewBrackets(client, rules{}, WithBracketStore(store))
+	recovered, err := brackets.Recover(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, 1, len(recovered))
+	test.AssertEqual(t, bracket.Id, recovered[0].Id)
+	test.AssertEqual(t, false, recovered[0].Done)
+
+	stopLoss := recovered[0].Groups[0][0]
+	if _, err := client.CancelOrder(ctx, "ETH-EUR", stopLoss); err != nil {
+		t.Fatal(err)
+	}
+	canceled, _ := client.GetOrder(ctx, "ETH-EUR", stopLoss)
+	updated, ok, err := brackets.UpdateOrder(ctx, canceled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, true, ok)
+	test.AssertEqual(t, true, updated.Done)
+
+	open, _ := client.GetOrdersOpen(ctx, "ETH-EUR")
+	test.AssertEqual(t, 0, len(open))
+}
+
+func TestBracketsRecoverPending(t *testing.T) {
+	ctx := context.Background()
+	client := newPaperClient(bitvavo.WithPaperBalance("EUR", "200"))
+	store := NewFileStore(filepath.Join(t.TempDir(), "brackets.json"))
+
+	order := bracketOrder
+	order.OrderType, order.Entry = bitvavo.OrderTypeMarket, bitvavo.OrderNew{Amount: "1"}
+	bracket, err := NewBrackets(client, rules{}, WithBracketStore(store)).Submit(ctx, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// as if the process stopped after the stop loss was placed, but before the bracket was saved again
+	bracket.Groups, bracket.Protected, bracket.Pending = nil, 0, PendingProtection{Amount: 1}
+	if err := store.Save(bracket); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered, err := NewBrackets(client, rules{}, WithBracketStore(store)).Recover(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, 1.0, recovered[0].Protected)
+	test.AssertEqual(t, 1, len(recovered[0].Groups))
+	test.AssertEqual(t, PendingProtection{}, recovered[0].Pending)
+
+	// the stop loss is adopted, not placed a second time
+	open, _ := client.GetOrdersOpen(ctx, "ETH-EUR")
+	test.AssertEqual(t, 1, len(open))
+	test.AssertEqual(t, open[0].OrderId, recovered[0].Groups[0][0])
+}
@@ -0,0 +1,129 @@
+// Package execution places and manages orders on behalf of a strategy: linked orders (OCO), brackets,
+// trailing stops and algorithms which split a large order into smaller ones (TWAP, VWAP and iceberg).
+package execution
+
+import (
+	"context"
+	"sync"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+// Client is the part of bitvavo.PrivateAPI needed to manage orders, the PaperClient can be used as well.
+type Client interface {
+	// GetOrder returns the order by market and ID
+	GetOrder(ctx context.Context, market string, orderId string) (bitvavo.Order, error)
+
+	// GetOrdersOpen returns all open orders for market (e.g: ETH-EUR) or all open orders if no market is given.
+	GetOrdersOpen(ctx context.Context, market ...string) ([]bitvavo.Order, error)
+
+	// CancelOrder cancels a single order by ID for the specific market (e.g: ETH-EUR)
+	CancelOrder(ctx context.Context, market string, orderId string) (string, error)
+
+	// NewOrder places a new order on the exchange.
+	NewOrder(ctx context.Context, market string, side bitvavo.Side, orderType bitvavo.OrderType, order bitvavo.OrderNew) (bitvavo.Order, error)
+
+	// UpdateOrder updates an existing order on the exchange.
+	UpdateOrder(ctx context.Context, market string, orderId string, order bitvavo.OrderUpdate) (bitvavo.Order, error)
+}
+
+// quote is the last known best bid, best ask and last price of a market.
+type quote struct {
+	bid, ask, last float64
+}
+
+// update returns the quote with the prices of ticker, a ticker only has the prices which changed.
+func (q quote) update(ticker bitvavo.Ticker) quote {
+	if ticker.BestBid != "" {
+		q.bid = util.ParseFloat(ticker.BestBid)
+	}
+	if ticker.BestAsk != "" {
+		q.ask = util.ParseFloat(ticker.BestAsk)
+	}
+	if ticker.LastPrice != "" {
+		q.last = util.ParseFloat(ticker.LastPrice)
+	}
+	return q
+}
+
+// reference returns the price a trigger with ref is compared with, the last price by default.
+func (q quote) reference(ref bitvavo.OrderTriggerRef) (float64, bool) {
+	var price float64
+	switch ref {
+	case bitvavo.OrderTriggerRefBestBid:
+		price = q.bid
+	case bitvavo.OrderTriggerRefBestAsk:
+		price = q.ask
+	case bitvavo.OrderTriggerRefMidPrice:
+		if q.bid > 0 && q.ask > 0 {
+			price = (q.bid + q.ask) / 2
+		}
+	default:
+		price = q.last
+	}
+	return price, price > 0
+}
+
+// isOpen reports whether the order can still fill.
+func isOpen(order bitvavo.Order) bool {
+	switch order.Status {
+	case bitvavo.OrderStatusNew, bitvavo.OrderStatusAwaitingTrigger, bitvavo.OrderStatusPartiallyFilled:
+		return true
+	}
+	return false
+}
+
+// event is a bitvavo.ListenerEvent or a type defined on it (e.g: bitvavo.FillEvent)
+type event[T any] interface {
+	~struct {
+		Value T
+		Error error
+	}
+}
+
+// listen runs the sources until all of them are done and sends the error and the changed values of every event to chn,
+// which is closed when done.
+func listen[O event[T], T any](chn chan<- O, sources ...func(emit func([]T, error))) {
+	var wg sync.WaitGroup
+	for _, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			source(func(values []T, err error) {
+				if err != nil {
+					chn <- O(bitvavo.ListenerEvent[T]{Error: err})
+				}
+				for _, value := range values {
+					chn <- O(bitvavo.ListenerEvent[T]{Value: value})
+				}
+			})
+		}()
+	}
+	wg.Wait()
+	close(chn)
+}
+
+// from returns a source of listen which applies the events of events (nil for none) until it's closed.
+func from[E event[V], T, V any](events <-chan E, apply func(V) ([]T, error)) func(emit func([]T, error)) {
+	return func(emit func([]T, error)) {
+		if events == nil {
+			return
+		}
+		for e := range events {
+			if err := bitvavo.ListenerEvent[V](e).Error; err != nil {
+				emit(nil, err)
+				continue
+			}
+			emit(apply(bitvavo.ListenerEvent[V](e).Value))
+		}
+	}
+}
+
+// changed returns value as the changed values of listen if ok.
+func changed[T any](value T, ok bool, err error) ([]T, error) {
+	if !ok {
+		return nil, err
+	}
+	return []T{value}, err
+}
@@ -0,0 +1,59 @@
+package execution
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+type bookAPI struct {
+	bitvavo.PublicAPI
+	book      bitvavo.Book
+	ratelimit int64
+}
+
+func (a *bookAPI) GetTickerBook(_ context.Context, market string) (bitvavo.TickerBook, error) {
+	return bitvavo.TickerBook{Market: market, Bid: a.book.Bids[0].Price, Ask: a.book.Asks[0].Price}, nil
+}
+
+func (a *bookAPI) GetRateLimit() int64 {
+	return a.ratelimit
+}
+
+func (a *bookAPI) GetRateLimitResetAt() time.Time {
+	return time.Now().Add(time.Minute)
+}
+
+func (a *bookAPI) GetOrderBook(_ context.Context, _ string, _ ...uint64) (bitvavo.Book, error) {
+	return a.book, nil
+}
+
+func newPaperClient(options ...bitvavo.PaperOption) *bitvavo.PaperClient {
+	return bitvavo.NewPaperClient(&bookAPI{ratelimit: -1, book: bitvavo.Book{
+		Nonce: 1,
+		Asks:  []bitvavo.Page{{Price: "101", Size: "10"}},
+		Bids:  []bitvavo.Page{{Price: "100", Size: "10"}},
+	}}, options...)
+}
+
+// waitFor reads events until done returns true or fails the test after a second.
+func waitFor[T any](t *testing.T, events <-chan T, done func(T) bool) T {
+	t.Helper()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatal("channel closed")
+			}
+			if done(event) {
+				return event
+			}
+		case <-timeout:
+			t.Fatal("timeout")
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sort"
+	"sync"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+var (
+	ErrInvalidIceberg = errors.New("an iceberg needs an amount, a price, a slice and a variance between 0 and 1")
+	ErrUnknownIceberg = errors.New("unknown iceberg")
+)
+
+// IcebergOrder is a large limit order of which only a slice is visible on the book.
+type IcebergOrder struct {
+	Market string
+	Side   bitvavo.Side
+
+	// The total amount in base currency.
+	Amount float64
+
+	// The limit price of the first slice.
+	Price float64
+
+	// The amount of each slice in base currency.
+	Slice float64
+
+	// Randomises the amount of each slice between Slice*(1-Variance) and Slice*(1+Variance) (e.g: 0.2),
+	// within the minimum and maximum order size of the market. 0 disables it.
+	Variance float64
+
+	// Added to the price of every next slice (e.g: a negative step lowers the price of a buy), 0 keeps the same price.
+	PriceStep float64
+
+	PostOnly bool
+}
+
+// Iceberg is the state of an iceberg order.
+type Iceberg struct {
+	// The order id of the first slice.
+	Id     string
+	Market string
+	Side   bitvavo.Side
+
+	// The total amount in base currency.
+	Amount float64
+
+	// The amount which has been filled, in base and quote currency.
+	Filled      float64
+	FilledQuote float64
+
+	// The order id, amount and price of the visible slice.
+	OrderId string
+	Slice   float64
+	Price   float64
+
+	// The number of slices which have been placed.
+	Slices int
+
+	// True when the total amount has been filled or the iceberg has been canceled.
+	Done bool
+}
+
+type IcebergEvent bitvavo.ListenerEvent[Iceberg]
+
+type IcebergOption func(*Icebergs)
+
+// WithIcebergRand sets the source of randomness for the slice amounts.
+//
+// Default: the global source of math/rand/v2
+func WithIcebergRand(random *rand.Rand) IcebergOption {
+	return func(i *Icebergs) {
+		i.random = random.Float64
+	}
+}
+
+type iceberg struct {
+	Iceberg
+	order IcebergOrder
+
+	// the amount of the visible slice which has been filled and its fill ids
+	filled float64
+	fills  map[string]bool
+}
+
+// Icebergs manages iceberg orders: a single slice is on the book at a time and when it has been filled the next
+// slice is placed, until the total amount has been filled.
+//
+// Fill and order events must be fed with Listen, or UpdateFill and UpdateOrder. It is safe for concurrent use.
+type Icebergs struct {
+	client Client
+	rules  Rules
+	random func() float64
+
+	mu       sync.Mutex
+	icebergs map[string]*iceberg
+	orders   map[string]*iceberg
+}
+
+func NewIcebergs(client Client, rules Rules, options ...IcebergOption) *Icebergs {
+	i := &Icebergs{
+		client:   client,
+		rules:    rules,
+		random:   rand.Float64,
+		icebergs: make(map[string]*iceberg),
+		orders:   make(map[string]*iceberg),
+	}
+
+	for _, opt := range options {
+		opt(i)
+	}
+
+	return i
+}
+
+// Submit places the first slice.
+func (i *Icebergs) Submit(ctx context.Context, order IcebergOrder) (Iceberg, error) {
+	if order.Amount <= 0 || order.Price <= 0 || order.Slice <= 0 || order.Variance < 0 || order.Variance >= 1 {
+		return Iceberg{}, ErrInvalidIceberg
+	}
+
+	// events of the first slice wait until the iceberg is registered
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	ice := &iceberg{
+		Iceberg: Iceberg{Market: order.Market, Side: order.Side, Amount: order.Amount, Price: order.Price},
+		order:   order,
+	}
+	slice, err := i.place(ctx, ice)
+	if err != nil {
+		return Iceberg{}, err
+	}
+	ice.Id = slice.OrderId
+	i.icebergs[ice.Id] = ice
+
+	err = i.settle(ctx, ice, slice)
+	return ice.Iceberg, err
+}
+
+// Cancel cancels the visible slice, the iceberg is done.
+func (i *Icebergs) Cancel(ctx context.Context, id string) (Iceberg, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	ice, ok := i.icebergs[id]
+	if !ok {
+		return Iceberg{}, ErrUnknownIceberg
+	}
+	if ice.Done {
+		return ice.Iceberg, nil
+	}
+
+	if _, err := i.client.CancelOrder(ctx, ice.Market, ice.OrderId); err != nil {
+		return ice.Iceberg, err
+	}
+	ice.Done = true
+	return ice.Iceberg, nil
+}
+
+// Iceberg returns the iceberg by id.
+func (i *Icebergs) Iceberg(id string) (Iceberg, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	ice, ok := i.icebergs[id]
+	if !ok {
+		return Iceberg{}, false
+	}
+	return ice.Iceberg, true
+}
+
+// Icebergs returns all icebergs which are not done, sorted by id.
+func (i *Icebergs) Icebergs() []Iceberg {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	icebergs := make([]Iceberg, 0, len(i.icebergs))
+	for _, ice := range i.icebergs {
+		if !ice.Done {
+			icebergs = append(icebergs, ice.Iceberg)
+		}
+	}
+	sort.Slice(icebergs, func(a, b int) bool { return icebergs[a].Id < icebergs[b].Id })
+	return icebergs
+}
+
+// UpdateFill applies a fill of a slice and places the next slice once the slice has been filled,
+// it returns false if the fill is not of a slice.
+func (i *Icebergs) UpdateFill(ctx context.Context, fill bitvavo.Fill) (Iceberg, bool, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	ice, ok := i.orders[fill.OrderId]
+	if !ok {
+		return Iceberg{}, false, nil
+	}
+	i.fill(ice, fill)
+	err := i.replenish(ctx, ice)
+	return ice.Iceberg, true, err
+}
+
+// UpdateOrder applies an order event of a slice, it returns false if the order is not a slice.
+// The iceberg is done when its slice is canceled or expires (e.g: by another application).
+func (i *Icebergs) UpdateOrder(ctx context.Context, order bitvavo.Order) (Iceberg, bool, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	ice, ok := i.orders[order.OrderId]
+	if !ok {
+		return Iceberg{}, false, nil
+	}
+	err := i.settle(ctx, ice, order)
+	return ice.Iceberg, true, err
+}
+
+// Listen applies fill events (see: bitvavo.FillListener) and order events (see: bitvavo.OrderListener) and emits
+// every iceberg that changed. The returned channel is closed when both channels are closed.
+func (i *Icebergs) Listen(ctx context.Context, fills <-chan bitvavo.FillEvent, orders <-chan bitvavo.OrderEvent) <-chan IcebergEvent {
+	chn := make(chan IcebergEvent)
+	go listen(chn, from(fills, func(fill bitvavo.Fill) ([]Iceberg, error) {
+		return changed(i.UpdateFill(ctx, fill))
+	}), from(orders, func(order bitvavo.Order) ([]Iceberg, error) {
+		return changed(i.UpdateOrder(ctx, order))
+	}))
+	return chn
+}
+
+// settle applies the fills and status of the visible slice.
+func (i *Icebergs) settle(ctx context.Context, ice *iceberg, slice bitvavo.Order) error {
+	if slice.OrderId != ice.OrderId || ice.Done {
+		return nil
+	}
+	for _, fill := range slice.Fills {
+		if fill.OrderId == "" {
+			fill.OrderId = slice.OrderId
+		}
+		i.fill(ice, fill)
+	}
+	if isOpen(slice) {
+		return i.replenish(ctx, ice)
+	}
+
+	// fills which were missed
+	if filled := filledOf(slice); filled > ice.filled {
+		ice.Filled += filled - ice.filled
+		ice.FilledQuote += (filled - ice.filled) * ice.Price
+		ice.filled = filled
+	}
+	if ice.filled < ice.Slice-1e-12 {
+		ice.Done = true
+		return nil
+	}
+	return i.replenish(ctx, ice)
+}
+
+func (i *Icebergs) fill(ice *iceberg, fill bitvavo.Fill) {
+	if fill.OrderId != ice.OrderId || ice.fills[fill.FillId] {
+		return
+	}
+	ice.fills[fill.FillId] = true
+
+	amount := util.ParseFloat(fill.Amount)
+	ice.filled += amount
+	ice.Filled += amount
+	ice.FilledQuote += amount * util.ParseFloat(fill.Price)
+}
+
+// replenish places the next slice once the visible slice has been filled.
+func (i *Icebergs) replenish(ctx context.Context, ice *iceberg) error {
+	if ice.Done || ice.filled < ice.Slice-1e-12 {
+		return nil
+	}
+
+	rules, err := i.rules.Rules(ice.Market)
+	if err != nil {
+		return err
+	}
+	remaining := util.ParseFloat(rules.FloorAmount(ice.Amount - ice.Filled))
+	if err := rules.Validate(remaining, ice.Price+ice.order.PriceStep); remaining <= 0 || errors.Is(err, bitvavo.ErrAmountBelowMinimum) || errors.Is(err, bitvavo.ErrNotionalBelowMinimum) {
+		ice.Done = true
+		return nil
+	}
+
+	ice.Price += ice.order.PriceStep
+	slice, err := i.place(ctx, ice)
+	if err != nil {
+		// there is no visible slice anymore
+		ice.Done = true
+		return err
+	}
+	return i.settle(ctx, ice, slice)
+}
+
+// place places the next slice at the price of the iceberg.
+func (i *Icebergs) place(ctx context.Context, ice *iceberg) (bitvavo.Order, error) {
+	rules, err := i.rules.Rules(ice.Market)
+	if err != nil {
+		return bitvavo.Order{}, err
+	}
+
+	var (
+		remaining = ice.Amount - ice.Filled
+		amount    = ice.order.Slice
+		minimum   = util.ParseFloat(rules.Market.MinOrderInBaseAsset)
+		maximum   = util.ParseFloat(rules.Market.MaxOrderInBaseAsset)
+	)
+	if ice.order.Variance > 0 {
+		amount *= 1 + ice.order.Variance*(2*i.random()-1)
+	}
+	amount = max(amount, minimum)
+	if maximum > 0 {
+		amount = min(amount, maximum)
+	}
+	// the last slice takes the rest if it would be too small for an order, within the maximum order size
+	if remaining-amount < minimum || amount > remaining {
+		amount = remaining
+		if maximum > 0 && amount > maximum {
+			// leave at least the minimum for the next slice
+			amount = min(maximum, remaining-minimum)
+		}
+	}
+
+	order, err := i.client.NewOrder(ctx, ice.Market, ice.Side, bitvavo.OrderTypeLimit, bitvavo.OrderNew{
+		Amount:   rules.FloorAmount(amount),
+		Price:    rules.RoundPrice(ice.Price),
+		PostOnly: ice.order.PostOnly,
+	})
+	if err != nil {
+		return bitvavo.Order{}, err
+	}
+
+	ice.OrderId, ice.Slice, ice.Slices = order.OrderId, util.ParseFloat(order.Amount), ice.Slices+1
+	ice.filled, ice.fills = 0, make(map[string]bool)
+	i.orders[order.OrderId] = ice
+
+	return order, nil
+}
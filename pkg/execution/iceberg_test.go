@@ -0,0 +1,86 @@
+package execution
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+func TestIcebergs(t *testing.T) {
+	ctx := context.Background()
+	client := newPaperClient(bitvavo.WithPaperBalance("EUR", "1000"))
+	fills, _ := client.FillListener().Subscribe([]string{"ETH-EUR"})
+	orders, _ := client.OrderListener().Subscribe([]string{"ETH-EUR"})
+
+	icebergs := NewIcebergs(client, rules{})
+	events := icebergs.Listen(ctx, fills, orders)
+
+	iceberg, err := icebergs.Submit(ctx, IcebergOrder{Market: "ETH-EUR", Side: bitvavo.SideBuy, Amount: 2.5, Price: 99, Slice: 1, PriceStep: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, 1, iceberg.Slices)
+
+	open, _ := client.GetOrdersOpen(ctx, "ETH-EUR")
+	test.AssertEqual(t, 1, len(open))
+	test.AssertEqual(t, 1.0, util.ParseFloat(open[0].Amount))
+	test.AssertEqual(t, 99.0, util.ParseFloat(open[0].Price))
+
+	// every slice fills against the new level, the last slice takes the rest
+	if err := client.UpdateBook(ctx, bitvavo.Book{Market: "ETH-EUR", Nonce: 2, Asks: []bitvavo.Page{{Price: "98.5", Size: "5"}}}); err != nil {
+		t.Fatal(err)
+	}
+	event := waitFor(t, events, func(e IcebergEvent) bool { return e.Value.Done })
+	test.AssertEqual(t, iceberg.Id, event.Value.Id)
+	test.AssertEqual(t, 2.5, event.Value.Filled)
+	test.AssertEqual(t, 3, event.Value.Slices)
+	test.AssertEqual(t, 0.5, event.Value.Slice)
+	test.AssertEqual(t, 100.0, event.Value.Price)
+
+	_ = client.FillListener().Close()
+	_ = client.OrderListener().Close()
+	for range events {
+	}
+	test.AssertEqual(t, 0, len(icebergs.Icebergs()))
+}
+
+func TestIcebergsRandomSlice(t *testing.T) {
+	ctx := context.Background()
+	client := newPaperClient(bitvavo.WithPaperBalance("EUR", "2000"))
+	icebergs := NewIcebergs(client, rules{}, WithIcebergRand(rand.New(rand.NewPCG(1, 2))))
+
+	_, err := icebergs.Submit(ctx, IcebergOrder{Market: "ETH-EUR", Side: bitvavo.SideBuy, Amount: 10, Price: 99, Slice: 2, Variance: 1})
+	test.AssertEqual(t, ErrInvalidIceberg, err)
+
+	iceberg, err := icebergs.Submit(ctx, IcebergOrder{Market: "ETH-EUR", Side: bitvavo.SideBuy, Amount: 10, Price: 99, Slice: 2, Variance: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, true, iceberg.Slice >= 1 && iceberg.Slice <= 3)
+	test.AssertEqual(t, true, iceberg.Slice != 2)
+
+	iceberg, err = icebergs.Cancel(ctx, iceberg.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, true, iceberg.Done)
+	open, _ := client.GetOrdersOpen(ctx, "ETH-EUR")
+	test.AssertEqual(t, 0, len(open))
+}
+
+func TestIcebergsLastSliceWithinMaximum(t *testing.T) {
+	ctx := context.Background()
+	client := newPaperClient(bitvavo.WithPaperBalance("EUR", "1000"))
+	icebergs := NewIcebergs(client, rules{maxOrderInBaseAsset: "1"})
+
+	// the rest after a slice of 1 is below the minimum, but taking it would exceed the maximum
+	iceberg, err := icebergs.Submit(ctx, IcebergOrder{Market: "ETH-EUR", Side: bitvavo.SideBuy, Amount: 1.0005, Price: 99, Slice: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, 0.9995, iceberg.Slice)
+}
@@ -0,0 +1,503 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+var (
+	ErrGroupTooSmall = errors.New("an order group needs at least 2 orders")
+	ErrUnknownGroup  = errors.New("unknown order group")
+	ErrLocalLeg      = errors.New("a local leg needs a take profit or stop loss order type, and the group an order on the exchange")
+)
+
+// Leg is an order of a group.
+type Leg struct {
+	OrderType bitvavo.OrderType
+	Order     bitvavo.OrderNew
+
+	// A local leg is not placed on the exchange, so it puts nothing on hold. It's watched with the ticker instead and
+	// once its trigger is hit, the orders of the group are canceled and a market order (takeProfit, stopLoss)
+	// or limit order (takeProfitLimit, stopLossLimit) is placed for the remaining amount.
+	Local bool
+}
+
+// Group is a set of linked orders of which only one may execute (one-cancels-other).
+type Group struct {
+	// The order id of the first order.
+	Id string
+
+	Market string
+	Side   bitvavo.Side
+
+	// The last known state of the orders, in the order they were placed.
+	Orders []bitvavo.Order
+
+	// The local legs which are watched, until one of them is hit.
+	Local []Leg
+
+	// True when a local leg was hit, its order is the last of Orders once placed.
+	Triggered bool
+
+	// True when one of the orders reached a final status and the others have been canceled.
+	Done bool
+}
+
+// Order returns the last known state of the order by id.
+func (g Group) Order(orderId string) (bitvavo.Order, bool) {
+	for _, order := range g.Orders {
+		if order.OrderId == orderId {
+			return order, true
+		}
+	}
+	return bitvavo.Order{}, false
+}
+
+type GroupEvent bitvavo.ListenerEvent[Group]
+
+// OrderGroup manages one-cancels-other groups on top of NewOrder and CancelOrder, the exchange has no native OCO.
+//
+// When an order of a group fills, is canceled or expires, the other orders are canceled. When an order partially fills,
+// the other orders are resized to the remaining amount of that order.
+//
+// Every order of a group puts its amount on hold, so a take profit and a stop loss of the same position on the exchange
+// need twice the position. Make one of them a local leg to only hold the position once, local legs follow the ticker
+// which must be fed with Listen or UpdateTicker.
+//
+// Order events must be fed with Listen or Update, call Reconcile after a reconnect (see: bitvavo.WithWebSocketReconnectFunc)
+// to catch up on missed events. It is safe for concurrent use.
+type OrderGroup struct {
+	client Client
+
+	mu        sync.Mutex
+	groups    map[string]*Group
+	orders    map[string]*Group
+	triggered map[string]Leg
+	quotes    map[string]quote
+
+	// events of orders which are not known (yet) while groups are placed
+	placing int
+	early   map[string]bitvavo.Order
+}
+
+func NewOrderGroup(client Client) *OrderGroup {
+	return &OrderGroup{
+		client:    client,
+		groups:    make(map[string]*Group),
+		orders:    make(map[string]*Group),
+		triggered: make(map[string]Leg),
+		quotes:    make(map[string]quote),
+		early:     make(map[string]bitvavo.Order),
+	}
+}
+
+// Place places the legs on market as a group, if any leg fails the placed legs are canceled.
+// Use OrderTypeTakeProfitLimit and OrderTypeStopLossLimit legs for a take profit OR stop loss.
+func (g *OrderGroup) Place(ctx context.Context, market string, side bitvavo.Side, legs ...Leg) (Group, error) {
+	if len(legs) < 2 {
+		return Group{}, ErrGroupTooSmall
+	}
+	exchange := 0
+	for _, leg := range legs {
+		if !leg.Local {
+			exchange++
+		} else if !isTrigger(leg.OrderType) {
+			return Group{}, ErrLocalLeg
+		}
+	}
+	if exchange == 0 {
+		return Group{}, ErrLocalLeg
+	}
+
+	g.mu.Lock()
+	g.placing++
+	g.mu.Unlock()
+
+	group := &Group{Market: market, Side: side, Orders: make([]bitvavo.Order, 0, exchange)}
+	var err error
+	for _, leg := range legs {
+		if leg.Local {
+			group.Local = append(group.Local, leg)
+			continue
+		}
+		var order bitvavo.Order
+		if order, err = g.client.NewOrder(ctx, market, side, leg.OrderType, leg.Order); err != nil {
+			break
+		}
+		group.Orders = append(group.Orders, order)
+	}
+	if err != nil {
+		for _, placed := range group.Orders {
+			_, _ = g.client.CancelOrder(context.WithoutCancel(ctx), market, placed.OrderId)
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	defer func() {
+		if g.placing--; g.placing == 0 {
+			clear(g.early)
+		}
+	}()
+
+	if err != nil {
+		return Group{}, err
+	}
+	group.Id = group.Orders[0].OrderId
+	g.track(group)
+
+	// a leg may have (partially) filled right away, or its events arrived before the group was known
+	for _, order := range group.Orders {
+		if early, ok := g.early[order.OrderId]; ok {
+			g.store(group, early)
+			delete(g.early, order.OrderId)
+		}
+	}
+	for _, order := range copyOf(group).Orders {
+		if !isOpen(order) || order.Status == bitvavo.OrderStatusPartiallyFilled {
+			if err := g.settle(ctx, group, order); err != nil {
+				return copyOf(group), err
+			}
+		}
+	}
+
+	return copyOf(group), nil
+}
+
+// Track manages a group of existing orders (e.g: after a restart), call Reconcile to bring it up to date.
+// Set Side and Local to keep watching local legs.
+func (g *OrderGroup) Track(group Group) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.track(&group)
+}
+
+// Cancel cancels all open orders of the group.
+func (g *OrderGroup) Cancel(ctx context.Context, id string) (Group, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	group, ok := g.groups[id]
+	if !ok {
+		return Group{}, ErrUnknownGroup
+	}
+	group.Done = true
+	group.Local = nil
+	delete(g.triggered, id)
+	err := g.cancel(ctx, group, "")
+	return copyOf(group), err
+}
+
+// Group returns the group by id.
+func (g *OrderGroup) Group(id string) (Group, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	group, ok := g.groups[id]
+	if !ok {
+		return Group{}, false
+	}
+	return copyOf(group), true
+}
+
+// Groups returns all groups which are not done, sorted by id.
+func (g *OrderGroup) Groups() []Group {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	groups := make([]Group, 0, len(g.groups))
+	for _, group := range g.groups {
+		if !group.Done {
+			groups = append(groups, copyOf(group))
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Id < groups[j].Id })
+	return groups
+}
+
+// Update applies an order event, it returns false if the order is not part of a group.
+func (g *OrderGroup) Update(ctx context.Context, order bitvavo.Order) (Group, bool, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	group, ok := g.orders[order.OrderId]
+	if !ok {
+		if known, ok := g.early[order.OrderId]; g.placing > 0 && (!ok || order.Updated >= known.Updated) {
+			g.early[order.OrderId] = order
+		}
+		return Group{}, false, nil
+	}
+	err := g.settle(ctx, group, order)
+	return copyOf(group), true, err
+}
+
+// UpdateTicker checks the local legs of the market and returns the groups of which a leg was hit.
+func (g *OrderGroup) UpdateTicker(ctx context.Context, ticker bitvavo.Ticker) ([]Group, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	q := g.quotes[ticker.Market].update(ticker)
+	g.quotes[ticker.Market] = q
+
+	var (
+		changed = make([]Group, 0)
+		errs    []error
+	)
+	for _, group := range g.groups {
+		if group.Market != ticker.Market || group.Done {
+			continue
+		}
+
+		// the order of a hit leg failed before
+		if _, ok := g.triggered[group.Id]; ok {
+			if err := g.fire(ctx, group); err != nil {
+				errs = append(errs, err)
+			}
+			changed = append(changed, copyOf(group))
+			continue
+		}
+
+		for _, leg := range group.Local {
+			price, ok := q.reference(leg.Order.TriggerReference)
+			if !ok || !hit(group.Side, leg, price) {
+				continue
+			}
+			if err := g.trigger(ctx, group, leg); err != nil {
+				errs = append(errs, err)
+			}
+			changed = append(changed, copyOf(group))
+			break
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Id < changed[j].Id })
+
+	return changed, errors.Join(errs...)
+}
+
+// Reconcile compares the groups with the open orders on the exchange and applies any change that was missed.
+// It returns the groups which changed.
+func (g *OrderGroup) Reconcile(ctx context.Context) ([]Group, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	open := make(map[string]map[string]bitvavo.Order)
+	changed := make([]Group, 0)
+
+	for _, group := range g.groups {
+		if group.Done {
+			continue
+		}
+
+		orders, ok := open[group.Market]
+		if !ok {
+			list, err := g.client.GetOrdersOpen(ctx, group.Market)
+			if err != nil {
+				return changed, err
+			}
+			orders = make(map[string]bitvavo.Order, len(list))
+			for _, order := range list {
+				orders[order.OrderId] = order
+			}
+			open[group.Market] = orders
+		}
+
+		updated := false
+		for _, known := range copyOf(group).Orders {
+			if !isOpen(known) {
+				continue
+			}
+			current, ok := orders[known.OrderId]
+			if !ok {
+				var err error
+				if current, err = g.client.GetOrder(ctx, group.Market, known.OrderId); err != nil {
+					return changed, err
+				}
+			}
+			if current.Status == known.Status && current.AmountRemaining == known.AmountRemaining {
+				continue
+			}
+			updated = true
+			if err := g.settle(ctx, group, current); err != nil {
+				return changed, err
+			}
+		}
+		if updated {
+			changed = append(changed, copyOf(group))
+		}
+	}
+
+	return changed, nil
+}
+
+// Listen applies order events (see: bitvavo.OrderListener) and ticker events (may be nil without local legs)
+// and emits every group that changed. The returned channel is closed when both channels are closed.
+func (g *OrderGroup) Listen(ctx context.Context, orders <-chan bitvavo.OrderEvent, tickers <-chan bitvavo.TickerEvent) <-chan GroupEvent {
+	chn := make(chan GroupEvent)
+	go listen(chn, from(orders, func(order bitvavo.Order) ([]Group, error) {
+		return changed(g.Update(ctx, order))
+	}), from(tickers, func(ticker bitvavo.Ticker) ([]Group, error) {
+		return g.UpdateTicker(ctx, ticker)
+	}))
+	return chn
+}
+
+func (g *OrderGroup) track(group *Group) {
+	g.groups[group.Id] = group
+	for _, order := range group.Orders {
+		g.orders[order.OrderId] = group
+	}
+}
+
+// settle stores the state of order and cancels or resizes the other orders of the group.
+func (g *OrderGroup) settle(ctx context.Context, group *Group, order bitvavo.Order) error {
+	g.store(group, order)
+	if group.Done {
+		return nil
+	}
+
+	// once a local leg was hit, the other orders have been canceled and only its order ends the group
+	if _, pending := g.triggered[group.Id]; group.Triggered && (pending || order.OrderId != group.Orders[len(group.Orders)-1].OrderId) {
+		return nil
+	}
+
+	if !isOpen(order) {
+		group.Done = true
+		group.Local = nil
+		return g.cancel(ctx, group, order.OrderId)
+	}
+	if order.Status != bitvavo.OrderStatusPartiallyFilled {
+		return nil
+	}
+
+	remaining := util.ParseFloat(order.AmountRemaining)
+	var errs []error
+	for _, sibling := range group.Orders {
+		if sibling.OrderId == order.OrderId || !isOpen(sibling) || util.ParseFloat(sibling.AmountRemaining) <= remaining {
+			continue
+		}
+		updated, err := g.client.UpdateOrder(ctx, group.Market, sibling.OrderId, bitvavo.OrderUpdate{AmountRemaining: order.AmountRemaining})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		g.store(group, updated)
+	}
+	return errors.Join(errs...)
+}
+
+// trigger cancels the orders of the group and places the hit leg for the amount which is left.
+func (g *OrderGroup) trigger(ctx context.Context, group *Group, leg Leg) error {
+	err := g.cancel(ctx, group, "")
+
+	remaining := math.MaxFloat64
+	for _, known := range copyOf(group).Orders {
+		order, getErr := g.client.GetOrder(ctx, group.Market, known.OrderId)
+		if getErr != nil {
+			return errors.Join(err, getErr)
+		}
+		if isOpen(order) {
+			// not canceled, the next ticker tries again
+			return err
+		}
+		g.store(group, order)
+		if order.Status == bitvavo.OrderStatusFilled {
+			// it filled before it could be canceled
+			return g.settle(ctx, group, order)
+		}
+		remaining = min(remaining, util.ParseFloat(order.Amount)-filledOf(order))
+	}
+
+	group.Triggered = true
+	group.Local = nil
+	if remaining <= 0 {
+		group.Done = true
+		return nil
+	}
+	leg.Order.Amount = util.FormatFloat(remaining)
+	g.triggered[group.Id] = leg
+	return g.fire(ctx, group)
+}
+
+// fire places the order of the hit leg of the group.
+func (g *OrderGroup) fire(ctx context.Context, group *Group) error {
+	leg := g.triggered[group.Id]
+
+	orderType, order := bitvavo.OrderTypeMarket, bitvavo.OrderNew{Amount: leg.Order.Amount}
+	if leg.OrderType == bitvavo.OrderTypeTakeProfitLimit || leg.OrderType == bitvavo.OrderTypeStopLossLimit {
+		orderType, order.Price = bitvavo.OrderTypeLimit, leg.Order.Price
+	}
+
+	placed, err := g.client.NewOrder(ctx, group.Market, group.Side, orderType, order)
+	if err != nil {
+		return err
+	}
+	delete(g.triggered, group.Id)
+	group.Orders = append(group.Orders, placed)
+	g.orders[placed.OrderId] = group
+
+	if !isOpen(placed) {
+		return g.settle(ctx, group, placed)
+	}
+	return nil
+}
+
+// cancel cancels all open orders of the group except orderId.
+func (g *OrderGroup) cancel(ctx context.Context, group *Group, orderId string) error {
+	var errs []error
+	for _, order := range group.Orders {
+		if order.OrderId == orderId || !isOpen(order) {
+			continue
+		}
+		if _, err := g.client.CancelOrder(ctx, group.Market, order.OrderId); err != nil {
+			// it may have been filled or canceled in the meantime, the next event or Reconcile tells
+			errs = append(errs, err)
+			continue
+		}
+		order.Status = bitvavo.OrderStatusCanceled
+		g.store(group, order)
+	}
+	return errors.Join(errs...)
+}
+
+// store replaces the state of order, unless it's older than the known state.
+func (g *OrderGroup) store(group *Group, order bitvavo.Order) {
+	for i := range group.Orders {
+		if group.Orders[i].OrderId == order.OrderId && order.Updated >= group.Orders[i].Updated {
+			group.Orders[i] = order
+		}
+	}
+}
+
+func copyOf(group *Group) Group {
+	g := *group
+	g.Orders = append([]bitvavo.Order(nil), group.Orders...)
+	g.Local = append([]Leg(nil), group.Local...)
+	return g
+}
+
+// isTrigger reports whether orderType is a take profit or stop loss.
+func isTrigger(orderType bitvavo.OrderType) bool {
+	switch orderType {
+	case bitvavo.OrderTypeTakeProfit, bitvavo.OrderTypeTakeProfitLimit, bitvavo.OrderTypeStopLoss, bitvavo.OrderTypeStopLossLimit:
+		return true
+	}
+	return false
+}
+
+// hit reports whether price reached the trigger of the leg: a take profit is hit once the price moved in favour of
+// side (above the trigger for a sell), a stop loss once it moved against it.
+func hit(side bitvavo.Side, leg Leg, price float64) bool {
+	trigger := util.ParseFloat(leg.Order.TriggerAmount)
+	above := leg.OrderType == bitvavo.OrderTypeTakeProfit || leg.OrderType == bitvavo.OrderTypeTakeProfitLimit
+	if side == bitvavo.SideBuy {
+		above = !above
+	}
+	return util.IfOrElse(above, func() bool { return price >= trigger }, price <= trigger)
+}
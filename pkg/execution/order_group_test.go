@@ -0,0 +1,132 @@
+package execution
+
+import (
+	"context"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+func TestOrderGroup(t *testing.T) {
+	ctx := context.Background()
+	client := newPaperClient(bitvavo.WithPaperBalance("ETH", "2"))
+	orders, _ := client.OrderListener().Subscribe([]string{"ETH-EUR"})
+
+	groups := NewOrderGroup(client)
+	events := groups.Listen(ctx, orders, nil)
+
+	group, err := groups.Place(ctx, "ETH-EUR", bitvavo.SideSell,
+		Leg{OrderType: bitvavo.OrderTypeLimit, Order: bitvavo.OrderNew{Amount: "1", Price: "105"}},
+		Leg{OrderType: bitvavo.OrderTypeStopLossLimit, Order: bitvavo.OrderNew{
+			Amount:           "1",
+			Price:            "89",
+			TriggerAmount:    "90",
+			TriggerType:      bitvavo.OrderTriggerTypePrice,
+			TriggerReference: bitvavo.OrderTriggerRefBestBid,
+		}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	takeProfit, stopLoss := group.Orders[0].OrderId, group.Orders[1].OrderId
+
+	// partial fill of the take profit resizes the stop loss
+	if err := client.UpdateBook(ctx, bitvavo.Book{Market: "ETH-EUR", Nonce: 2, Bids: []bitvavo.Page{{Price: "106", Size: "0.4"}}}); err != nil {
+		t.Fatal(err)
+	}
+	event := waitFor(t, events, func(e GroupEvent) bool {
+		order, _ := e.Value.Order(stopLoss)
+		return order.AmountRemaining == "0.6"
+	})
+	test.AssertEqual(t, false, event.Value.Done)
+
+	// the take profit fills, so the stop loss is canceled
+	if err := client.UpdateBook(ctx, bitvavo.Book{Market: "ETH-EUR", Nonce: 3, Bids: []bitvavo.Page{{Price: "106", Size: "1"}}}); err != nil {
+		t.Fatal(err)
+	}
+	event = waitFor(t, events, func(e GroupEvent) bool { return e.Value.Done })
+
+	order, _ := event.Value.Order(takeProfit)
+	test.AssertEqual(t, bitvavo.OrderStatusFilled, order.Status)
+	order, _ = event.Value.Order(stopLoss)
+	test.AssertEqual(t, bitvavo.OrderStatusCanceled, order.Status)
+
+	open, _ := client.GetOrdersOpen(ctx, "ETH-EUR")
+	test.AssertEqual(t, 0, len(open))
+	test.AssertEqual(t, 0, len(groups.Groups()))
+}
+
+func TestOrderGroupLocalLeg(t *testing.T) {
+	ctx := context.Background()
+	// only the position, the stop loss must not hold it a second time
+	client := newPaperClient(bitvavo.WithPaperBalance("ETH", "1"))
+	orders, _ := client.OrderListener().Subscribe([]string{"ETH-EUR"})
+	tickers := make(chan bitvavo.TickerEvent)
+
+	groups := NewOrderGroup(client)
+	events := groups.Listen(ctx, orders, tickers)
+
+	stopLoss := Leg{OrderType: bitvavo.OrderTypeStopLossLimit, Local: true, Order: bitvavo.OrderNew{
+		Amount:           "1",
+		Price:            "89",
+		TriggerAmount:    "90",
+		TriggerType:      bitvavo.OrderTriggerTypePrice,
+		TriggerReference: bitvavo.OrderTriggerRefBestBid,
+	}}
+	_, err := groups.Place(ctx, "ETH-EUR", bitvavo.SideSell, stopLoss, Leg{OrderType: bitvavo.OrderTypeLimit, Local: true})
+	test.AssertEqual(t, ErrLocalLeg, err)
+
+	group, err := groups.Place(ctx, "ETH-EUR", bitvavo.SideSell,
+		Leg{OrderType: bitvavo.OrderTypeLimit, Order: bitvavo.OrderNew{Amount: "1", Price: "105"}},
+		stopLoss,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, 1, len(group.Orders))
+	test.AssertEqual(t, 1, len(group.Local))
+
+	go func() {
+		tickers <- bitvavo.TickerEvent{Value: bitvavo.Ticker{Market: "ETH-EUR", BestBid: "95"}}
+		tickers <- bitvavo.TickerEvent{Value: bitvavo.Ticker{Market: "ETH-EUR", BestBid: "89"}}
+	}()
+	event := waitFor(t, events, func(e GroupEvent) bool { return e.Value.Done })
+	test.AssertEqual(t, group.Id, event.Value.Id)
+	test.AssertEqual(t, true, event.Value.Triggered)
+	test.AssertEqual(t, 2, len(event.Value.Orders))
+	test.AssertEqual(t, bitvavo.OrderStatusCanceled, event.Value.Orders[0].Status)
+	test.AssertEqual(t, bitvavo.OrderStatusFilled, event.Value.Orders[1].Status)
+	test.AssertEqual(t, "1", event.Value.Orders[1].FilledAmount)
+
+	open, _ := client.GetOrdersOpen(ctx, "ETH-EUR")
+	test.AssertEqual(t, 0, len(open))
+	test.AssertEqual(t, 0, len(groups.Groups()))
+}
+
+func TestOrderGroupReconcile(t *testing.T) {
+	ctx := context.Background()
+	client := newPaperClient(bitvavo.WithPaperBalance("ETH", "2"))
+
+	// no listener, as if all events were missed
+	groups := NewOrderGroup(client)
+	group, err := groups.Place(ctx, "ETH-EUR", bitvavo.SideSell,
+		Leg{OrderType: bitvavo.OrderTypeLimit, Order: bitvavo.OrderNew{Amount: "1", Price: "105"}},
+		Leg{OrderType: bitvavo.OrderTypeLimit, Order: bitvavo.OrderNew{Amount: "1", Price: "110"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.CancelOrder(ctx, "ETH-EUR", group.Orders[0].OrderId); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := groups.Reconcile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, 1, len(changed))
+	test.AssertEqual(t, true, changed[0].Done)
+	test.AssertEqual(t, bitvavo.OrderStatusCanceled, changed[0].Orders[1].Status)
+}
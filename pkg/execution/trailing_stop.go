@@ -0,0 +1,355 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+	"github.com/orsinium-labs/enum"
+)
+
+var (
+	ErrInvalidTrail        = errors.New("a trailing stop needs either an offset or a percentage between 0 and 1")
+	ErrUnknownTrailingStop = errors.New("unknown trailing stop")
+)
+
+type TrailingMode enum.Member[string]
+
+var (
+	trailingMode = enum.NewBuilder[string, TrailingMode]()
+
+	// TrailingModeOrder keeps a stopLoss order on the exchange and moves its trigger.
+	TrailingModeOrder = trailingMode.Add(TrailingMode{"order"})
+
+	// TrailingModeLocal keeps the stop locally and places a market order once it's hit.
+	TrailingModeLocal = trailingMode.Add(TrailingMode{"local"})
+)
+
+// TrailingStopOrder is a stop which follows the price at a fixed distance.
+type TrailingStopOrder struct {
+	Market string
+
+	// A sell stop trails below the highest price (e.g: to protect a long position), a buy stop above the lowest price.
+	Side bitvavo.Side
+
+	// The amount in base currency.
+	Amount string
+
+	// The distance to the price in quote currency, or as a fraction of the price (e.g: 0.02 for 2%).
+	Offset     float64
+	Percentage float64
+
+	// The price which is followed.
+	//
+	// Default: OrderTriggerRefLastTrade
+	Reference bitvavo.OrderTriggerRef
+
+	// Default: TrailingModeOrder
+	Mode TrailingMode
+}
+
+// TrailingStop is the state of a trailing stop.
+type TrailingStop struct {
+	Id string
+	TrailingStopOrder
+
+	// The best price seen: the highest for a sell stop, the lowest for a buy stop. 0 until the first price is known.
+	Price float64
+
+	// The current stop price, rounded to the precision of the market.
+	Stop float64
+
+	// The trigger of the stopLoss order on the exchange (TrailingModeOrder), it lags Stop while updates are throttled.
+	Trigger float64
+
+	// The stopLoss order (TrailingModeOrder) or the market order once hit (TrailingModeLocal).
+	OrderId string
+
+	// True when the stop has been hit.
+	Triggered bool
+
+	// True when the stop has been hit or canceled.
+	Done bool
+}
+
+type TrailingStopEvent bitvavo.ListenerEvent[TrailingStop]
+
+type TrailingStopOption func(*TrailingStops)
+
+// WithTrailingInterval sets the minimum time between 2 updates of the stopLoss order of a trailing stop,
+// moves in between are combined into the next update.
+//
+// Default: 1 second
+func WithTrailingInterval(interval time.Duration) TrailingStopOption {
+	return func(s *TrailingStops) {
+		s.interval = interval
+	}
+}
+
+// WithTrailingRateLimitReserve postpones updates of stopLoss orders while the remaining rate limit of the client
+// (see: GetRateLimit) is below reserve, leaving room for other requests. Hitting a stop is never postponed.
+//
+// Default: 100
+func WithTrailingRateLimitReserve(reserve int64) TrailingStopOption {
+	return func(s *TrailingStops) {
+		s.reserve = reserve
+	}
+}
+
+// rateLimited is implemented by the HTTP clients and the PaperClient.
+type rateLimited interface {
+	GetRateLimit() int64
+	GetRateLimitResetAt() time.Time
+}
+
+type trailingStop struct {
+	TrailingStop
+	updated time.Time
+}
+
+// TrailingStops emulates trailing stops, the exchange only supports stops at a fixed price.
+// The stops follow the ticker (see: bitvavo.TickerListener), which must be fed with Listen or UpdateTicker.
+//
+// In TrailingModeOrder the stop is a stopLoss order on the exchange and every move updates its trigger,
+// feed order events as well to know when it has been hit. In TrailingModeLocal no order is on the exchange
+// until the stop is hit, at which point a market order is placed. It is safe for concurrent use.
+type TrailingStops struct {
+	client   Client
+	rules    Rules
+	interval time.Duration
+	reserve  int64
+
+	mu     sync.Mutex
+	nextId uint64
+	stops  map[string]*trailingStop
+	quotes map[string]quote
+}
+
+func NewTrailingStops(client Client, rules Rules, options ...TrailingStopOption) *TrailingStops {
+	s := &TrailingStops{
+		client:   client,
+		rules:    rules,
+		interval: time.Second,
+		reserve:  100,
+		stops:    make(map[string]*trailingStop),
+		quotes:   make(map[string]quote),
+	}
+
+	for _, opt := range options {
+		opt(s)
+	}
+
+	return s
+}
+
+// Add adds a trailing stop, it starts following the price once the first price of the market is known.
+func (s *TrailingStops) Add(ctx context.Context, order TrailingStopOrder) (TrailingStop, error) {
+	if (order.Offset > 0) == (order.Percentage > 0) || order.Offset < 0 || order.Percentage < 0 || order.Percentage >= 1 {
+		return TrailingStop{}, ErrInvalidTrail
+	}
+	order.Reference = util.IfOrElse(order.Reference.Value == "", func() bitvavo.OrderTriggerRef { return bitvavo.OrderTriggerRefLastTrade }, order.Reference)
+	order.Mode = util.IfOrElse(order.Mode.Value == "", func() TrailingMode { return TrailingModeOrder }, order.Mode)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextId++
+	stop := &trailingStop{TrailingStop: TrailingStop{Id: fmt.Sprintf("trailing-%d", s.nextId), TrailingStopOrder: order}}
+	s.stops[stop.Id] = stop
+
+	if price, ok := s.reference(order.Market, order.Reference); ok {
+		_, err := s.follow(ctx, stop, price)
+		return stop.TrailingStop, err
+	}
+	return stop.TrailingStop, nil
+}
+
+// Cancel stops following the price and cancels the stopLoss order (TrailingModeOrder).
+func (s *TrailingStops) Cancel(ctx context.Context, id string) (TrailingStop, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stop, ok := s.stops[id]
+	if !ok {
+		return TrailingStop{}, ErrUnknownTrailingStop
+	}
+	if stop.Done {
+		return stop.TrailingStop, nil
+	}
+
+	if stop.Mode == TrailingModeOrder && stop.OrderId != "" {
+		if _, err := s.client.CancelOrder(ctx, stop.Market, stop.OrderId); err != nil {
+			return stop.TrailingStop, err
+		}
+	}
+	stop.Done = true
+	return stop.TrailingStop, nil
+}
+
+// TrailingStop returns the trailing stop by id.
+func (s *TrailingStops) TrailingStop(id string) (TrailingStop, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stop, ok := s.stops[id]
+	if !ok {
+		return TrailingStop{}, false
+	}
+	return stop.TrailingStop, true
+}
+
+// TrailingStops returns all trailing stops which are not done, sorted by id.
+func (s *TrailingStops) TrailingStops() []TrailingStop {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stops := make([]TrailingStop, 0, len(s.stops))
+	for _, stop := range s.stops {
+		if !stop.Done {
+			stops = append(stops, stop.TrailingStop)
+		}
+	}
+	sort.Slice(stops, func(i, j int) bool { return stops[i].Id < stops[j].Id })
+	return stops
+}
+
+// UpdateTicker moves the trailing stops of the market and returns the stops which changed.
+func (s *TrailingStops) UpdateTicker(ctx context.Context, ticker bitvavo.Ticker) ([]TrailingStop, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.quotes[ticker.Market] = s.quotes[ticker.Market].update(ticker)
+
+	var (
+		changed = make([]TrailingStop, 0)
+		errs    []error
+	)
+	for _, stop := range s.stops {
+		if stop.Market != ticker.Market || stop.Done {
+			continue
+		}
+		price, ok := s.reference(stop.Market, stop.Reference)
+		if !ok {
+			continue
+		}
+		moved, err := s.follow(ctx, stop, price)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if moved {
+			changed = append(changed, stop.TrailingStop)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].Id < changed[j].Id })
+
+	return changed, errors.Join(errs...)
+}
+
+// UpdateOrder applies an order event, it returns false if the order is not of a trailing stop.
+func (s *TrailingStops) UpdateOrder(order bitvavo.Order) (TrailingStop, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, stop := range s.stops {
+		if stop.OrderId != order.OrderId || order.OrderId == "" {
+			continue
+		}
+		if !isOpen(order) {
+			stop.Triggered = stop.Triggered || filledOf(order) > 0
+			stop.Done = true
+		}
+		return stop.TrailingStop, true
+	}
+	return TrailingStop{}, false
+}
+
+// Listen applies ticker and order events (orders may be nil in TrailingModeLocal) and emits every trailing stop that changed.
+// The returned channel is closed when both channels are closed.
+func (s *TrailingStops) Listen(ctx context.Context, tickers <-chan bitvavo.TickerEvent, orders <-chan bitvavo.OrderEvent) <-chan TrailingStopEvent {
+	chn := make(chan TrailingStopEvent)
+	go listen(chn, from(tickers, func(ticker bitvavo.Ticker) ([]TrailingStop, error) {
+		return s.UpdateTicker(ctx, ticker)
+	}), from(orders, func(order bitvavo.Order) ([]TrailingStop, error) {
+		stop, ok := s.UpdateOrder(order)
+		return changed(stop, ok, nil)
+	}))
+	return chn
+}
+
+// follow moves the stop with price and places, updates or hits the order, it returns true if the stop changed.
+func (s *TrailingStops) follow(ctx context.Context, stop *trailingStop, price float64) (bool, error) {
+	rules, err := s.rules.Rules(stop.Market)
+	if err != nil {
+		return false, err
+	}
+
+	sell := stop.Side == bitvavo.SideSell
+	moved := false
+	if stop.Price == 0 || (sell && price > stop.Price) || (!sell && price < stop.Price) {
+		stop.Price = price
+		if next := util.ParseFloat(rules.RoundPrice(s.stopOf(stop.TrailingStopOrder, price))); stop.Stop == 0 || (sell && next > stop.Stop) || (!sell && next < stop.Stop) {
+			stop.Stop = next
+			moved = true
+		}
+	}
+
+	if stop.Mode == TrailingModeLocal {
+		if (sell && price <= stop.Stop) || (!sell && price >= stop.Stop) {
+			order, err := s.client.NewOrder(ctx, stop.Market, stop.Side, bitvavo.OrderTypeMarket, bitvavo.OrderNew{Amount: stop.Amount})
+			if err != nil {
+				return moved, err
+			}
+			stop.OrderId, stop.Triggered, stop.Done = order.OrderId, true, true
+			return true, nil
+		}
+		return moved, nil
+	}
+
+	if stop.OrderId == "" {
+		order, err := s.client.NewOrder(ctx, stop.Market, stop.Side, bitvavo.OrderTypeStopLoss, bitvavo.OrderNew{
+			Amount:           stop.Amount,
+			TriggerAmount:    util.FormatFloat(stop.Stop),
+			TriggerType:      bitvavo.OrderTriggerTypePrice,
+			TriggerReference: stop.Reference,
+		})
+		if err != nil {
+			return moved, err
+		}
+		stop.OrderId, stop.Trigger, stop.updated = order.OrderId, stop.Stop, time.Now()
+		return true, nil
+	}
+
+	if stop.Trigger == stop.Stop || time.Since(stop.updated) < s.interval || s.limited() {
+		return moved, nil
+	}
+	if _, err := s.client.UpdateOrder(ctx, stop.Market, stop.OrderId, bitvavo.OrderUpdate{TriggerAmount: util.FormatFloat(stop.Stop)}); err != nil {
+		return moved, err
+	}
+	stop.Trigger, stop.updated = stop.Stop, time.Now()
+	return true, nil
+}
+
+func (s *TrailingStops) stopOf(order TrailingStopOrder, price float64) float64 {
+	offset := util.IfOrElse(order.Offset > 0, func() float64 { return order.Offset }, price*order.Percentage)
+	return util.IfOrElse(order.Side == bitvavo.SideSell, func() float64 { return price - offset }, price+offset)
+}
+
+// reference returns the price of market the trigger is compared with.
+func (s *TrailingStops) reference(market string, ref bitvavo.OrderTriggerRef) (float64, bool) {
+	return s.quotes[market].reference(ref)
+}
+
+// limited reports whether the remaining rate limit of the client is below the reserve.
+func (s *TrailingStops) limited() bool {
+	client, ok := s.client.(rateLimited)
+	if !ok {
+		return false
+	}
+	remaining := client.GetRateLimit()
+	return remaining >= 0 && remaining < s.reserve && time.Now().Before(client.GetRateLimitResetAt())
+}
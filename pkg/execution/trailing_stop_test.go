@@ -0,0 +1,110 @@
+package execution
+
+import (
+	"context"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+func TestTrailingStopsOrder(t *testing.T) {
+	ctx := context.Background()
+	client := newPaperClient(bitvavo.WithPaperBalance("ETH", "1"))
+	stops := NewTrailingStops(client, rules{}, WithTrailingInterval(0))
+
+	stop, err := stops.Add(ctx, TrailingStopOrder{Market: "ETH-EUR", Side: bitvavo.SideSell, Amount: "1", Offset: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, "", stop.OrderId)
+
+	ticker := func(last string) []TrailingStop {
+		t.Helper()
+		client.UpdateTicker(bitvavo.Ticker{Market: "ETH-EUR", LastPrice: last})
+		changed, err := stops.UpdateTicker(ctx, bitvavo.Ticker{Market: "ETH-EUR", LastPrice: last})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return changed
+	}
+
+	changed := ticker("100")
+	test.AssertEqual(t, 1, len(changed))
+	test.AssertEqual(t, 90.0, changed[0].Stop)
+	order, _ := client.GetOrder(ctx, "ETH-EUR", changed[0].OrderId)
+	test.AssertEqual(t, bitvavo.OrderTypeStopLoss, order.OrderType)
+	test.AssertEqual(t, "90", order.TriggerAmount)
+
+	changed = ticker("110")
+	test.AssertEqual(t, 100.0, changed[0].Trigger)
+	order, _ = client.GetOrder(ctx, "ETH-EUR", order.OrderId)
+	test.AssertEqual(t, "100", order.TriggerAmount)
+
+	// the stop doesn't move down
+	test.AssertEqual(t, 0, len(ticker("105")))
+
+	// updates are postponed while the rate limit is low
+	client.PublicAPI.(*bookAPI).ratelimit = 10
+	changed = ticker("120")
+	test.AssertEqual(t, 110.0, changed[0].Stop)
+	test.AssertEqual(t, 100.0, changed[0].Trigger)
+	client.PublicAPI.(*bookAPI).ratelimit = 500
+	changed = ticker("115")
+	test.AssertEqual(t, 110.0, changed[0].Trigger)
+
+	// the stop loss order is hit
+	client.UpdateTicker(bitvavo.Ticker{Market: "ETH-EUR", BestBid: "108", BestBidSize: "5", LastPrice: "109"})
+	order, _ = client.GetOrder(ctx, "ETH-EUR", order.OrderId)
+
+	// order events have no filledAmount
+	order.FilledAmount = ""
+	orders := make(chan bitvavo.OrderEvent, 1)
+	orders <- bitvavo.OrderEvent{Value: order}
+	close(orders)
+
+	event := waitFor(t, stops.Listen(ctx, nil, orders), func(e TrailingStopEvent) bool { return true })
+	test.AssertEqual(t, nil, event.Error)
+	test.AssertEqual(t, true, event.Value.Triggered)
+	test.AssertEqual(t, true, event.Value.Done)
+	test.AssertEqual(t, 0, len(stops.TrailingStops()))
+}
+
+func TestTrailingStopsLocal(t *testing.T) {
+	ctx := context.Background()
+	client := newPaperClient(bitvavo.WithPaperBalance("EUR", "200"))
+	stops := NewTrailingStops(client, rules{})
+
+	_, err := stops.Add(ctx, TrailingStopOrder{Market: "ETH-EUR", Side: bitvavo.SideBuy, Amount: "1", Offset: 1, Percentage: 0.1})
+	test.AssertEqual(t, ErrInvalidTrail, err)
+
+	tickers := make(chan bitvavo.TickerEvent)
+	events := stops.Listen(ctx, tickers, nil)
+
+	stop, err := stops.Add(ctx, TrailingStopOrder{Market: "ETH-EUR", Side: bitvavo.SideBuy, Amount: "1", Percentage: 0.1, Mode: TrailingModeLocal})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tickers <- bitvavo.TickerEvent{Value: bitvavo.Ticker{Market: "ETH-EUR", LastPrice: "100"}}
+	test.AssertEqual(t, 110.0, waitFor(t, events, func(e TrailingStopEvent) bool { return true }).Value.Stop)
+	tickers <- bitvavo.TickerEvent{Value: bitvavo.Ticker{Market: "ETH-EUR", LastPrice: "90"}}
+	test.AssertEqual(t, 99.0, waitFor(t, events, func(e TrailingStopEvent) bool { return true }).Value.Stop)
+
+	open, _ := client.GetOrdersOpen(ctx, "ETH-EUR")
+	test.AssertEqual(t, 0, len(open))
+
+	tickers <- bitvavo.TickerEvent{Value: bitvavo.Ticker{Market: "ETH-EUR", LastPrice: "99.5"}}
+	event := waitFor(t, events, func(e TrailingStopEvent) bool { return e.Value.Done })
+	test.AssertEqual(t, stop.Id, event.Value.Id)
+	test.AssertEqual(t, true, event.Value.Triggered)
+
+	order, _ := client.GetOrder(ctx, "ETH-EUR", event.Value.OrderId)
+	test.AssertEqual(t, bitvavo.OrderTypeMarket, order.OrderType)
+	test.AssertEqual(t, bitvavo.OrderStatusFilled, order.Status)
+
+	close(tickers)
+	if _, ok := <-events; ok {
+		t.Fatal("expected closed channel")
+	}
+}
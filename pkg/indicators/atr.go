@@ -0,0 +1,59 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+type atrState struct {
+	avg   average
+	close float64
+	n     int
+}
+
+func (s atrState) clone() atrState {
+	return s
+}
+
+// ATR is the average true range using Wilder's smoothing.
+type ATR struct {
+	rev revision[atrState]
+}
+
+func NewATR(period int) (*ATR, error) {
+	if err := validatePeriod(period); err != nil {
+		return nil, err
+	}
+	return &ATR{rev: revision[atrState]{current: atrState{avg: newWilder(period)}}}, nil
+}
+
+func (a *ATR) Update(candle bitvavo.CandleOnly) (float64, bool) {
+	s := a.rev.next(candle.Timestamp)
+
+	var (
+		high = util.ParseFloat(candle.High)
+		low  = util.ParseFloat(candle.Low)
+		tr   = high - low
+	)
+
+	// the first candle has no previous close, so the true range is just the range
+	if s.n > 0 {
+		tr = max(tr, math.Abs(high-s.close), math.Abs(low-s.close))
+	}
+	s.close = closeOf(candle)
+	s.n++
+
+	return s.avg.push(tr)
+}
+
+// ATRSeries calculates the average true range for each candle (oldest first).
+// The first period-1 values are NaN.
+func ATRSeries(candles []bitvavo.CandleOnly, period int) ([]float64, error) {
+	atr, err := NewATR(period)
+	if err != nil {
+		return nil, err
+	}
+	return calculate[float64](atr, candles, math.NaN()), nil
+}
@@ -0,0 +1,48 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+type BandsValue struct {
+	Upper  float64
+	Middle float64
+	Lower  float64
+}
+
+// BollingerBands is the SMA of the close price (middle band) with bands at k standard deviations (commonly 20, 2).
+type BollingerBands struct {
+	rev revision[window]
+	k   float64
+}
+
+func NewBollingerBands(period int, k float64) (*BollingerBands, error) {
+	if err := validatePeriod(period); err != nil {
+		return nil, err
+	}
+	return &BollingerBands{rev: revision[window]{current: newWindow(period)}, k: k}, nil
+}
+
+func (b *BollingerBands) Update(candle bitvavo.CandleOnly) (BandsValue, bool) {
+	w := b.rev.next(candle.Timestamp)
+	w.push(closeOf(candle))
+
+	var (
+		middle = w.mean()
+		offset = b.k * w.stddev()
+	)
+
+	return BandsValue{Upper: middle + offset, Middle: middle, Lower: middle - offset}, w.full()
+}
+
+// BollingerSeries calculates the bollinger bands for each candle (oldest first), values are NaN until the indicator is ready.
+func BollingerSeries(candles []bitvavo.CandleOnly, period int, k float64) ([]BandsValue, error) {
+	bands, err := NewBollingerBands(period, k)
+	if err != nil {
+		return nil, err
+	}
+	nan := math.NaN()
+	return calculate[BandsValue](bands, candles, BandsValue{Upper: nan, Middle: nan, Lower: nan}), nil
+}
@@ -0,0 +1,33 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+// EMA is the exponential moving average of the close price, seeded with the SMA of the first period candles.
+type EMA struct {
+	rev revision[average]
+}
+
+func NewEMA(period int) (*EMA, error) {
+	if err := validatePeriod(period); err != nil {
+		return nil, err
+	}
+	return &EMA{rev: revision[average]{current: newEMA(period)}}, nil
+}
+
+func (e *EMA) Update(candle bitvavo.CandleOnly) (float64, bool) {
+	return e.rev.next(candle.Timestamp).push(closeOf(candle))
+}
+
+// EMASeries calculates the exponential moving average for each candle (oldest first).
+// The first period-1 values are NaN.
+func EMASeries(candles []bitvavo.CandleOnly, period int) ([]float64, error) {
+	ema, err := NewEMA(period)
+	if err != nil {
+		return nil, err
+	}
+	return calculate[float64](ema, candles, math.NaN()), nil
+}
@@ -0,0 +1,167 @@
+// Package indicators contains technical indicators which operate on the candle types of the bitvavo package.
+//
+// Every indicator is available as a streaming type (e.g: NewSMA) which can be fed candles one at a time,
+// and as a function (e.g: SMASeries) which calculates the indicator over a complete series.
+//
+// Streaming indicators understand live candle updates: the candles channel sends the candle that is
+// currently forming multiple times, an update with the same timestamp as the previous update replaces
+// that candle instead of adding a new one.
+package indicators
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+var ErrInvalidPeriod = errors.New("period must be greater than 0")
+
+// Indicator is implemented by all streaming indicators.
+type Indicator[V any] interface {
+	// Update adds a candle (or replaces the last candle if the timestamp is equal) and returns the
+	// latest value. The bool reports whether enough candles have been seen to produce a value.
+	Update(candle bitvavo.CandleOnly) (V, bool)
+}
+
+// Chronological returns a copy of candles sorted by timestamp (oldest first).
+// GetCandles returns the newest candle first, the functions in this package expect the oldest candle first.
+func Chronological(candles []bitvavo.CandleOnly) []bitvavo.CandleOnly {
+	sorted := make([]bitvavo.CandleOnly, len(candles))
+	copy(sorted, candles)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+	return sorted
+}
+
+// FromCandles converts candles from the candles listener into a series.
+func FromCandles(candles []bitvavo.Candle) []bitvavo.CandleOnly {
+	series := make([]bitvavo.CandleOnly, len(candles))
+	for i, candle := range candles {
+		series[i] = candle.CandleOnly()
+	}
+	return series
+}
+
+// calculate runs indicator over candles, values are NaN (or zero values for structs) as long as the indicator is not ready.
+func calculate[V any](indicator Indicator[V], candles []bitvavo.CandleOnly, empty V) []V {
+	values := make([]V, len(candles))
+	for i, candle := range candles {
+		if value, ok := indicator.Update(candle); ok {
+			values[i] = value
+		} else {
+			values[i] = empty
+		}
+	}
+	return values
+}
+
+// revision keeps track of the state before the last candle, so the last candle can be replaced by an update.
+type revision[S interface{ clone() S }] struct {
+	current   S
+	previous  S
+	timestamp int64
+	count     int
+}
+
+// next returns the state to apply candle with timestamp on.
+func (r *revision[S]) next(timestamp int64) *S {
+	if r.count > 0 && timestamp == r.timestamp {
+		r.current = r.previous.clone()
+	} else {
+		r.previous = r.current.clone()
+		r.timestamp = timestamp
+		r.count++
+	}
+	return &r.current
+}
+
+// window is a fixed size ring buffer of values.
+type window struct {
+	values []float64
+	pos    int
+	n      int
+}
+
+func newWindow(size int) window {
+	return window{values: make([]float64, size)}
+}
+
+func (w window) clone() window {
+	values := make([]float64, len(w.values))
+	copy(values, w.values)
+	w.values = values
+	return w
+}
+
+func (w *window) push(v float64) {
+	w.values[w.pos] = v
+	w.pos = (w.pos + 1) % len(w.values)
+	w.n = min(w.n+1, len(w.values))
+}
+
+func (w *window) full() bool {
+	return w.n == len(w.values)
+}
+
+func (w *window) mean() float64 {
+	sum := 0.0
+	for i := 0; i < w.n; i++ {
+		sum += w.values[i]
+	}
+	return sum / float64(w.n)
+}
+
+func (w *window) stddev() float64 {
+	mean := w.mean()
+	sum := 0.0
+	for i := 0; i < w.n; i++ {
+		sum += (w.values[i] - mean) * (w.values[i] - mean)
+	}
+	return math.Sqrt(sum / float64(w.n))
+}
+
+// average is an exponential moving average of values, seeded by the simple average of the first period values.
+// Wilder's smoothing is the same average with alpha 1/period.
+type average struct {
+	alpha float64
+	size  int
+	n     int
+	value float64
+}
+
+func newEMA(period int) average {
+	return average{alpha: 2 / float64(period+1), size: period}
+}
+
+func newWilder(period int) average {
+	return average{alpha: 1 / float64(period), size: period}
+}
+
+func (a average) clone() average {
+	return a
+}
+
+func (a *average) push(v float64) (float64, bool) {
+	if a.n < a.size {
+		a.n++
+		a.value += (v - a.value) / float64(a.n)
+		return a.value, a.n == a.size
+	}
+	a.value += a.alpha * (v - a.value)
+	return a.value, true
+}
+
+func validatePeriod(periods ...int) error {
+	for _, period := range periods {
+		if period <= 0 {
+			return ErrInvalidPeriod
+		}
+	}
+	return nil
+}
+
+func closeOf(candle bitvavo.CandleOnly) float64 {
+	return util.ParseFloat(candle.Close)
+}
@@ -0,0 +1,184 @@
+package indicators
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+// closes and RSI(14) from the RSI example of StockCharts (https://school.stockcharts.com), which follows Wilder
+var (
+	rsiCloses = []float64{
+		44.3389, 44.0902, 44.1497, 43.6124, 44.3278, 44.8264, 45.0955, 45.4245, 45.8433, 46.0826,
+		45.8931, 46.0328, 45.6140, 46.2820, 46.2820, 46.0028, 46.0328, 46.4116, 46.2222, 45.6439,
+		46.2122, 46.2521, 45.7137, 46.4515, 45.7835, 45.3548, 44.0288, 44.1783, 44.2181, 44.5672,
+		43.4205, 42.6628, 43.1314,
+	}
+	rsiValues = []float64{
+		70.53, 66.32, 66.55, 69.41, 66.36, 57.97, 62.93, 63.26, 56.06, 62.38,
+		54.71, 50.42, 39.99, 41.46, 41.87, 45.46, 37.30, 33.08, 37.77,
+	}
+)
+
+// closes, SMA(10) and EMA(10) from the moving averages example of StockCharts
+var (
+	averageCloses = []float64{
+		22.2734, 22.1940, 22.0847, 22.1741, 22.1840, 22.1344, 22.2337, 22.4323, 22.2436, 22.2933,
+		22.1542, 22.3926, 22.3816, 22.6109, 23.3558, 24.0519, 23.7530, 23.8324, 23.9516, 23.6338,
+		23.8225, 23.8722, 23.6537, 23.1870, 23.0976, 23.3260, 22.6805, 23.0976, 22.4025, 22.1725,
+	}
+	smaValues = []float64{
+		22.22, 22.21, 22.23, 22.26, 22.31, 22.42, 22.61, 22.77, 22.91, 23.08, 23.21,
+		23.38, 23.53, 23.65, 23.71, 23.69, 23.61, 23.51, 23.43, 23.28, 23.13,
+	}
+	emaValues = []float64{
+		22.22, 22.21, 22.24, 22.27, 22.33, 22.52, 22.80, 22.97, 23.13, 23.28, 23.34,
+		23.43, 23.51, 23.54, 23.47, 23.40, 23.39, 23.26, 23.23, 23.08, 22.92,
+	}
+)
+
+func closeCandles(closes []float64) []bitvavo.CandleOnly {
+	candles := make([]bitvavo.CandleOnly, len(closes))
+	for i, c := range closes {
+		price := strconv.FormatFloat(c, 'f', -1, 64)
+		candles[i] = bitvavo.CandleOnly{Timestamp: int64(i), Open: price, High: price, Low: price, Close: price, Volume: "1"}
+	}
+	return candles
+}
+
+// ohlcvCandles creates candles from high, low, close and volume.
+func ohlcvCandles(ohlcv ...[4]float64) []bitvavo.CandleOnly {
+	candles := make([]bitvavo.CandleOnly, len(ohlcv))
+	for i, c := range ohlcv {
+		candles[i] = bitvavo.CandleOnly{
+			Timestamp: int64(i),
+			High:      strconv.FormatFloat(c[0], 'f', -1, 64),
+			Low:       strconv.FormatFloat(c[1], 'f', -1, 64),
+			Close:     strconv.FormatFloat(c[2], 'f', -1, 64),
+			Volume:    strconv.FormatFloat(c[3], 'f', -1, 64),
+		}
+	}
+	return candles
+}
+
+func assertFloat(t *testing.T, expected float64, actual float64, tolerance float64) {
+	t.Helper()
+	if math.Abs(expected-actual) > tolerance {
+		t.Errorf("\nexpected: %v\nactual: %v\n", expected, actual)
+	}
+}
+
+// assertSeries compares the values from offset with the (rounded) reference values.
+func assertSeries(t *testing.T, expected []float64, actual []float64, offset int, tolerance float64) {
+	t.Helper()
+	test.AssertEqual(t, len(expected), len(actual)-offset)
+	for i := 0; i < offset; i++ {
+		test.AssertEqual(t, true, math.IsNaN(actual[i]))
+	}
+	for i, e := range expected {
+		assertFloat(t, e, actual[offset+i], tolerance)
+	}
+}
+
+func TestSMA(t *testing.T) {
+	values, err := SMASeries(closeCandles(averageCloses), 10)
+	test.AssertEqual(t, nil, err)
+	assertSeries(t, smaValues, values, 9, 0.005)
+}
+
+func TestEMA(t *testing.T) {
+	values, err := EMASeries(closeCandles(averageCloses), 10)
+	test.AssertEqual(t, nil, err)
+	assertSeries(t, emaValues, values, 9, 0.005)
+}
+
+func TestRSI(t *testing.T) {
+	values, err := RSISeries(closeCandles(rsiCloses), 14)
+	test.AssertEqual(t, nil, err)
+	assertSeries(t, rsiValues, values, 14, 0.005)
+}
+
+func TestMACD(t *testing.T) {
+	// fast: 10.5, 11.5, 13.1667, 13.0556
+	// slow: 11, 12.5, 12.75
+	// macd: 0.5, 0.6667, 0.3056
+	// signal: (0.5 + 0.6667) / 2 = 0.5833, 0.5833 + 2/3 * (0.3056 - 0.5833) = 0.3981
+	values, err := MACDSeries(closeCandles([]float64{10, 11, 12, 14, 13}), 2, 3, 2)
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, true, math.IsNaN(values[2].Signal))
+
+	assertFloat(t, 2.0/3, values[3].MACD, 1e-9)
+	assertFloat(t, 7.0/12, values[3].Signal, 1e-9)
+	assertFloat(t, 11.0/36, values[4].MACD, 1e-9)
+	assertFloat(t, 43.0/108, values[4].Signal, 1e-9)
+	assertFloat(t, -10.0/108, values[4].Histogram, 1e-9)
+}
+
+func TestBollingerBands(t *testing.T) {
+	// mean 3, population standard deviation sqrt(2)
+	values, err := BollingerSeries(closeCandles([]float64{1, 2, 3, 4, 5}), 5, 2)
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, true, math.IsNaN(values[3].Middle))
+
+	last := values[len(values)-1]
+	assertFloat(t, 3+2*math.Sqrt2, last.Upper, 1e-9)
+	assertFloat(t, 3, last.Middle, 1e-9)
+	assertFloat(t, 3-2*math.Sqrt2, last.Lower, 1e-9)
+}
+
+func TestATR(t *testing.T) {
+	// true range: 2, 2, 3, 1, 3
+	// ATR: (2 + 2 + 3) / 3 = 7/3, (7/3 * 2 + 1) / 3 = 17/9, (17/9 * 2 + 3) / 3 = 61/27
+	values, err := ATRSeries(ohlcvCandles(
+		[4]float64{10, 8, 9, 1},
+		[4]float64{11, 9, 10, 1},
+		[4]float64{12, 9, 11, 1},
+		[4]float64{11, 10, 10.5, 1},
+		[4]float64{13, 10, 12, 1},
+	), 3)
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, true, math.IsNaN(values[1]))
+
+	assertFloat(t, 7.0/3, values[2], 1e-9)
+	assertFloat(t, 17.0/9, values[3], 1e-9)
+	assertFloat(t, 61.0/27, values[4], 1e-9)
+}
+
+func TestVWAP(t *testing.T) {
+	// typical prices 10 and 12: (10 * 1 + 12 * 3) / 4
+	values := VWAPSeries(ohlcvCandles([4]float64{11, 9, 10, 1}, [4]float64{13, 11, 12, 3}))
+	assertFloat(t, 10, values[0], 1e-9)
+	assertFloat(t, 11.5, values[1], 1e-9)
+}
+
+func TestInvalidPeriod(t *testing.T) {
+	_, err := NewSMA(0)
+	test.AssertEqual(t, ErrInvalidPeriod, err)
+	_, err = RSISeries(closeCandles(rsiCloses), -1)
+	test.AssertEqual(t, ErrInvalidPeriod, err)
+	_, err = NewMACD(12, 26, 0)
+	test.AssertEqual(t, ErrInvalidPeriod, err)
+}
+
+func TestStreamingUpdateReplacesLastCandle(t *testing.T) {
+	candles := closeCandles(averageCloses)
+	sma, _ := NewSMA(10)
+
+	for _, candle := range candles[:len(candles)-1] {
+		sma.Update(candle)
+	}
+
+	// the forming candle gets updated a couple of times before the final close
+	last := candles[len(candles)-1]
+	for _, price := range []string{"50", "40", last.Close} {
+		last.Close = price
+		sma.Update(last)
+	}
+
+	value, ok := sma.Update(last)
+	test.AssertEqual(t, true, ok)
+	assertFloat(t, 23.13, value, 0.005)
+}
@@ -0,0 +1,67 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+type MACDValue struct {
+	// The difference between the fast and slow EMA.
+	MACD float64
+
+	// The EMA of MACD.
+	Signal float64
+
+	// MACD - Signal
+	Histogram float64
+}
+
+type macdState struct {
+	fast   average
+	slow   average
+	signal average
+}
+
+func (s macdState) clone() macdState {
+	return s
+}
+
+// MACD is the moving average convergence/divergence (commonly 12, 26, 9).
+type MACD struct {
+	rev revision[macdState]
+}
+
+func NewMACD(fast, slow, signal int) (*MACD, error) {
+	if err := validatePeriod(fast, slow, signal); err != nil {
+		return nil, err
+	}
+	return &MACD{rev: revision[macdState]{current: macdState{fast: newEMA(fast), slow: newEMA(slow), signal: newEMA(signal)}}}, nil
+}
+
+// Update returns a value once the slow EMA and the signal EMA are both ready (slow+signal-1 candles).
+func (m *MACD) Update(candle bitvavo.CandleOnly) (MACDValue, bool) {
+	s := m.rev.next(candle.Timestamp)
+
+	price := closeOf(candle)
+	fast, _ := s.fast.push(price)
+	slow, ok := s.slow.push(price)
+	if !ok {
+		return MACDValue{}, false
+	}
+
+	macd := fast - slow
+	signal, ok := s.signal.push(macd)
+
+	return MACDValue{MACD: macd, Signal: signal, Histogram: macd - signal}, ok
+}
+
+// MACDSeries calculates the MACD for each candle (oldest first), values are NaN until the indicator is ready.
+func MACDSeries(candles []bitvavo.CandleOnly, fast, slow, signal int) ([]MACDValue, error) {
+	macd, err := NewMACD(fast, slow, signal)
+	if err != nil {
+		return nil, err
+	}
+	nan := math.NaN()
+	return calculate[MACDValue](macd, candles, MACDValue{MACD: nan, Signal: nan, Histogram: nan}), nil
+}
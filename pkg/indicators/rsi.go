@@ -0,0 +1,65 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+type rsiState struct {
+	gain  average
+	loss  average
+	close float64
+	n     int
+}
+
+func (s rsiState) clone() rsiState {
+	return s
+}
+
+// RSI is the relative strength index (0-100) using Wilder's smoothing.
+type RSI struct {
+	rev revision[rsiState]
+}
+
+func NewRSI(period int) (*RSI, error) {
+	if err := validatePeriod(period); err != nil {
+		return nil, err
+	}
+	return &RSI{rev: revision[rsiState]{current: rsiState{gain: newWilder(period), loss: newWilder(period)}}}, nil
+}
+
+func (r *RSI) Update(candle bitvavo.CandleOnly) (float64, bool) {
+	s := r.rev.next(candle.Timestamp)
+
+	price := closeOf(candle)
+	s.n++
+	if s.n == 1 {
+		s.close = price
+		return 0, false
+	}
+
+	change := price - s.close
+	s.close = price
+
+	gain, ok := s.gain.push(max(change, 0))
+	loss, _ := s.loss.push(max(-change, 0))
+	if !ok {
+		return 0, false
+	}
+	if loss == 0 {
+		return 100, true
+	}
+
+	return 100 - 100/(1+gain/loss), true
+}
+
+// RSISeries calculates the relative strength index for each candle (oldest first).
+// The first period values are NaN.
+func RSISeries(candles []bitvavo.CandleOnly, period int) ([]float64, error) {
+	rsi, err := NewRSI(period)
+	if err != nil {
+		return nil, err
+	}
+	return calculate[float64](rsi, candles, math.NaN()), nil
+}
@@ -0,0 +1,35 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+// SMA is the simple moving average of the close price over period candles.
+type SMA struct {
+	rev revision[window]
+}
+
+func NewSMA(period int) (*SMA, error) {
+	if err := validatePeriod(period); err != nil {
+		return nil, err
+	}
+	return &SMA{rev: revision[window]{current: newWindow(period)}}, nil
+}
+
+func (s *SMA) Update(candle bitvavo.CandleOnly) (float64, bool) {
+	w := s.rev.next(candle.Timestamp)
+	w.push(closeOf(candle))
+	return w.mean(), w.full()
+}
+
+// SMASeries calculates the simple moving average for each candle (oldest first).
+// The first period-1 values are NaN.
+func SMASeries(candles []bitvavo.CandleOnly, period int) ([]float64, error) {
+	sma, err := NewSMA(period)
+	if err != nil {
+		return nil, err
+	}
+	return calculate[float64](sma, candles, math.NaN()), nil
+}
@@ -0,0 +1,57 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+type vwapState struct {
+	priceVolume float64
+	volume      float64
+}
+
+func (s vwapState) clone() vwapState {
+	return s
+}
+
+// VWAP is the cumulative volume weighted average of the typical price ((high+low+close)/3).
+// Call Reset to start a new session (e.g: at the start of each day).
+type VWAP struct {
+	rev revision[vwapState]
+}
+
+func NewVWAP() *VWAP {
+	return new(VWAP)
+}
+
+// Update returns false as long as there was no volume.
+func (v *VWAP) Update(candle bitvavo.CandleOnly) (float64, bool) {
+	s := v.rev.next(candle.Timestamp)
+
+	var (
+		high    = util.ParseFloat(candle.High)
+		low     = util.ParseFloat(candle.Low)
+		volume  = util.ParseFloat(candle.Volume)
+		typical = (high + low + closeOf(candle)) / 3
+	)
+
+	s.priceVolume += typical * volume
+	s.volume += volume
+	if s.volume == 0 {
+		return 0, false
+	}
+
+	return s.priceVolume / s.volume, true
+}
+
+// Reset starts a new session.
+func (v *VWAP) Reset() {
+	v.rev = revision[vwapState]{}
+}
+
+// VWAPSeries calculates the cumulative VWAP for each candle (oldest first).
+func VWAPSeries(candles []bitvavo.CandleOnly) []float64 {
+	return calculate[float64](NewVWAP(), candles, math.NaN())
+}
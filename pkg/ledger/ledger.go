@@ -0,0 +1,251 @@
+// Package ledger exports the history of an account (trades, deposits and withdrawals) as normalized ledger entries.
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+	"github.com/orsinium-labs/enum"
+)
+
+type EntryType enum.Member[string]
+
+var (
+	entryType           = enum.NewBuilder[string, EntryType]()
+	EntryTypeTrade      = entryType.Add(EntryType{"trade"})
+	EntryTypeDeposit    = entryType.Add(EntryType{"deposit"})
+	EntryTypeWithdrawal = entryType.Add(EntryType{"withdrawal"})
+	entryTypes          = entryType.Enum()
+)
+
+func (t EntryType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Value)
+}
+
+func (t *EntryType) UnmarshalJSON(bytes []byte) error {
+	var value string
+	if err := json.Unmarshal(bytes, &value); err != nil {
+		return err
+	}
+	if parsed := entryTypes.Parse(value); parsed != nil {
+		*t = *parsed
+		return nil
+	}
+	return fmt.Errorf("unknown entry type: %s", value)
+}
+
+// Entry is a single movement on the account, amounts are strings as returned by the API.
+type Entry struct {
+	// Timestamp in unix milliseconds.
+	Timestamp int64 `json:"timestamp"`
+
+	Type EntryType `json:"type"`
+
+	// The market for trades (e.g: ETH-EUR), empty otherwise.
+	Market string `json:"market,omitempty"`
+
+	// The asset and amount received, empty for withdrawals.
+	InAsset  string `json:"inAsset,omitempty"`
+	InAmount string `json:"inAmount,omitempty"`
+
+	// The asset and amount spent, empty for deposits.
+	OutAsset  string `json:"outAsset,omitempty"`
+	OutAmount string `json:"outAmount,omitempty"`
+
+	FeeAsset  string `json:"feeAsset,omitempty"`
+	FeeAmount string `json:"feeAmount,omitempty"`
+
+	// The price for trades.
+	Price string `json:"price,omitempty"`
+
+	// The fill id for trades and the transaction id for deposits and withdrawals (if any).
+	Reference string `json:"reference,omitempty"`
+
+	// The order id for trades.
+	OrderId string `json:"orderId,omitempty"`
+
+	// The status for deposits and withdrawals.
+	Status string `json:"status,omitempty"`
+}
+
+// Time returns the timestamp as time.
+func (e Entry) Time() time.Time {
+	return time.UnixMilli(e.Timestamp)
+}
+
+// TradeEntry converts a trade into an entry, a buy receives the base asset and a sell the quote asset.
+func TradeEntry(trade bitvavo.TradeHistoric) Entry {
+	base, quote, _ := strings.Cut(trade.Market, "-")
+	total := multiply(trade.Amount, trade.Price)
+
+	entry := Entry{
+		Timestamp: trade.Timestamp,
+		Type:      EntryTypeTrade,
+		Market:    trade.Market,
+		Price:     trade.Price,
+		FeeAsset:  trade.FeeCurrency,
+		FeeAmount: trade.Fee,
+		Reference: trade.FillId,
+		OrderId:   trade.OrderId,
+	}
+	if trade.Side == bitvavo.SideBuy {
+		entry.InAsset, entry.InAmount, entry.OutAsset, entry.OutAmount = base, trade.Amount, quote, total
+	} else {
+		entry.InAsset, entry.InAmount, entry.OutAsset, entry.OutAmount = quote, total, base, trade.Amount
+	}
+	return entry
+}
+
+// DepositEntry converts a deposit into an entry.
+func DepositEntry(deposit bitvavo.DepositHistory) Entry {
+	return Entry{
+		Timestamp: deposit.Timestamp,
+		Type:      EntryTypeDeposit,
+		InAsset:   deposit.Symbol,
+		InAmount:  deposit.Amount,
+		FeeAsset:  util.IfOrElse(deposit.Fee != "", func() string { return deposit.Symbol }, ""),
+		FeeAmount: deposit.Fee,
+		Reference: deposit.TxId,
+		Status:    deposit.Status,
+	}
+}
+
+// WithdrawalEntry converts a withdrawal into an entry.
+func WithdrawalEntry(withdrawal bitvavo.WithdrawalHistory) Entry {
+	return Entry{
+		Timestamp: withdrawal.Timestamp,
+		Type:      EntryTypeWithdrawal,
+		OutAsset:  withdrawal.Symbol,
+		OutAmount: withdrawal.Amount,
+		FeeAsset:  util.IfOrElse(withdrawal.Fee != "", func() string { return withdrawal.Symbol }, ""),
+		FeeAmount: withdrawal.Fee,
+		Reference: withdrawal.TxId,
+		Status:    withdrawal.Status.Value,
+	}
+}
+
+// Cursor holds how far each source (trades per market, deposits and withdrawals) has been exported,
+// as unix milliseconds. Store it (e.g: as JSON) to continue the next export where the previous ended.
+// A market which is not in the cursor is exported from the start of the history.
+type Cursor map[string]int64
+
+func (c Cursor) from(source string) time.Time {
+	return time.UnixMilli(c[source])
+}
+
+// Exporter walks the history of the account and writes it to writers.
+type Exporter struct {
+	client  bitvavo.History
+	markets []string
+}
+
+// NewExporter creates an exporter for the trades of markets (e.g: all markets of MarketRegistry) and all deposits and withdrawals.
+func NewExporter(client bitvavo.History, markets []string) *Exporter {
+	return &Exporter{client: client, markets: markets}
+}
+
+// Export writes all entries from cursor until end, sorted by timestamp, and returns the cursor to continue from.
+// An entry is exported exactly once across exports, as long as the returned cursor is stored after the writers are flushed.
+//
+// Deposits and withdrawals are exported with their status at the time of export.
+func (e *Exporter) Export(ctx context.Context, cursor Cursor, end time.Time, writers ...Writer) (Cursor, error) {
+	var (
+		entries = make([]Entry, 0)
+		next    = maps.Clone(cursor)
+	)
+	if next == nil {
+		next = make(Cursor)
+	}
+
+	for _, market := range e.markets {
+		source := "trades:" + market
+		trades, err := bitvavo.GetAllTradesHistoric(ctx, e.client, market, cursor.from(source), end)
+		if err != nil {
+			return cursor, err
+		}
+		for _, trade := range trades {
+			if trade.Market == "" {
+				trade.Market = market
+			}
+			entries = appendEntry(entries, TradeEntry(trade), cursor[source], end)
+		}
+		next[source] = end.UnixMilli()
+	}
+
+	deposits, err := bitvavo.GetAllDepositHistory(ctx, e.client, cursor.from("deposits"), end)
+	if err != nil {
+		return cursor, err
+	}
+	for _, deposit := range deposits {
+		entries = appendEntry(entries, DepositEntry(deposit), cursor["deposits"], end)
+	}
+	next["deposits"] = end.UnixMilli()
+
+	withdrawals, err := bitvavo.GetAllWithdrawalHistory(ctx, e.client, cursor.from("withdrawals"), end)
+	if err != nil {
+		return cursor, err
+	}
+	for _, withdrawal := range withdrawals {
+		entries = appendEntry(entries, WithdrawalEntry(withdrawal), cursor["withdrawals"], end)
+	}
+	next["withdrawals"] = end.UnixMilli()
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp < entries[j].Timestamp })
+	for _, entry := range entries {
+		for _, w := range writers {
+			if err := w.Write(entry); err != nil {
+				return cursor, err
+			}
+		}
+	}
+	for _, w := range writers {
+		if err := w.Flush(); err != nil {
+			return cursor, err
+		}
+	}
+
+	return next, nil
+}
+
+// appendEntry only appends entries in [from, end), the API may return entries on the boundaries.
+func appendEntry(entries []Entry, entry Entry, from int64, end time.Time) []Entry {
+	if entry.Timestamp < from || entry.Timestamp >= end.UnixMilli() {
+		return entries
+	}
+	return append(entries, entry)
+}
+
+// multiply multiplies decimal strings (e.g: amount * price) without rounding, it returns an empty string
+// if either is not a decimal.
+func multiply(a string, b string) string {
+	x, ok := new(big.Rat).SetString(a)
+	if !ok {
+		return ""
+	}
+	y, ok := new(big.Rat).SetString(b)
+	if !ok {
+		return ""
+	}
+
+	// the product of decimals has at most the decimals of both
+	product := new(big.Rat).Mul(x, y).FloatString(decimalsOf(a) + decimalsOf(b))
+	if strings.Contains(product, ".") {
+		product = strings.TrimRight(strings.TrimRight(product, "0"), ".")
+	}
+	return product
+}
+
+func decimalsOf(decimal string) int {
+	if _, fraction, ok := strings.Cut(decimal, "."); ok {
+		return len(fraction)
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+type history struct {
+	trades      []bitvavo.TradeHistoric
+	deposits    []bitvavo.DepositHistory
+	withdrawals []bitvavo.WithdrawalHistory
+}
+
+func (h *history) GetTradesHistoric(_ context.Context, _ string, params ...bitvavo.Params) ([]bitvavo.TradeHistoric, error) {
+	p := params[0].(*bitvavo.TradeParams)
+	return between(h.trades, p.Start, p.End, func(t bitvavo.TradeHistoric) int64 { return t.Timestamp }), nil
+}
+
+func (h *history) GetDepositHistory(_ context.Context, params ...bitvavo.Params) ([]bitvavo.DepositHistory, error) {
+	p := params[0].(*bitvavo.DepositHistoryParams)
+	return between(h.deposits, p.Start, p.End, func(d bitvavo.DepositHistory) int64 { return d.Timestamp }), nil
+}
+
+func (h *history) GetWithdrawalHistory(_ context.Context, params ...bitvavo.Params) ([]bitvavo.WithdrawalHistory, error) {
+	p := params[0].(*bitvavo.WithdrawalHistoryParams)
+	return between(h.withdrawals, p.Start, p.End, func(w bitvavo.WithdrawalHistory) int64 { return w.Timestamp }), nil
+}
+
+// between returns the items between start and end inclusive, like the API.
+func between[T any](items []T, start, end time.Time, timestamp func(T) int64) []T {
+	result := make([]T, 0)
+	for _, item := range items {
+		if timestamp(item) >= start.UnixMilli() && timestamp(item) <= end.UnixMilli() {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func TestExportResumes(t *testing.T) {
+	h := &history{
+		trades: []bitvavo.TradeHistoric{
+			{FillId: "f2", OrderId: "o2", Timestamp: 3000, Side: bitvavo.SideSell, Amount: "0.5", Price: "2200", Fee: "2.75", FeeCurrency: "EUR"},
+			{FillId: "f1", OrderId: "o1", Timestamp: 1000, Side: bitvavo.SideBuy, Amount: "1", Price: "2000", Fee: "5", FeeCurrency: "EUR"},
+		},
+		deposits:    []bitvavo.DepositHistory{{Timestamp: 500, Symbol: "EUR", Amount: "2500", Status: bitvavo.DepositHistoryStatusCompleted}},
+		withdrawals: []bitvavo.WithdrawalHistory{{Timestamp: 2000, Symbol: "ETH", Amount: "0.1", Fee: "0.001", TxId: "0xabc", Status: bitvavo.WithdrawalHistoryStatusCompleted}},
+	}
+	exporter := NewExporter(h, []string{"ETH-EUR"})
+
+	var csvOut, jsonOut bytes.Buffer
+	cursor, err := exporter.Export(context.Background(), nil, time.UnixMilli(2000), NewCSVWriter(&csvOut, true), NewJSONWriter(&jsonOut))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, int64(2000), cursor["trades:ETH-EUR"])
+
+	// the withdrawal at the end of the first export is exported in the second export
+	cursor, err = exporter.Export(context.Background(), cursor, time.UnixMilli(4000), NewCSVWriter(&csvOut, false), NewJSONWriter(&jsonOut))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, int64(4000), cursor["deposits"])
+
+	lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	test.AssertEqual(t, 5, len(lines))
+	test.AssertEqual(t, "500,1970-01-01T00:00:00.5Z,deposit,,EUR,2500,,,,,,,,completed", lines[1])
+	test.AssertEqual(t, "1000,1970-01-01T00:00:01Z,trade,ETH-EUR,ETH,1,EUR,2000,EUR,5,2000,f1,o1,", lines[2])
+	test.AssertEqual(t, "2000,1970-01-01T00:00:02Z,withdrawal,,,,ETH,0.1,ETH,0.001,,0xabc,,completed", lines[3])
+	test.AssertEqual(t, "3000,1970-01-01T00:00:03Z,trade,ETH-EUR,EUR,1100,ETH,0.5,EUR,2.75,2200,f2,o2,", lines[4])
+
+	test.AssertEqual(t, 4, strings.Count(jsonOut.String(), "\n"))
+	test.AssertEqual(t, true, strings.Contains(jsonOut.String(), `{"timestamp":3000,"type":"trade","market":"ETH-EUR","inAsset":"EUR","inAmount":"1100"`))
+}
+
+func TestTradeEntryTotalIsExact(t *testing.T) {
+	entry := TradeEntry(bitvavo.TradeHistoric{Market: "BTC-EUR", Side: bitvavo.SideBuy, Amount: "123456.789012", Price: "98765.4321"})
+	test.AssertEqual(t, "12193263112.4487120852", entry.OutAmount)
+
+	entry = TradeEntry(bitvavo.TradeHistoric{Market: "ETH-EUR", Side: bitvavo.SideSell, Amount: "0.1", Price: "0.2"})
+	test.AssertEqual(t, "0.02", entry.InAmount)
+}
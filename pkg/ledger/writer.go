@@ -0,0 +1,82 @@
+package ledger
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// Writer writes ledger entries (e.g: to a file).
+type Writer interface {
+	Write(entry Entry) error
+
+	// Flush writes any buffered entries.
+	Flush() error
+}
+
+var csvHeader = []string{
+	"timestamp", "time", "type", "market", "inAsset", "inAmount", "outAsset", "outAmount",
+	"feeAsset", "feeAmount", "price", "reference", "orderId", "status",
+}
+
+type CSVWriter struct {
+	writer *csv.Writer
+	header bool
+}
+
+// NewCSVWriter writes entries as CSV rows, the header is written before the first entry if header is true.
+// Pass false when appending to an existing export.
+func NewCSVWriter(w io.Writer, header bool) *CSVWriter {
+	return &CSVWriter{writer: csv.NewWriter(w), header: header}
+}
+
+func (w *CSVWriter) Write(entry Entry) error {
+	if w.header {
+		w.header = false
+		if err := w.writer.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+
+	return w.writer.Write([]string{
+		strconv.FormatInt(entry.Timestamp, 10),
+		entry.Time().UTC().Format(time.RFC3339Nano),
+		entry.Type.Value,
+		entry.Market,
+		entry.InAsset,
+		entry.InAmount,
+		entry.OutAsset,
+		entry.OutAmount,
+		entry.FeeAsset,
+		entry.FeeAmount,
+		entry.Price,
+		entry.Reference,
+		entry.OrderId,
+		entry.Status,
+	})
+}
+
+func (w *CSVWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type JSONWriter struct {
+	encoder *json.Encoder
+}
+
+// NewJSONWriter writes entries as JSON lines (one object per line), so an export can be appended to.
+func NewJSONWriter(w io.Writer) *JSONWriter {
+	return &JSONWriter{encoder: json.NewEncoder(w)}
+}
+
+func (w *JSONWriter) Write(entry Entry) error {
+	return w.encoder.Encode(entry)
+}
+
+func (w *JSONWriter) Flush() error {
+	return nil
+}
@@ -0,0 +1,55 @@
+package pnl
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+// Load applies all trades of markets, deposits and withdrawals between start and end, oldest first.
+// Trades which have been applied before are skipped, so it's safe to call Load after the fill listener started.
+func (e *Engine) Load(ctx context.Context, client bitvavo.History, markets []string, start, end time.Time) error {
+	type entry struct {
+		timestamp int64
+		apply     func()
+	}
+	entries := make([]entry, 0)
+
+	for _, market := range markets {
+		trades, err := bitvavo.GetAllTradesHistoric(ctx, client, market, start, end)
+		if err != nil {
+			return err
+		}
+		for _, trade := range trades {
+			if trade.Market == "" {
+				trade.Market = market
+			}
+			entries = append(entries, entry{trade.Timestamp, func() { e.ApplyTrade(trade) }})
+		}
+	}
+
+	deposits, err := bitvavo.GetAllDepositHistory(ctx, client, start, end)
+	if err != nil {
+		return err
+	}
+	for _, deposit := range deposits {
+		entries = append(entries, entry{deposit.Timestamp, func() { e.ApplyDeposit(deposit) }})
+	}
+
+	withdrawals, err := bitvavo.GetAllWithdrawalHistory(ctx, client, start, end)
+	if err != nil {
+		return err
+	}
+	for _, withdrawal := range withdrawals {
+		entries = append(entries, entry{withdrawal.Timestamp, func() { e.ApplyWithdrawal(withdrawal) }})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].timestamp < entries[j].timestamp })
+	for _, entry := range entries {
+		entry.apply()
+	}
+
+	return nil
+}
@@ -0,0 +1,416 @@
+// Package pnl calculates the cost basis and the realised and unrealised profit and loss of your trades.
+//
+// Positions are kept per market (e.g: ETH-EUR), so profit and loss is always in the quote currency of the market.
+// Fees paid in the quote or base currency are part of the cost basis and proceeds, fees paid in any other
+// currency are reported but not included.
+package pnl
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+	"github.com/orsinium-labs/enum"
+)
+
+// Method decides which lots are sold first.
+type Method enum.Member[string]
+
+var (
+	method = enum.NewBuilder[string, Method]()
+	// MethodFIFO sells the oldest lots first.
+	MethodFIFO = method.Add(Method{"fifo"})
+	// MethodLIFO sells the newest lots first.
+	MethodLIFO = method.Add(Method{"lifo"})
+	// MethodAverage merges all lots into a single lot with the average price.
+	MethodAverage = method.Add(Method{"average"})
+	methods       = method.Enum()
+)
+
+// dust is the amount below which floating point leftovers are ignored.
+const dust = 1e-12
+
+// ParseMethod parses fifo, lifo or average, it returns nil for any other value.
+func ParseMethod(value string) *Method {
+	return methods.Parse(value)
+}
+
+// Lot is an amount bought at the same price.
+type Lot struct {
+	// Amount in base currency.
+	Amount float64
+
+	// Cost in quote currency, including fees.
+	Cost float64
+
+	// Timestamp in unix milliseconds.
+	Timestamp int64
+}
+
+// Price returns the cost of one unit.
+func (l Lot) Price() float64 {
+	if l.Amount == 0 {
+		return 0
+	}
+	return l.Cost / l.Amount
+}
+
+type Position struct {
+	// The market (e.g: ETH-EUR)
+	Market string
+
+	Base  string
+	Quote string
+
+	// Amount held in base currency (sum of the lots).
+	Amount float64
+
+	// Cost basis of Amount in quote currency (sum of the lots).
+	Cost float64
+
+	// Realised profit and loss in quote currency.
+	Realised float64
+
+	// Fees paid in quote currency (fees paid in base currency are converted with the price of the fill).
+	Fees float64
+
+	// The open lots, oldest first.
+	Lots []Lot
+}
+
+// AveragePrice returns the average cost of one unit.
+func (p Position) AveragePrice() float64 {
+	if p.Amount == 0 {
+		return 0
+	}
+	return p.Cost / p.Amount
+}
+
+// Unrealised returns the profit and loss of the amount held if it would be sold at price.
+func (p Position) Unrealised(price float64) float64 {
+	return p.Amount*price - p.Cost
+}
+
+// Realisation is the result of applying a single fill.
+type Realisation struct {
+	FillId    string
+	Market    string
+	Side      bitvavo.Side
+	Timestamp int64
+
+	// Amount and price of the fill.
+	Amount float64
+	Price  float64
+
+	// The fee as reported by the exchange.
+	Fee         float64
+	FeeCurrency string
+
+	// The fee in quote currency, 0 if the fee was paid in another currency than base or quote.
+	FeeQuote float64
+
+	// Amount * Price minus the fee in quote currency, only for sells.
+	Proceeds float64
+
+	// The cost of the lots that have been sold, only for sells.
+	CostBasis float64
+
+	// Proceeds - CostBasis, only for sells.
+	Realised float64
+
+	// The amount sold for which no lots were found (e.g: missing history), it's sold at zero cost.
+	Uncovered float64
+}
+
+type RealisationEvent bitvavo.ListenerEvent[Realisation]
+
+// Total is the profit and loss of all positions with the same quote currency.
+type Total struct {
+	Quote      string
+	Realised   float64
+	Unrealised float64
+	Fees       float64
+}
+
+type Option func(*Engine)
+
+// WithMethod sets the method to match sells with lots.
+//
+// Default: MethodFIFO
+func WithMethod(method Method) Option {
+	return func(e *Engine) {
+		e.method = method
+	}
+}
+
+// WithQuote sets the quote currency for deposits and withdrawals, a deposit of ETH is added to the ETH-<quote> position.
+//
+// Default: "EUR"
+func WithQuote(quote string) Option {
+	return func(e *Engine) {
+		e.quote = quote
+	}
+}
+
+// WithDepositPrice sets the cost of one unit of a deposit, the exchange doesn't know what you paid for it elsewhere.
+//
+// Default: 0 (deposits have no cost)
+func WithDepositPrice(price func(symbol string, timestamp int64) float64) Option {
+	return func(e *Engine) {
+		e.depositPrice = price
+	}
+}
+
+// Engine keeps the lots per market, it is safe for concurrent use.
+type Engine struct {
+	method       Method
+	quote        string
+	depositPrice func(symbol string, timestamp int64) float64
+
+	mu        sync.Mutex
+	positions map[string]*Position
+	applied   map[string]bool
+}
+
+func New(options ...Option) *Engine {
+	e := &Engine{
+		method:       MethodFIFO,
+		quote:        "EUR",
+		depositPrice: func(string, int64) float64 { return 0 },
+		positions:    make(map[string]*Position),
+		applied:      make(map[string]bool),
+	}
+
+	for _, opt := range options {
+		opt(e)
+	}
+
+	return e
+}
+
+// ApplyFill adds a buy to the lots or matches a sell with the lots.
+// It returns false if the fill has been applied before (same fill id) or if it's not a buy or sell.
+func (e *Engine) ApplyFill(fill bitvavo.Fill) (Realisation, bool) {
+	if fill.Side != bitvavo.SideBuy && fill.Side != bitvavo.SideSell {
+		return Realisation{}, false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if fill.FillId != "" {
+		if e.applied[fill.FillId] {
+			return Realisation{}, false
+		}
+		e.applied[fill.FillId] = true
+	}
+
+	position := e.position(fill.Market)
+	r := Realisation{
+		FillId:      fill.FillId,
+		Market:      fill.Market,
+		Side:        fill.Side,
+		Timestamp:   fill.Timestamp,
+		Amount:      util.ParseFloat(fill.Amount),
+		Price:       util.ParseFloat(fill.Price),
+		Fee:         util.ParseFloat(fill.Fee),
+		FeeCurrency: fill.FeeCurrency,
+	}
+
+	var (
+		amount   = r.Amount
+		feeQuote float64
+	)
+	switch fill.FeeCurrency {
+	case position.Quote:
+		feeQuote = r.Fee
+		r.FeeQuote = r.Fee
+	case position.Base:
+		// a fee in base currency is paid with the amount bought or on top of the amount sold
+		amount = util.IfOrElse(fill.Side == bitvavo.SideBuy, func() float64 { return amount - r.Fee }, amount+r.Fee)
+		r.FeeQuote = r.Fee * r.Price
+	}
+	position.Fees += r.FeeQuote
+
+	if fill.Side == bitvavo.SideBuy {
+		e.add(position, Lot{Amount: amount, Cost: r.Amount*r.Price + feeQuote, Timestamp: fill.Timestamp})
+		return r, true
+	}
+
+	r.Proceeds = r.Amount*r.Price - feeQuote
+	r.CostBasis, r.Uncovered = e.remove(position, amount)
+	r.Realised = r.Proceeds - r.CostBasis
+	position.Realised += r.Realised
+
+	return r, true
+}
+
+// ApplyTrade applies a trade of GetTradesHistoric (see: ApplyFill)
+func (e *Engine) ApplyTrade(trade bitvavo.TradeHistoric) (Realisation, bool) {
+	return e.ApplyFill(bitvavo.Fill(trade))
+}
+
+// ApplyDeposit adds a lot with the deposited amount to the <symbol>-<quote> position (see: WithQuote and WithDepositPrice).
+// Deposits of the quote currency and canceled deposits are ignored.
+func (e *Engine) ApplyDeposit(deposit bitvavo.DepositHistory) {
+	if deposit.Symbol == e.quote || deposit.Status == bitvavo.DepositHistoryStatusCanceled {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	amount := util.ParseFloat(deposit.Amount)
+	price := e.depositPrice(deposit.Symbol, deposit.Timestamp)
+	e.add(e.position(deposit.Symbol+"-"+e.quote), Lot{Amount: amount, Cost: amount * price, Timestamp: deposit.Timestamp})
+}
+
+// ApplyWithdrawal removes the withdrawn amount from the lots of the <symbol>-<quote> position (see: WithQuote).
+// The cost of the withdrawal fee is realised as a loss, withdrawals of the quote currency and canceled withdrawals are ignored.
+func (e *Engine) ApplyWithdrawal(withdrawal bitvavo.WithdrawalHistory) {
+	if withdrawal.Symbol == e.quote || withdrawal.Status == bitvavo.WithdrawalHistoryStatusCanceled {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	position := e.position(withdrawal.Symbol + "-" + e.quote)
+	amount := util.ParseFloat(withdrawal.Amount)
+	cost, _ := e.remove(position, amount)
+	if fee := util.ParseFloat(withdrawal.Fee); fee > 0 && amount > 0 {
+		position.Realised -= cost * min(fee/amount, 1)
+	}
+}
+
+// Position returns the position of market (e.g: ETH-EUR)
+func (e *Engine) Position(market string) (Position, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	position, ok := e.positions[market]
+	if !ok {
+		return Position{}, false
+	}
+	return copyOf(position), true
+}
+
+// Positions returns all positions sorted by market.
+func (e *Engine) Positions() []Position {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	positions := make([]Position, 0, len(e.positions))
+	for _, position := range e.positions {
+		positions = append(positions, copyOf(position))
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Market < positions[j].Market })
+	return positions
+}
+
+// Totals returns the profit and loss per quote currency, unrealised profit and loss is calculated with prices
+// per market (e.g: GetTickerPrices), positions without a price are not included in Unrealised.
+func (e *Engine) Totals(prices map[string]float64) []Total {
+	totals := make(map[string]*Total)
+	for _, position := range e.Positions() {
+		total, ok := totals[position.Quote]
+		if !ok {
+			total = &Total{Quote: position.Quote}
+			totals[position.Quote] = total
+		}
+		total.Realised += position.Realised
+		total.Fees += position.Fees
+		if price, ok := prices[position.Market]; ok {
+			total.Unrealised += position.Unrealised(price)
+		}
+	}
+
+	result := make([]Total, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Quote < result[j].Quote })
+	return result
+}
+
+// Listen applies fill events (see: bitvavo.FillListener) and emits a realisation for every fill that has not been applied before.
+// The returned channel is closed when events is closed.
+func (e *Engine) Listen(events <-chan bitvavo.FillEvent) <-chan RealisationEvent {
+	chn := make(chan RealisationEvent)
+
+	go func() {
+		defer close(chn)
+
+		for event := range events {
+			if event.Error != nil {
+				chn <- RealisationEvent{Error: event.Error}
+				continue
+			}
+			if r, ok := e.ApplyFill(event.Value); ok {
+				chn <- RealisationEvent{Value: r}
+			}
+		}
+	}()
+
+	return chn
+}
+
+func (e *Engine) position(market string) *Position {
+	position, ok := e.positions[market]
+	if !ok {
+		base, quote, _ := strings.Cut(market, "-")
+		position = &Position{Market: market, Base: base, Quote: quote}
+		e.positions[market] = position
+	}
+	return position
+}
+
+func (e *Engine) add(position *Position, lot Lot) {
+	position.Amount += lot.Amount
+	position.Cost += lot.Cost
+
+	if e.method == MethodAverage && len(position.Lots) > 0 {
+		position.Lots[0].Amount += lot.Amount
+		position.Lots[0].Cost += lot.Cost
+		return
+	}
+	position.Lots = append(position.Lots, lot)
+}
+
+// remove takes amount from the lots, it returns the cost of the removed lots and the amount for which there were no lots.
+func (e *Engine) remove(position *Position, amount float64) (float64, float64) {
+	var cost float64
+	for amount > dust && len(position.Lots) > 0 {
+		i := util.IfOrElse(e.method == MethodLIFO, func() int { return len(position.Lots) - 1 }, 0)
+		lot := &position.Lots[i]
+
+		if lot.Amount <= amount+dust {
+			cost += lot.Cost
+			amount -= lot.Amount
+			position.Lots = append(position.Lots[:i], position.Lots[i+1:]...)
+			continue
+		}
+
+		taken := lot.Cost * amount / lot.Amount
+		lot.Cost -= taken
+		lot.Amount -= amount
+		cost += taken
+		amount = 0
+	}
+
+	position.Amount, position.Cost = 0, 0
+	for _, lot := range position.Lots {
+		position.Amount += lot.Amount
+		position.Cost += lot.Cost
+	}
+
+	return cost, util.IfOrElse(amount > dust, func() float64 { return amount }, 0)
+}
+
+func copyOf(position *Position) Position {
+	p := *position
+	p.Lots = append([]Lot(nil), position.Lots...)
+	return p
+}
@@ -0,0 +1,119 @@
+package pnl
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+func round(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+func fill(id string, side bitvavo.Side, amount string, price string, fee string) bitvavo.Fill {
+	return bitvavo.Fill{FillId: id, Market: "ETH-EUR", Side: side, Amount: amount, Price: price, Fee: fee, FeeCurrency: "EUR"}
+}
+
+func TestMethods(t *testing.T) {
+	tests := []struct {
+		method   Method
+		realised float64
+		cost     float64
+	}{
+		// sells 1 @ 100 (+1 fee) and 0.5 @ 200
+		{MethodFIFO, 300 - 1 - 101 - 100, 100},
+		// sells 1 @ 200, 0.5 @ 100 (+1 fee)
+		{MethodLIFO, 300 - 1 - 200 - 50.5, 50.5},
+		// average price (101 + 200) / 2
+		{MethodAverage, 300 - 1 - 150.5*1.5, 150.5 / 2},
+	}
+
+	for _, tt := range tests {
+		e := New(WithMethod(tt.method))
+		e.ApplyFill(fill("1", bitvavo.SideBuy, "1", "100", "1"))
+		e.ApplyFill(fill("2", bitvavo.SideBuy, "1", "200", "0"))
+		r, ok := e.ApplyFill(fill("3", bitvavo.SideSell, "1.5", "200", "1"))
+
+		test.AssertEqual(t, true, ok)
+		test.AssertEqual(t, round(tt.realised), round(r.Realised))
+
+		position, _ := e.Position("ETH-EUR")
+		test.AssertEqual(t, 0.5, round(position.Amount))
+		test.AssertEqual(t, round(tt.cost), round(position.Cost))
+		test.AssertEqual(t, 2.0, position.Fees)
+		test.AssertEqual(t, round(150-tt.cost), round(position.Unrealised(300)))
+	}
+}
+
+func TestFeeInBaseAndUncovered(t *testing.T) {
+	e := New()
+	e.ApplyFill(bitvavo.Fill{FillId: "1", Market: "ETH-EUR", Side: bitvavo.SideBuy, Amount: "1", Price: "100", Fee: "0.01", FeeCurrency: "ETH"})
+
+	position, _ := e.Position("ETH-EUR")
+	test.AssertEqual(t, 0.99, round(position.Amount))
+	test.AssertEqual(t, 100.0, position.Cost)
+	test.AssertEqual(t, 1.0, round(position.Fees))
+
+	r, _ := e.ApplyFill(fill("2", bitvavo.SideSell, "1", "110", ""))
+	test.AssertEqual(t, 0.01, round(r.Uncovered))
+	test.AssertEqual(t, 10.0, round(r.Realised))
+
+	// duplicate
+	_, ok := e.ApplyFill(fill("2", bitvavo.SideSell, "1", "110", ""))
+	test.AssertEqual(t, false, ok)
+}
+
+type history struct {
+	trades []bitvavo.TradeHistoric
+}
+
+func (h *history) GetTradesHistoric(context.Context, string, ...bitvavo.Params) ([]bitvavo.TradeHistoric, error) {
+	return h.trades, nil
+}
+
+func (h *history) GetDepositHistory(context.Context, ...bitvavo.Params) ([]bitvavo.DepositHistory, error) {
+	return []bitvavo.DepositHistory{
+		{Timestamp: 1, Symbol: "ETH", Amount: "1"},
+		{Timestamp: 1, Symbol: "ETH", Amount: "5", Status: bitvavo.DepositHistoryStatusCanceled},
+	}, nil
+}
+
+func (h *history) GetWithdrawalHistory(context.Context, ...bitvavo.Params) ([]bitvavo.WithdrawalHistory, error) {
+	return []bitvavo.WithdrawalHistory{{Timestamp: 4, Symbol: "ETH", Amount: "0.5", Fee: "0.05"}}, nil
+}
+
+func TestLoadAndListen(t *testing.T) {
+	e := New(WithDepositPrice(func(symbol string, timestamp int64) float64 { return 50 }))
+	err := e.Load(context.Background(), &history{trades: []bitvavo.TradeHistoric{
+		{FillId: "3", Side: bitvavo.SideSell, Amount: "1", Price: "150", Timestamp: 3},
+		{FillId: "2", Side: bitvavo.SideBuy, Amount: "1", Price: "100", Timestamp: 2},
+	}}, []string{"ETH-EUR"}, time.UnixMilli(0), time.UnixMilli(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// deposit @ 50 is sold first, the withdrawal removes half of the 100 lot and realises the fee
+	position, _ := e.Position("ETH-EUR")
+	test.AssertEqual(t, 0.5, round(position.Amount))
+	test.AssertEqual(t, 50.0, round(position.Cost))
+	test.AssertEqual(t, 95.0, round(position.Realised))
+
+	events := make(chan bitvavo.FillEvent, 2)
+	events <- bitvavo.FillEvent{Value: bitvavo.Fill{FillId: "3", Market: "ETH-EUR", Side: bitvavo.SideSell, Amount: "1", Price: "150"}}
+	events <- bitvavo.FillEvent{Value: bitvavo.Fill{FillId: "4", Market: "ETH-EUR", Side: bitvavo.SideSell, Amount: "0.5", Price: "120"}}
+	close(events)
+
+	realisations := e.Listen(events)
+	test.AssertEqual(t, 10.0, round((<-realisations).Value.Realised))
+	_, ok := <-realisations
+	test.AssertEqual(t, false, ok)
+
+	totals := e.Totals(map[string]float64{"ETH-EUR": 120})
+	test.AssertEqual(t, 1, len(totals))
+	test.AssertEqual(t, 105.0, round(totals[0].Realised))
+	test.AssertEqual(t, 0.0, round(totals[0].Unrealised))
+}
@@ -0,0 +1,338 @@
+// Package portfolio values the balances of an account in a reference currency (e.g: EUR) using ticker prices.
+//
+// Holdings without a direct market to the reference currency are valued through intermediate markets,
+// for example: SOL -> SOL-BTC -> BTC-EUR -> EUR.
+package portfolio
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+// Client is the part of bitvavo.PrivateAPI the portfolio needs.
+type Client interface {
+	// GetBalance returns the balance on the account.
+	GetBalance(ctx context.Context, symbol ...string) ([]bitvavo.Balance, error)
+
+	// GetTickerPrices returns the latest trade price for all markets.
+	GetTickerPrices(ctx context.Context) ([]bitvavo.TickerPrice, error)
+}
+
+type Holding struct {
+	// The asset (e.g: ETH)
+	Symbol string
+
+	// Balance freely available.
+	Available float64
+
+	// Balance on hold for open orders.
+	InOrder float64
+
+	// Available + InOrder
+	Total float64
+
+	// The price of one unit in the quote currency, 0 if the holding could not be priced.
+	Price float64
+
+	// Total * Price
+	Value float64
+
+	// Value as fraction of the equity (0..1)
+	Allocation float64
+
+	// The markets used to price the holding (e.g: [SOL-BTC BTC-EUR]), empty for the quote currency itself.
+	Route []string
+
+	// False if there is no route to the quote currency, the holding is not part of the equity.
+	Priced bool
+}
+
+type Valuation struct {
+	// The currency in which the holdings are valued (e.g: EUR)
+	Quote string
+
+	// Sum of the value of all priced holdings.
+	Equity float64
+
+	// Holdings sorted by value, highest first.
+	Holdings []Holding
+
+	Timestamp time.Time
+}
+
+type ValuationEvent bitvavo.ListenerEvent[Valuation]
+
+type Option func(*Portfolio)
+
+// WithQuote values the portfolio in quote.
+//
+// Default: "EUR"
+func WithQuote(quote string) Option {
+	return func(p *Portfolio) {
+		p.quote = quote
+	}
+}
+
+// WithLastPrice prices markets with the last traded price instead of the middle of the best bid and best ask.
+func WithLastPrice() Option {
+	return func(p *Portfolio) {
+		p.lastPrice = true
+	}
+}
+
+// Portfolio holds the balances and the latest prices, it is safe for concurrent use.
+type Portfolio struct {
+	client    Client
+	quote     string
+	lastPrice bool
+
+	mu       sync.RWMutex
+	balances map[string]bitvavo.Balance
+	quotes   map[string]*quote
+}
+
+type quote struct {
+	bid  float64
+	ask  float64
+	last float64
+}
+
+func New(client Client, options ...Option) *Portfolio {
+	p := &Portfolio{
+		client:   client,
+		quote:    "EUR",
+		balances: make(map[string]bitvavo.Balance),
+		quotes:   make(map[string]*quote),
+	}
+
+	for _, opt := range options {
+		opt(p)
+	}
+
+	return p
+}
+
+// Refresh loads the balances and the latest trade prices of all markets.
+func (p *Portfolio) Refresh(ctx context.Context) error {
+	if err := p.RefreshBalances(ctx); err != nil {
+		return err
+	}
+
+	prices, err := p.client.GetTickerPrices(ctx)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, price := range prices {
+		p.quoteOf(price.Market).last = util.ParseFloat(price.Price)
+	}
+	return nil
+}
+
+// RefreshBalances only loads the balances, e.g: after an order has been filled.
+func (p *Portfolio) RefreshBalances(ctx context.Context) error {
+	balances, err := p.client.GetBalance(ctx)
+	if err != nil {
+		return err
+	}
+	p.SetBalances(balances)
+	return nil
+}
+
+// SetBalances replaces all balances.
+func (p *Portfolio) SetBalances(balances []bitvavo.Balance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	clear(p.balances)
+	for _, balance := range balances {
+		p.balances[balance.Symbol] = balance
+	}
+}
+
+// UpdateTicker updates the prices of a market with an event of the bitvavo.TickerListener.
+// Fields which are not in the ticker (only changes are sent) keep their previous value.
+func (p *Portfolio) UpdateTicker(ticker bitvavo.Ticker) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	q := p.quoteOf(ticker.Market)
+	if ticker.BestBid != "" {
+		q.bid = util.ParseFloat(ticker.BestBid)
+	}
+	if ticker.BestAsk != "" {
+		q.ask = util.ParseFloat(ticker.BestAsk)
+	}
+	if ticker.LastPrice != "" {
+		q.last = util.ParseFloat(ticker.LastPrice)
+	}
+}
+
+// Valuation values all balances in the quote currency.
+func (p *Portfolio) Valuation() Valuation {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	valuation := Valuation{
+		Quote:     p.quote,
+		Holdings:  make([]Holding, 0, len(p.balances)),
+		Timestamp: time.Now(),
+	}
+
+	for symbol, balance := range p.balances {
+		holding := Holding{
+			Symbol:    symbol,
+			Available: util.ParseFloat(balance.Available),
+			InOrder:   util.ParseFloat(balance.InOrder),
+		}
+		holding.Total = holding.Available + holding.InOrder
+		if holding.Total == 0 {
+			continue
+		}
+
+		if price, route, ok := p.route(symbol); ok {
+			holding.Price = price
+			holding.Value = holding.Total * price
+			holding.Route = route
+			holding.Priced = true
+			valuation.Equity += holding.Value
+		}
+		valuation.Holdings = append(valuation.Holdings, holding)
+	}
+
+	for i := range valuation.Holdings {
+		if valuation.Equity > 0 {
+			valuation.Holdings[i].Allocation = valuation.Holdings[i].Value / valuation.Equity
+		}
+	}
+	sort.Slice(valuation.Holdings, func(i, j int) bool {
+		a, b := valuation.Holdings[i], valuation.Holdings[j]
+		if a.Value == b.Value {
+			return a.Symbol < b.Symbol
+		}
+		return a.Value > b.Value
+	})
+
+	return valuation
+}
+
+// Listen updates the prices with ticker events and emits a new valuation when the equity or an allocation changed.
+// The returned channel is closed when events is closed.
+func (p *Portfolio) Listen(events <-chan bitvavo.TickerEvent) <-chan ValuationEvent {
+	chn := make(chan ValuationEvent)
+
+	go func() {
+		defer close(chn)
+
+		var previous Valuation
+		for event := range events {
+			if event.Error != nil {
+				chn <- ValuationEvent{Error: event.Error}
+				continue
+			}
+
+			p.UpdateTicker(event.Value)
+			if valuation := p.Valuation(); changed(previous, valuation) {
+				previous = valuation
+				chn <- ValuationEvent{Value: valuation}
+			}
+		}
+	}()
+
+	return chn
+}
+
+func (p *Portfolio) quoteOf(market string) *quote {
+	q, ok := p.quotes[market]
+	if !ok {
+		q = new(quote)
+		p.quotes[market] = q
+	}
+	return q
+}
+
+func (p *Portfolio) price(q *quote) float64 {
+	if !p.lastPrice && q.bid > 0 && q.ask > 0 {
+		return (q.bid + q.ask) / 2
+	}
+	return q.last
+}
+
+// route finds the shortest chain of priced markets from symbol to the quote currency (breadth first).
+func (p *Portfolio) route(symbol string) (float64, []string, bool) {
+	if symbol == p.quote {
+		return 1, nil, true
+	}
+
+	type edge struct {
+		to     string
+		market string
+		rate   float64
+	}
+	edges := make(map[string][]edge)
+	for market, q := range p.quotes {
+		base, quote, ok := strings.Cut(market, "-")
+		price := p.price(q)
+		if !ok || price <= 0 {
+			continue
+		}
+		edges[base] = append(edges[base], edge{to: quote, market: market, rate: price})
+		edges[quote] = append(edges[quote], edge{to: base, market: market, rate: 1 / price})
+	}
+	for _, e := range edges {
+		// deterministic routes when there are multiple of the same length
+		sort.Slice(e, func(i, j int) bool { return e[i].market < e[j].market })
+	}
+
+	type node struct {
+		symbol string
+		rate   float64
+		route  []string
+	}
+	visited := map[string]bool{symbol: true}
+	queue := []node{{symbol: symbol, rate: 1}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, e := range edges[current.symbol] {
+			if visited[e.to] {
+				continue
+			}
+			next := node{symbol: e.to, rate: current.rate * e.rate, route: append(append([]string{}, current.route...), e.market)}
+			if next.symbol == p.quote {
+				return next.rate, next.route, true
+			}
+			visited[e.to] = true
+			queue = append(queue, next)
+		}
+	}
+
+	return 0, nil, false
+}
+
+func changed(previous Valuation, current Valuation) bool {
+	if len(previous.Holdings) != len(current.Holdings) || !equal(previous.Equity, current.Equity) {
+		return true
+	}
+	for i := range current.Holdings {
+		if previous.Holdings[i].Symbol != current.Holdings[i].Symbol || !equal(previous.Holdings[i].Allocation, current.Holdings[i].Allocation) {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(a float64, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(math.Abs(a), math.Abs(b))
+}
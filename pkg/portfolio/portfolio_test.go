@@ -0,0 +1,73 @@
+package portfolio
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+type client struct {
+	balances []bitvavo.Balance
+	prices   []bitvavo.TickerPrice
+}
+
+func (c *client) GetBalance(context.Context, ...string) ([]bitvavo.Balance, error) {
+	return c.balances, nil
+}
+
+func (c *client) GetTickerPrices(context.Context) ([]bitvavo.TickerPrice, error) {
+	return c.prices, nil
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+func TestValuation(t *testing.T) {
+	p := New(&client{
+		balances: []bitvavo.Balance{
+			{Symbol: "EUR", Available: "500", InOrder: "500"},
+			{Symbol: "BTC", Available: "0.01", InOrder: "0"},
+			{Symbol: "SOL", Available: "10", InOrder: "0"},
+			{Symbol: "XYZ", Available: "1", InOrder: "0"},
+		},
+		prices: []bitvavo.TickerPrice{
+			{Market: "BTC-EUR", Price: "50000"},
+			{Market: "SOL-BTC", Price: "0.002"},
+		},
+	})
+	if err := p.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	valuation := p.Valuation()
+	test.AssertEqual(t, 2500.0, round(valuation.Equity))
+	test.AssertEqual(t, 4, len(valuation.Holdings))
+
+	eur, sol, btc, xyz := valuation.Holdings[0], valuation.Holdings[1], valuation.Holdings[2], valuation.Holdings[3]
+	test.AssertEqual(t, "EUR", eur.Symbol)
+	test.AssertEqual(t, 0.4, round(eur.Allocation))
+	test.AssertEqual(t, "SOL", sol.Symbol)
+	test.AssertEqual(t, 100.0, round(sol.Price))
+	test.AssertEqual(t, "SOL-BTC,BTC-EUR", sol.Route[0]+","+sol.Route[1])
+	test.AssertEqual(t, 500.0, round(btc.Value))
+	test.AssertEqual(t, false, xyz.Priced)
+
+	events := make(chan bitvavo.TickerEvent, 2)
+	valuations := p.Listen(events)
+
+	events <- bitvavo.TickerEvent{Value: bitvavo.Ticker{Market: "BTC-EUR", BestBid: "59990", BestAsk: "60010"}}
+	test.AssertEqual(t, 2800.0, round((<-valuations).Value.Equity))
+
+	// only the size changed
+	events <- bitvavo.TickerEvent{Value: bitvavo.Ticker{Market: "BTC-EUR", BestBidSize: "2"}}
+	events <- bitvavo.TickerEvent{Value: bitvavo.Ticker{Market: "SOL-BTC", LastPrice: "0.001"}}
+	close(events)
+	test.AssertEqual(t, 2200.0, round((<-valuations).Value.Equity))
+
+	_, ok := <-valuations
+	test.AssertEqual(t, false, ok)
+}
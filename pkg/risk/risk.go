@@ -0,0 +1,423 @@
+// Package risk wraps a bitvavo.PrivateAPI with limits on the orders it sends and a kill switch,
+// to protect the account from a runaway strategy.
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/util"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+	"github.com/larscom/bitvavo-go/v2/pkg/pnl"
+)
+
+var (
+	ErrKilled             = errors.New("the kill switch is active")
+	ErrMaxNotional        = errors.New("max order notional exceeded")
+	ErrMaxPosition        = errors.New("max position exceeded")
+	ErrMaxOpenOrders      = errors.New("max open orders exceeded")
+	ErrMaxOrdersPerMinute = errors.New("max orders per minute exceeded")
+	ErrDailyLossLimit     = errors.New("daily loss limit reached")
+	ErrNoFills            = errors.New("the daily loss limit needs the fills (see: Client.Listen)")
+)
+
+var _ bitvavo.PrivateAPI = (*Client)(nil)
+
+// LimitError is returned when an order would exceed a limit, errors.Is matches the limit (e.g: ErrMaxNotional).
+type LimitError struct {
+	Err    error
+	Market string
+
+	// The value the order would reach and the limit.
+	Value float64
+	Limit float64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s for %s: %s (limit %s)", e.Err, e.Market, util.FormatFloat(e.Value), util.FormatFloat(e.Limit))
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+type Option func(*Client)
+
+// WithMaxNotional limits the value of a single order in quote currency.
+//
+// Default: 0 (no limit)
+func WithMaxNotional(notional float64) Option {
+	return func(c *Client) {
+		c.maxNotional = notional
+	}
+}
+
+// WithMaxPosition limits the amount of symbol (e.g: ETH) which is held, including the open buy orders of the market.
+//
+// Default: no limit
+func WithMaxPosition(symbol string, amount float64) Option {
+	return func(c *Client) {
+		c.maxPositions[symbol] = amount
+	}
+}
+
+// WithMaxOpenOrders limits the number of open orders per market.
+//
+// Default: 0 (no limit)
+func WithMaxOpenOrders(orders int) Option {
+	return func(c *Client) {
+		c.maxOpenOrders = orders
+	}
+}
+
+// WithMaxOrdersPerMinute limits the number of new orders which are sent within a minute.
+//
+// Default: 0 (no limit)
+func WithMaxOrdersPerMinute(orders int) Option {
+	return func(c *Client) {
+		c.maxOrdersPerMinute = orders
+	}
+}
+
+// WithDailyLossLimit rejects new orders once the realised loss of the markets in quote (e.g: EUR) reaches loss
+// on the current day (UTC). The realised profit and loss is calculated from the fills (see: Client.UpdateFill).
+//
+// IMPORTANT: the limit fails closed, new orders are rejected with ErrNoFills until the fills are fed with
+// Client.Listen or a fill has been applied with Client.UpdateFill.
+//
+// Default: 0 (no limit)
+func WithDailyLossLimit(quote string, loss float64) Option {
+	return func(c *Client) {
+		c.lossQuote = quote
+		c.maxLoss = loss
+	}
+}
+
+// WithPnL sets the engine which calculates the realised profit and loss of the fills, use an engine which has
+// loaded the trade history (see: pnl.Engine.Load) to know the cost of positions opened before.
+//
+// Default: pnl.New()
+func WithPnL(engine *pnl.Engine) Option {
+	return func(c *Client) {
+		c.pnl = engine
+	}
+}
+
+// Client checks every new order against the limits before it is sent and returns a LimitError if it would exceed one.
+// New orders are sent one at a time, so concurrent orders can't exceed a limit together. All other calls go straight
+// to the wrapped client.
+//
+// It is safe for concurrent use.
+type Client struct {
+	bitvavo.PrivateAPI
+
+	maxNotional        float64
+	maxPositions       map[string]float64
+	maxOpenOrders      int
+	maxOrdersPerMinute int
+	lossQuote          string
+	maxLoss            float64
+	pnl                *pnl.Engine
+	now                func() time.Time
+
+	// sends one order at a time
+	orders sync.Mutex
+
+	mu     sync.Mutex
+	killed bool
+	sent   []time.Time
+	day    time.Time
+	loss   float64
+	fed    bool
+}
+
+func New(client bitvavo.PrivateAPI, options ...Option) *Client {
+	c := &Client{
+		PrivateAPI:   client,
+		maxPositions: make(map[string]float64),
+		pnl:          pnl.New(),
+		now:          time.Now,
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	return c
+}
+
+// NewOrder checks the kill switch and the limits and places the order.
+func (c *Client) NewOrder(ctx context.Context, market string, side bitvavo.Side, orderType bitvavo.OrderType, order bitvavo.OrderNew) (bitvavo.Order, error) {
+	_, limitPosition := c.maxPosition(market, side)
+	price, err := c.price(ctx, market, order, limitPosition)
+	if err != nil {
+		return bitvavo.Order{}, err
+	}
+
+	c.orders.Lock()
+	defer c.orders.Unlock()
+
+	if err := c.admit(market); err != nil {
+		return bitvavo.Order{}, err
+	}
+	if err := c.check(ctx, market, side, order, price); err != nil {
+		return bitvavo.Order{}, err
+	}
+
+	c.mu.Lock()
+	c.sent = append(c.sent, c.now())
+	c.mu.Unlock()
+
+	return c.PrivateAPI.NewOrder(ctx, market, side, orderType, order)
+}
+
+// UpdateOrder checks the kill switch and the max notional of the updated order and updates the order.
+func (c *Client) UpdateOrder(ctx context.Context, market string, orderId string, order bitvavo.OrderUpdate) (bitvavo.Order, error) {
+	var (
+		update bitvavo.OrderNew
+		price  float64
+		check  = c.maxNotional > 0 && (order.Amount != "" || order.AmountRemaining != "" || order.AmountQuote != "" || order.Price != "")
+	)
+	if check {
+		current, err := c.PrivateAPI.GetOrder(ctx, market, orderId)
+		if err != nil {
+			return bitvavo.Order{}, err
+		}
+		update = bitvavo.OrderNew{
+			Amount:      util.IfOrElse(order.Amount != "", func() string { return order.Amount }, util.IfOrElse(order.AmountRemaining != "", func() string { return order.AmountRemaining }, current.AmountRemaining)),
+			AmountQuote: order.AmountQuote,
+			Price:       util.IfOrElse(order.Price != "", func() string { return order.Price }, current.Price),
+		}
+		if price, err = c.price(ctx, market, update, false); err != nil {
+			return bitvavo.Order{}, err
+		}
+	}
+
+	c.orders.Lock()
+	defer c.orders.Unlock()
+
+	if c.Killed() {
+		return bitvavo.Order{}, ErrKilled
+	}
+	if check {
+		if _, err := c.notional(market, update, price); err != nil {
+			return bitvavo.Order{}, err
+		}
+	}
+
+	return c.PrivateAPI.UpdateOrder(ctx, market, orderId, order)
+}
+
+// Kill activates the kill switch: new orders are rejected with ErrKilled and the open orders of all markets are canceled.
+// It returns the ids of the canceled orders.
+func (c *Client) Kill(ctx context.Context) ([]string, error) {
+	c.mu.Lock()
+	c.killed = true
+	c.mu.Unlock()
+
+	// waits for an order which is being sent, so it's canceled as well
+	c.orders.Lock()
+	c.orders.Unlock()
+
+	return c.PrivateAPI.CancelOrders(ctx)
+}
+
+// Resume deactivates the kill switch.
+func (c *Client) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.killed = false
+}
+
+// Killed returns true when the kill switch is active.
+func (c *Client) Killed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.killed
+}
+
+// UpdateFill applies a fill to the realised profit and loss of the day (see: WithDailyLossLimit).
+func (c *Client) UpdateFill(fill bitvavo.Fill) {
+	c.mu.Lock()
+	c.fed = true
+	c.mu.Unlock()
+
+	realisation, ok := c.pnl.ApplyFill(fill)
+	if !ok || realisation.Realised == 0 {
+		return
+	}
+	if _, quote, _ := strings.Cut(fill.Market, "-"); quote != c.lossQuote {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rollover()
+	c.loss -= realisation.Realised
+}
+
+// DailyLoss returns the realised loss of the current day (UTC), negative for a profit.
+func (c *Client) DailyLoss() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rollover()
+	return c.loss
+}
+
+// Listen applies fill events (see: bitvavo.FillListener) and forwards them, the returned channel is closed when events is closed.
+func (c *Client) Listen(events <-chan bitvavo.FillEvent) <-chan bitvavo.FillEvent {
+	c.mu.Lock()
+	c.fed = true
+	c.mu.Unlock()
+
+	chn := make(chan bitvavo.FillEvent)
+
+	go func() {
+		defer close(chn)
+
+		for event := range events {
+			if event.Error == nil {
+				c.UpdateFill(event.Value)
+			}
+			chn <- event
+		}
+	}()
+
+	return chn
+}
+
+// admit checks the kill switch, the daily loss and the orders per minute.
+func (c *Client) admit(market string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.killed {
+		return ErrKilled
+	}
+
+	if c.maxLoss > 0 {
+		if !c.fed {
+			return ErrNoFills
+		}
+		if c.rollover(); c.loss >= c.maxLoss {
+			return &LimitError{Err: ErrDailyLossLimit, Market: market, Value: c.loss, Limit: c.maxLoss}
+		}
+	}
+
+	if c.maxOrdersPerMinute > 0 {
+		start := c.now().Add(-time.Minute)
+		i := 0
+		for i < len(c.sent) && !c.sent[i].After(start) {
+			i++
+		}
+		c.sent = c.sent[i:]
+		if len(c.sent) >= c.maxOrdersPerMinute {
+			return &LimitError{Err: ErrMaxOrdersPerMinute, Market: market, Value: float64(len(c.sent) + 1), Limit: float64(c.maxOrdersPerMinute)}
+		}
+	}
+	return nil
+}
+
+// check checks the limits which need the order, the open orders or the balance.
+func (c *Client) check(ctx context.Context, market string, side bitvavo.Side, order bitvavo.OrderNew, price float64) error {
+	maxPosition, limitPosition := c.maxPosition(market, side)
+
+	amount, err := c.notional(market, order, price)
+	if err != nil {
+		return err
+	}
+	if c.maxOpenOrders <= 0 && !limitPosition {
+		return nil
+	}
+
+	open, err := c.PrivateAPI.GetOrdersOpen(ctx, market)
+	if err != nil {
+		return err
+	}
+	if c.maxOpenOrders > 0 && len(open) >= c.maxOpenOrders {
+		return &LimitError{Err: ErrMaxOpenOrders, Market: market, Value: float64(len(open) + 1), Limit: float64(c.maxOpenOrders)}
+	}
+	if !limitPosition {
+		return nil
+	}
+
+	base, _, _ := strings.Cut(market, "-")
+	balances, err := c.PrivateAPI.GetBalance(ctx, base)
+	if err != nil {
+		return err
+	}
+	position := amount
+	for _, balance := range balances {
+		position += util.ParseFloat(balance.Available) + util.ParseFloat(balance.InOrder)
+	}
+	for _, o := range open {
+		if o.Side == bitvavo.SideBuy {
+			position += util.ParseFloat(o.AmountRemaining)
+		}
+	}
+	if position > maxPosition {
+		return &LimitError{Err: ErrMaxPosition, Market: market, Value: position, Limit: maxPosition}
+	}
+	return nil
+}
+
+// maxPosition returns the max position of the base currency of market, if it's limited for an order on side.
+func (c *Client) maxPosition(market string, side bitvavo.Side) (float64, bool) {
+	base, _, _ := strings.Cut(market, "-")
+	maxPosition, ok := c.maxPositions[base]
+	return maxPosition, ok && side == bitvavo.SideBuy
+}
+
+// price returns the price of order, or the latest price of market for an order without a price if the limits need it
+// (the amount if needAmount). It's called before an order is sent, so a slow request doesn't hold up other orders.
+func (c *Client) price(ctx context.Context, market string, order bitvavo.OrderNew, needAmount bool) (float64, error) {
+	var (
+		amount      = util.ParseFloat(order.Amount)
+		amountQuote = util.ParseFloat(order.AmountQuote)
+		price       = util.ParseFloat(util.IfOrElse(order.Price != "", func() string { return order.Price }, order.TriggerAmount))
+	)
+
+	if price <= 0 && ((needAmount && amount <= 0) || (c.maxNotional > 0 && amountQuote <= 0)) {
+		ticker, err := c.PrivateAPI.GetTickerPrice(ctx, market)
+		if err != nil {
+			return 0, err
+		}
+		price = util.ParseFloat(ticker.Price)
+	}
+	return price, nil
+}
+
+// notional checks the max notional of order at price (see: Client.price) and returns its amount in base currency.
+func (c *Client) notional(market string, order bitvavo.OrderNew, price float64) (float64, error) {
+	var (
+		amount      = util.ParseFloat(order.Amount)
+		amountQuote = util.ParseFloat(order.AmountQuote)
+	)
+
+	if amount <= 0 && price > 0 {
+		amount = amountQuote / price
+	}
+
+	notional := util.IfOrElse(amountQuote > 0, func() float64 { return amountQuote }, amount*price)
+	if c.maxNotional > 0 && notional > c.maxNotional {
+		return 0, &LimitError{Err: ErrMaxNotional, Market: market, Value: notional, Limit: c.maxNotional}
+	}
+	return amount, nil
+}
+
+// rollover resets the loss when a new day (UTC) started.
+func (c *Client) rollover() {
+	day := c.now().UTC().Truncate(24 * time.Hour)
+	if !day.Equal(c.day) {
+		c.day = day
+		c.loss = 0
+	}
+}
@@ -0,0 +1,161 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/larscom/bitvavo-go/v2/internal/test"
+	"github.com/larscom/bitvavo-go/v2/pkg/bitvavo"
+)
+
+type bookAPI struct {
+	bitvavo.PublicAPI
+
+	// GetTickerPrice sends on ticker when called and waits for a receive to return, if set
+	ticker chan struct{}
+}
+
+func (a *bookAPI) GetOrderBook(_ context.Context, _ string, _ ...uint64) (bitvavo.Book, error) {
+	return bitvavo.Book{
+		Nonce: 1,
+		Asks:  []bitvavo.Page{{Price: "101", Size: "100"}},
+		Bids:  []bitvavo.Page{{Price: "100", Size: "100"}},
+	}, nil
+}
+
+func (a *bookAPI) GetTickerPrice(_ context.Context, market string) (bitvavo.TickerPrice, error) {
+	if a.ticker != nil {
+		a.ticker <- struct{}{}
+		<-a.ticker
+	}
+	return bitvavo.TickerPrice{Market: market, Price: "100"}, nil
+}
+
+func newClient(options ...Option) *Client {
+	return New(bitvavo.NewPaperClient(&bookAPI{}, bitvavo.WithPaperBalance("EUR", "100000")), options...)
+}
+
+func buy(client *Client, amount string, price string) error {
+	orderType := bitvavo.OrderTypeLimit
+	if price == "" {
+		orderType = bitvavo.OrderTypeMarket
+	}
+	_, err := client.NewOrder(context.Background(), "ETH-EUR", bitvavo.SideBuy, orderType, bitvavo.OrderNew{Amount: amount, Price: price})
+	return err
+}
+
+func TestMaxNotional(t *testing.T) {
+	client := newClient(WithMaxNotional(1000))
+
+	err := buy(client, "20", "90")
+	test.AssertEqual(t, true, errors.Is(err, ErrMaxNotional))
+
+	var limitErr *LimitError
+	test.AssertEqual(t, true, errors.As(err, &limitErr))
+	test.AssertEqual(t, 1800.0, limitErr.Value)
+
+	// the latest price is used for a market order
+	test.AssertEqual(t, true, errors.Is(buy(client, "11", ""), ErrMaxNotional))
+	test.AssertEqual(t, nil, buy(client, "9", ""))
+
+	_, err = client.NewOrder(context.Background(), "ETH-EUR", bitvavo.SideBuy, bitvavo.OrderTypeMarket, bitvavo.OrderNew{AmountQuote: "1001"})
+	test.AssertEqual(t, true, errors.Is(err, ErrMaxNotional))
+}
+
+func TestMaxOpenOrdersAndPosition(t *testing.T) {
+	client := newClient(WithMaxOpenOrders(3), WithMaxPosition("ETH", 5))
+
+	test.AssertEqual(t, nil, buy(client, "2", ""))
+	test.AssertEqual(t, nil, buy(client, "1", "90"))
+
+	// 2 held + 1 open + 3
+	test.AssertEqual(t, true, errors.Is(buy(client, "3", "90"), ErrMaxPosition))
+	test.AssertEqual(t, nil, buy(client, "1", "90"))
+	test.AssertEqual(t, nil, buy(client, "0.5", "90"))
+	test.AssertEqual(t, true, errors.Is(buy(client, "0.1", "90"), ErrMaxOpenOrders))
+}
+
+func TestMaxOrdersPerMinute(t *testing.T) {
+	client := newClient(WithMaxOrdersPerMinute(2))
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	test.AssertEqual(t, nil, buy(client, "1", "90"))
+	test.AssertEqual(t, nil, buy(client, "1", "90"))
+	test.AssertEqual(t, true, errors.Is(buy(client, "1", "90"), ErrMaxOrdersPerMinute))
+
+	now = now.Add(time.Minute + time.Second)
+	test.AssertEqual(t, nil, buy(client, "1", "90"))
+}
+
+func TestDailyLossLimit(t *testing.T) {
+	client := newClient(WithDailyLossLimit("EUR", 10))
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	client.now = func() time.Time { return now }
+
+	client.UpdateFill(bitvavo.Fill{FillId: "1", Market: "ETH-EUR", Side: bitvavo.SideBuy, Amount: "1", Price: "100"})
+	client.UpdateFill(bitvavo.Fill{FillId: "2", Market: "ETH-EUR", Side: bitvavo.SideSell, Amount: "1", Price: "80"})
+	test.AssertEqual(t, 20.0, client.DailyLoss())
+	test.AssertEqual(t, true, errors.Is(buy(client, "1", "90"), ErrDailyLossLimit))
+
+	now = now.Add(12 * time.Hour)
+	test.AssertEqual(t, 0.0, client.DailyLoss())
+	test.AssertEqual(t, nil, buy(client, "1", "90"))
+}
+
+func TestDailyLossLimitNeedsFills(t *testing.T) {
+	client := newClient(WithDailyLossLimit("EUR", 10))
+	test.AssertEqual(t, ErrNoFills, buy(client, "1", "90"))
+
+	fills := make(chan bitvavo.FillEvent)
+	defer close(fills)
+	client.Listen(fills)
+	test.AssertEqual(t, nil, buy(client, "1", "90"))
+}
+
+func TestKill(t *testing.T) {
+	client := newClient()
+
+	test.AssertEqual(t, nil, buy(client, "1", "90"))
+	test.AssertEqual(t, nil, buy(client, "1", "95"))
+
+	canceled, err := client.Kill(context.Background())
+	test.AssertEqual(t, nil, err)
+	test.AssertEqual(t, 2, len(canceled))
+	test.AssertEqual(t, true, client.Killed())
+	test.AssertEqual(t, ErrKilled, buy(client, "1", "90"))
+
+	_, err = client.UpdateOrder(context.Background(), "ETH-EUR", canceled[0], bitvavo.OrderUpdate{Price: "91"})
+	test.AssertEqual(t, ErrKilled, err)
+
+	client.Resume()
+	test.AssertEqual(t, nil, buy(client, "1", "90"))
+}
+
+func TestKillDoesNotWaitForTicker(t *testing.T) {
+	api := &bookAPI{ticker: make(chan struct{})}
+	client := New(bitvavo.NewPaperClient(api, bitvavo.WithPaperBalance("EUR", "100000")), WithMaxNotional(1000))
+
+	// a market order waits for the latest price
+	done := make(chan error)
+	go func() {
+		done <- buy(client, "1", "")
+	}()
+	<-api.ticker
+
+	killed := make(chan struct{})
+	go func() {
+		_, _ = client.Kill(context.Background())
+		close(killed)
+	}()
+	select {
+	case <-killed:
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+
+	api.ticker <- struct{}{}
+	test.AssertEqual(t, ErrKilled, <-done)
+}